@@ -9,8 +9,6 @@ import (
 	"sort"
 	"strings"
 
-	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
 	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider/types"
 )
 
@@ -18,6 +16,7 @@ var oauthConfig *OAuthConfig
 
 func SetOAuthConfig(config *OAuthConfig) {
 	oauthConfig = config
+	defaultManager.tokenCache.clear()
 }
 
 func GetOAuthConfig() *OAuthConfig {
@@ -26,8 +25,13 @@ func GetOAuthConfig() *OAuthConfig {
 
 // getRoleAttributeName returns the configured Cognito custom attribute name for user role.
 func getRoleAttributeName() string {
-	if oauthConfig != nil && oauthConfig.RoleAttributeName != "" {
-		return oauthConfig.RoleAttributeName
+	return roleAttributeName(oauthConfig)
+}
+
+// roleAttributeName returns config's role attribute name, defaulting to custom:role.
+func roleAttributeName(config *OAuthConfig) string {
+	if config != nil && config.RoleAttributeName != "" {
+		return config.RoleAttributeName
 	}
 	return "custom:role"
 }
@@ -82,66 +86,129 @@ func normalizeCustomAttributes(attrs map[string]string, roleAttrName string) (ma
 	return normalized, nil
 }
 
-func GetUser(ctx context.Context, email string) (*User, error) {
+// newUserAttributes builds the attribute set written for a new user: email
+// (pre-verified), optional profile fields, the role attribute (defaulting to
+// "user") and any normalized custom attributes.
+func newUserAttributes(req CreateUserRequest, roleAttr string) (map[string]string, error) {
+	attributes := map[string]string{
+		"email":          req.Email,
+		"email_verified": "true",
+	}
+
+	if req.GivenName != "" {
+		attributes["given_name"] = req.GivenName
+	}
+	if req.FamilyName != "" {
+		attributes["family_name"] = req.FamilyName
+	}
+	if req.Name != "" {
+		attributes["name"] = req.Name
+	}
+	if req.Picture != "" {
+		attributes["picture"] = req.Picture
+	}
+
+	role := req.Role
+	if role == "" {
+		role = "user"
+	}
+	attributes[roleAttr] = role
+
+	normAttrs, err := normalizeCustomAttributes(req.CustomAttributes, roleAttr)
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range normAttrs {
+		attributes[name] = value
+	}
+
+	return attributes, nil
+}
+
+// profileUpdateAttributes returns the attributes changed by a profile update.
+func profileUpdateAttributes(update ProfileUpdate) map[string]string {
+	attributes := make(map[string]string)
+	if update.GivenName != nil {
+		attributes["given_name"] = *update.GivenName
+	}
+	if update.FamilyName != nil {
+		attributes["family_name"] = *update.FamilyName
+	}
+	if update.Name != nil {
+		attributes["name"] = *update.Name
+	}
+	if update.Picture != nil {
+		attributes["picture"] = *update.Picture
+	}
+	return attributes
+}
+
+func (m *Manager) GetUser(ctx context.Context, email string) (*User, error) {
 	if email == "" {
 		return nil, fmt.Errorf("email cannot be empty: %w", ErrInvalidInput)
 	}
 
-	if oauthConfig == nil {
-		return nil, fmt.Errorf("oauth config is not set")
+	store, err := m.userStore()
+	if err != nil {
+		return nil, err
 	}
 
-	cognitoUser, err := cognitoGetUser(ctx, email, oauthConfig)
+	record, err := store.GetUser(ctx, email)
 	if err != nil {
 		return nil, err
 	}
 
-	return cognitoUserToUser(*cognitoUser)
+	user, err := recordToUser(record, m.roleAttributeName())
+	if err != nil {
+		return nil, err
+	}
+	m.addGroupMembership(ctx, user)
+	return user, nil
 }
 
-// CreateUser provisions a Cognito user without setting a temporary password.
+// CreateUser provisions a user without setting a temporary password.
 // Post-create verification and rollback on failure are provided by
 // CreateUserWithInvitation only; CreateUser does not verify or roll back.
-func CreateUser(ctx context.Context, req CreateUserRequest) (*User, error) {
+func (m *Manager) CreateUser(ctx context.Context, req CreateUserRequest) (*User, error) {
 	if req.Email == "" {
 		return nil, fmt.Errorf("email is required: %w", ErrInvalidInput)
 	}
 
-	if oauthConfig == nil {
-		return nil, fmt.Errorf("oauth config is not set")
+	store, err := m.userStore()
+	if err != nil {
+		return nil, err
+	}
+
+	attributes, err := newUserAttributes(req, m.roleAttributeName())
+	if err != nil {
+		return nil, err
+	}
+	if err := m.validateNewUserAttributes(attributes); err != nil {
+		return nil, err
 	}
 
-	cognitoUser, err := cognitoCreateUser(ctx, req, oauthConfig)
+	record, err := store.CreateUser(ctx, req.Email, attributes)
 	if err != nil {
 		return nil, err
 	}
 
-	return cognitoUserToUser(*cognitoUser)
+	return recordToUser(record, m.roleAttributeName())
 }
 
-func UpdateProfile(ctx context.Context, email string, update ProfileUpdate) (*User, error) {
+func (m *Manager) UpdateProfile(ctx context.Context, email string, update ProfileUpdate) (*User, error) {
 	if email == "" {
 		return nil, fmt.Errorf("email cannot be empty: %w", ErrInvalidInput)
 	}
 
-	if oauthConfig == nil {
-		return nil, fmt.Errorf("oauth config is not set")
-	}
-
-	attributes := userToCognitoAttributes(nil, &update)
+	attributes := profileUpdateAttributes(update)
 	if len(attributes) == 0 {
-		return GetUser(ctx, email)
+		return m.GetUser(ctx, email)
 	}
 
-	err := cognitoUpdateUserAttributes(ctx, email, attributes, oauthConfig)
-	if err != nil {
-		return nil, err
-	}
-
-	return GetUser(ctx, email)
+	return m.updateAttributes(ctx, email, attributes)
 }
 
-func UpdateRole(ctx context.Context, email string, role string) (*User, error) {
+func (m *Manager) UpdateRole(ctx context.Context, email string, role string) (*User, error) {
 	if email == "" {
 		return nil, fmt.Errorf("email cannot be empty: %w", ErrInvalidInput)
 	}
@@ -150,27 +217,19 @@ func UpdateRole(ctx context.Context, email string, role string) (*User, error) {
 		return nil, fmt.Errorf("role cannot be empty: %w", ErrInvalidInput)
 	}
 
-	if oauthConfig == nil {
-		return nil, fmt.Errorf("oauth config is not set")
-	}
-
-	attributes := []types.AttributeType{
-		{
-			Name:  aws.String(getRoleAttributeName()),
-			Value: aws.String(role),
-		},
-	}
-
-	if err := cognitoUpdateUserAttributes(ctx, email, attributes, oauthConfig); err != nil {
+	user, err := m.updateUserAttribute(ctx, email, m.roleAttributeName(), role)
+	if err != nil {
 		return nil, err
 	}
 
-	return GetUser(ctx, email)
+	// Tokens issued before the change still carry the old role
+	m.signOutOnAccountChange(ctx, email, "UpdateRole")
+	return user, nil
 }
 
-// UpdateTenantID updates the user's custom:tenantId attribute in Cognito.
+// UpdateTenantID updates the user's custom:tenantId attribute.
 // This requires the user to logout and login again to get a fresh JWT with the new tenant.
-func UpdateTenantID(ctx context.Context, email string, tenantID string) (*User, error) {
+func (m *Manager) UpdateTenantID(ctx context.Context, email string, tenantID string) (*User, error) {
 	if email == "" {
 		return nil, fmt.Errorf("email cannot be empty: %w", ErrInvalidInput)
 	}
@@ -179,53 +238,52 @@ func UpdateTenantID(ctx context.Context, email string, tenantID string) (*User,
 		return nil, fmt.Errorf("tenantID cannot be empty: %w", ErrInvalidInput)
 	}
 
-	if oauthConfig == nil {
-		return nil, fmt.Errorf("oauth config is not set")
-	}
+	return m.updateUserAttribute(ctx, email, "custom:tenantId", tenantID)
+}
 
-	attributes := []types.AttributeType{
-		{
-			Name:  aws.String("custom:tenantId"),
-			Value: aws.String(tenantID),
-		},
+// DisableUser disables a user account.
+func (m *Manager) DisableUser(ctx context.Context, email string) error {
+	if email == "" {
+		return fmt.Errorf("email cannot be empty: %w", ErrInvalidInput)
 	}
 
-	err := cognitoUpdateUserAttributes(ctx, email, attributes, oauthConfig)
+	store, err := m.userStore()
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	return GetUser(ctx, email)
-}
-
-// DisableUser disables a user account in Cognito.
-func DisableUser(ctx context.Context, email string) error {
-	if email == "" {
-		return fmt.Errorf("email cannot be empty: %w", ErrInvalidInput)
-	}
+	// Cognito refuses to sign out a disabled user, so sign out first
+	m.signOutOnAccountChange(ctx, email, "DisableUser")
 
-	if oauthConfig == nil {
-		return fmt.Errorf("oauth config is not set")
+	if err := store.DisableUser(ctx, email); err != nil {
+		return err
 	}
 
-	return cognitoDisableUser(ctx, email, oauthConfig)
+	m.tokenCache.invalidateUser(email)
+	return nil
 }
 
-// EnableUser re-enables a disabled user account in Cognito.
-func EnableUser(ctx context.Context, email string) error {
+// EnableUser re-enables a disabled user account.
+func (m *Manager) EnableUser(ctx context.Context, email string) error {
 	if email == "" {
 		return fmt.Errorf("email cannot be empty: %w", ErrInvalidInput)
 	}
 
-	if oauthConfig == nil {
-		return fmt.Errorf("oauth config is not set")
+	store, err := m.userStore()
+	if err != nil {
+		return err
+	}
+
+	if err := store.EnableUser(ctx, email); err != nil {
+		return err
 	}
 
-	return cognitoEnableUser(ctx, email, oauthConfig)
+	m.tokenCache.invalidateUser(email)
+	return nil
 }
 
-// UpdateServiceProviderID updates the user's custom:serviceProviderId attribute in Cognito.
-func UpdateServiceProviderID(ctx context.Context, email string, serviceProviderID string) (*User, error) {
+// UpdateServiceProviderID updates the user's custom:serviceProviderId attribute.
+func (m *Manager) UpdateServiceProviderID(ctx context.Context, email string, serviceProviderID string) (*User, error) {
 	if email == "" {
 		return nil, fmt.Errorf("email cannot be empty: %w", ErrInvalidInput)
 	}
@@ -234,52 +292,60 @@ func UpdateServiceProviderID(ctx context.Context, email string, serviceProviderI
 		return nil, fmt.Errorf("serviceProviderID cannot be empty: %w", ErrInvalidInput)
 	}
 
-	if oauthConfig == nil {
-		return nil, fmt.Errorf("oauth config is not set")
-	}
-
-	attributes := []types.AttributeType{
-		{
-			Name:  aws.String("custom:serviceProviderId"),
-			Value: aws.String(serviceProviderID),
-		},
-	}
-
-	err := cognitoUpdateUserAttributes(ctx, email, attributes, oauthConfig)
-	if err != nil {
-		return nil, err
-	}
+	return m.updateUserAttribute(ctx, email, "custom:serviceProviderId", serviceProviderID)
+}
 
-	return GetUser(ctx, email)
+// updateUserAttribute sets a single attribute and returns the refreshed user.
+func (m *Manager) updateUserAttribute(ctx context.Context, email, name, value string) (*User, error) {
+	return m.updateAttributes(ctx, email, map[string]string{name: value})
 }
 
-// SetUserPassword sets a user's password in Cognito.
+// SetUserPassword sets a user's password.
 // If permanent is false, the user must change it on next login (FORCE_CHANGE_PASSWORD state).
-func SetUserPassword(ctx context.Context, email string, password string, permanent bool) error {
+// A password breaking GetPasswordPolicy returns a ValidationError listing every rule it breaks.
+func (m *Manager) SetUserPassword(ctx context.Context, email string, password string, permanent bool) error {
 	if email == "" {
 		return fmt.Errorf("email cannot be empty: %w", ErrInvalidInput)
 	}
+	if err := m.GetPasswordPolicy(ctx).Validate(password); err != nil {
+		return err
+	}
+
+	store, err := m.userStore()
+	if err != nil {
+		return err
+	}
 
-	if oauthConfig == nil {
-		return fmt.Errorf("oauth config is not set")
+	if err := store.SetPassword(ctx, email, password, permanent); err != nil {
+		return err
 	}
 
-	return cognitoSetUserPassword(ctx, email, password, permanent, oauthConfig)
+	m.signOutOnAccountChange(ctx, email, "SetUserPassword")
+	return nil
 }
 
-func DeleteUser(ctx context.Context, email string) error {
+func (m *Manager) DeleteUser(ctx context.Context, email string) error {
 	if email == "" {
 		return fmt.Errorf("email cannot be empty: %w", ErrInvalidInput)
 	}
 
-	if oauthConfig == nil {
-		return fmt.Errorf("oauth config is not set")
+	store, err := m.userStore()
+	if err != nil {
+		return err
+	}
+
+	// The user's sub is needed for the revocation, so sign out first
+	m.signOutOnAccountChange(ctx, email, "DeleteUser")
+
+	if err := store.DeleteUser(ctx, email); err != nil {
+		return err
 	}
 
-	return cognitoDeleteUser(ctx, email, oauthConfig)
+	m.tokenCache.invalidateUser(email)
+	return nil
 }
 
-func ListUsers(ctx context.Context, limit, offset int) ([]*User, error) {
+func (m *Manager) ListUsers(ctx context.Context, limit, offset int) ([]*User, error) {
 	if limit <= 0 {
 		limit = 20
 	}
@@ -290,55 +356,58 @@ func ListUsers(ctx context.Context, limit, offset int) ([]*User, error) {
 		offset = 0
 	}
 
-	if oauthConfig == nil {
-		return nil, fmt.Errorf("oauth config is not set")
+	store, err := m.userStore()
+	if err != nil {
+		return nil, err
 	}
 
-	var allUsers []types.UserType
-	var paginationToken *string
-	limit32 := int32(limit + offset)
+	var allRecords []*UserRecord
+	pageToken := ""
 
 	for {
-		users, nextToken, err := cognitoListUsers(ctx, limit32, paginationToken, oauthConfig)
+		records, nextToken, err := store.ListUsers(ctx, limit+offset, pageToken)
 		if err != nil {
 			return nil, err
 		}
 
-		allUsers = append(allUsers, users...)
+		allRecords = append(allRecords, records...)
 
-		if nextToken == nil || len(allUsers) >= limit+offset {
+		if nextToken == "" || len(allRecords) >= limit+offset {
 			break
 		}
 
-		paginationToken = nextToken
+		pageToken = nextToken
 	}
 
-	if offset > 0 && len(allUsers) > offset {
-		allUsers = allUsers[offset:]
+	if offset > 0 && len(allRecords) > offset {
+		allRecords = allRecords[offset:]
 	}
-	if len(allUsers) > limit {
-		allUsers = allUsers[:limit]
-	}
-
-	result := make([]*User, 0, len(allUsers))
-	for _, cognitoUser := range allUsers {
-		user, err := cognitoUserToUser(cognitoUser)
-		if err != nil {
-			continue
-		}
-		result = append(result, user)
+	if len(allRecords) > limit {
+		allRecords = allRecords[:limit]
 	}
 
-	return result, nil
+	return recordsToUsers(allRecords, m.roleAttributeName()), nil
 }
 
-func GenerateAPIKey(ctx context.Context, email string) (string, error) {
-	if email == "" {
-		return "", fmt.Errorf("email cannot be empty: %w", ErrInvalidInput)
-	}
+// GenerateAPIKey creates the user's default API key, replacing any previous
+// default key immediately. Named keys created with CreateAPIKey are kept.
+func (m *Manager) GenerateAPIKey(ctx context.Context, email string) (string, error) {
+	var apiKey string
+	err := m.updateAPIKeys(ctx, email, func(prefix string, keys []storedAPIKey) (string, []storedAPIKey, error) {
+		kept := make([]storedAPIKey, 0, len(keys)+1)
+		for _, k := range keys {
+			if k.Name != defaultAPIKeyName {
+				kept = append(kept, k)
+			}
+		}
 
-	apiKey := generateSecureAPIKey()
-	err := UpdateAPIKey(ctx, email, apiKey)
+		apiKey = newAPIKey(prefix)
+		stored, err := newStoredAPIKey(apiKey, defaultAPIKeyName, nil, nil)
+		if err != nil {
+			return "", nil, err
+		}
+		return apiKey[:apiKeyLookupLength], append(kept, stored), nil
+	})
 	if err != nil {
 		return "", err
 	}
@@ -346,12 +415,14 @@ func GenerateAPIKey(ctx context.Context, email string) (string, error) {
 	return apiKey, nil
 }
 
-func GetAPIKey(ctx context.Context, email string) (string, error) {
+// GetAPIKey returns the public lookup prefix of the user's API key. The raw key
+// is only stored as a salted hash and is returned once, by GenerateAPIKey.
+func (m *Manager) GetAPIKey(ctx context.Context, email string) (string, error) {
 	if email == "" {
 		return "", fmt.Errorf("email cannot be empty: %w", ErrInvalidInput)
 	}
 
-	user, err := GetUser(ctx, email)
+	user, err := m.GetUser(ctx, email)
 	if err != nil {
 		return "", err
 	}
@@ -359,11 +430,25 @@ func GetAPIKey(ctx context.Context, email string) (string, error) {
 	return user.APIKey, nil
 }
 
-func RotateAPIKey(ctx context.Context, email string) (string, error) {
-	return GenerateAPIKey(ctx, email)
+// RotateAPIKey replaces the user's default API key. The previous key keeps
+// working for OAuthConfig.APIKeyRotationGraceSeconds (24 hours by default).
+func (m *Manager) RotateAPIKey(ctx context.Context, email string) (string, error) {
+	keyID, err := m.defaultAPIKeyID(ctx, email)
+	if err != nil {
+		return "", err
+	}
+	if keyID == "" {
+		return m.GenerateAPIKey(ctx, email)
+	}
+
+	apiKey, _, err := m.RotateAPIKeyByID(ctx, email, keyID, m.apiKeyRotationGrace())
+	return apiKey, err
 }
 
-func UpdateAPIKey(ctx context.Context, email string, apiKey string) error {
+// UpdateAPIKey makes apiKey the user's only API key, stored as a lookup prefix
+// plus salted hash. Any other keys are revoked. apiKey must be in the
+// usr_<hex> format produced by GenerateAPIKey.
+func (m *Manager) UpdateAPIKey(ctx context.Context, email string, apiKey string) error {
 	if email == "" {
 		return fmt.Errorf("email cannot be empty: %w", ErrInvalidInput)
 	}
@@ -372,110 +457,116 @@ func UpdateAPIKey(ctx context.Context, email string, apiKey string) error {
 		return fmt.Errorf("apiKey cannot be empty: %w", ErrInvalidInput)
 	}
 
-	if oauthConfig == nil {
-		return fmt.Errorf("oauth config is not set")
+	store, err := m.userStore()
+	if err != nil {
+		return err
 	}
 
-	attrName := tokenAttributeName
-	if !strings.HasPrefix(attrName, "custom:") {
-		attrName = "custom:" + attrName
+	attributes, err := apiKeyAttributes(apiKey)
+	if err != nil {
+		return err
 	}
 
-	attributes := []types.AttributeType{
-		{
-			Name:  aws.String(attrName),
-			Value: aws.String(apiKey),
-		},
+	unlock := apiKeyLocks.lock(email)
+	err = store.UpdateAttributes(ctx, email, attributes)
+	unlock()
+	if err != nil {
+		return err
 	}
 
-	return cognitoUpdateUserAttributes(ctx, email, attributes, oauthConfig)
+	m.tokenCache.invalidateUser(email)
+	m.tokenCache.invalidateToken(apiKey)
+	return nil
 }
 
-func ValidateAPIKey(ctx context.Context, apiKey string) (*User, error) {
+func (m *Manager) ValidateAPIKey(ctx context.Context, apiKey string) (*User, error) {
 	if apiKey == "" {
 		return nil, ErrInvalidAPIKey
 	}
 
-	if oauthConfig == nil {
-		return nil, fmt.Errorf("oauth config is not set")
-	}
-
-	filter := fmt.Sprintf("%s = \"%s\"", tokenAttributeName, apiKey)
-	input := &cognitoidentityprovider.ListUsersInput{
-		UserPoolId: aws.String(oauthConfig.UserPoolID),
-		Filter:     aws.String(filter),
-		Limit:      aws.Int32(2),
-	}
-
-	client, err := getCognitoClient(ctx, oauthConfig)
+	store, err := m.userStore()
 	if err != nil {
 		return nil, err
 	}
 
-	result, err := client.ListUsers(ctx, input)
+	matches, err := matchAPIKey(ctx, store, apiKey)
 	if err != nil {
-		return nil, wrapCognitoError(err, "ListUsers")
+		return nil, err
 	}
 
-	if len(result.Users) == 0 {
+	if len(matches) == 0 {
 		return nil, ErrInvalidAPIKey
 	}
 
-	if len(result.Users) > 1 {
+	if len(matches) > 1 {
 		return nil, fmt.Errorf("multiple users found with same API key")
 	}
 
-	return cognitoUserToUser(result.Users[0])
+	touchAPIKey(ctx, store, matches[0])
+	return recordToUser(matches[0].record, m.roleAttributeName())
 }
 
-func FindUserByToken(ctx context.Context, token string) (*Claims, error) {
-	return FindUserClaimsByToken(ctx, token, oauthConfig)
+func (m *Manager) FindUserByToken(ctx context.Context, token string) (*Claims, error) {
+	store, err := m.userStore()
+	if err != nil {
+		return nil, err
+	}
+
+	return findClaimsByToken(ctx, store, token, m.Config())
 }
 
-func CreateUserWithInvitation(ctx context.Context, req CreateUserRequest) (*User, string, error) {
+func (m *Manager) CreateUserWithInvitation(ctx context.Context, req CreateUserRequest) (*User, string, error) {
 	if req.Email == "" {
 		return nil, "", fmt.Errorf("email is required: %w", ErrInvalidInput)
 	}
 
-	if oauthConfig == nil {
-		return nil, "", fmt.Errorf("oauth config is not set")
+	store, err := m.userStore()
+	if err != nil {
+		return nil, "", err
 	}
 
-	_, err := cognitoCreateUser(ctx, req, oauthConfig)
+	attributes, err := newUserAttributes(req, m.roleAttributeName())
 	if err != nil {
 		return nil, "", err
 	}
+	if err := m.validateNewUserAttributes(attributes); err != nil {
+		return nil, "", err
+	}
+
+	if _, err := store.CreateUser(ctx, req.Email, attributes); err != nil {
+		return nil, "", err
+	}
 
 	rollback := func() {
-		if delErr := cognitoDeleteUser(ctx, req.Email, oauthConfig); delErr != nil {
-			log.Printf("⚠️ [Cognito] rollback AdminDeleteUser failed for %s: %v", req.Email, delErr)
+		if delErr := store.DeleteUser(ctx, req.Email); delErr != nil {
+			log.Printf("⚠️ [UserStore] rollback DeleteUser failed for %s: %v", req.Email, delErr)
 		}
 	}
 
-	tempPassword, err := generateSecureTemporaryPassword()
+	tempPassword, err := generateTemporaryPassword(m.GetPasswordPolicy(ctx))
 	if err != nil {
 		rollback()
 		return nil, "", fmt.Errorf("failed to generate temporary password: %w", err)
 	}
 
-	err = cognitoSetTemporaryPassword(ctx, req.Email, tempPassword, oauthConfig)
+	err = store.SetPassword(ctx, req.Email, tempPassword, false)
 	if err != nil {
 		rollback()
 		return nil, "", fmt.Errorf("failed to set temporary password: %w", err)
 	}
 
-	verifiedUser, err := cognitoGetUser(ctx, req.Email, oauthConfig)
+	verifiedUser, err := store.GetUser(ctx, req.Email)
 	if err != nil {
 		rollback()
 		return nil, "", fmt.Errorf("failed to verify user after provisioning: %w", err)
 	}
 
-	if err := assertRequiredCustomAttributes(verifiedUser, req); err != nil {
+	if err := verifyProvisionedAttributes(verifiedUser, req, m.roleAttributeName()); err != nil {
 		rollback()
 		return nil, "", fmt.Errorf("provisioning verification: %w", err)
 	}
 
-	user, err := cognitoUserToUser(*verifiedUser)
+	user, err := recordToUser(verifiedUser, m.roleAttributeName())
 	if err != nil {
 		rollback()
 		return nil, "", fmt.Errorf("failed to convert verified user: %w", err)
@@ -489,14 +580,18 @@ func assertRequiredCustomAttributes(cognitoUser *types.UserType, req CreateUserR
 		return fmt.Errorf("provisioning verification failed: user is nil")
 	}
 
-	attrs := make(map[string]string, len(cognitoUser.Attributes))
-	for _, attr := range cognitoUser.Attributes {
-		if attr.Name != nil && attr.Value != nil {
-			attrs[*attr.Name] = *attr.Value
-		}
+	return verifyProvisionedAttributes(cognitoUserToRecord(*cognitoUser), req, getRoleAttributeName())
+}
+
+// verifyProvisionedAttributes checks that the stored user carries the role and
+// every custom attribute requested at creation time.
+func verifyProvisionedAttributes(record *UserRecord, req CreateUserRequest, roleAttr string) error {
+	if record == nil {
+		return fmt.Errorf("provisioning verification failed: user is nil")
 	}
 
-	roleAttr := getRoleAttributeName()
+	attrs := record.Attributes
+
 	expectedRole := req.Role
 	if expectedRole == "" {
 		expectedRole = "user"
@@ -526,23 +621,24 @@ func assertRequiredCustomAttributes(cognitoUser *types.UserType, req CreateUserR
 	return nil
 }
 
-// ResetTemporaryPassword generates a new temporary password for an existing Cognito user.
+// ResetTemporaryPassword generates a new temporary password for an existing user.
 // This is useful for resending invitation emails — the user must still change the password on first login.
-func ResetTemporaryPassword(ctx context.Context, email string) (string, error) {
+func (m *Manager) ResetTemporaryPassword(ctx context.Context, email string) (string, error) {
 	if email == "" {
 		return "", fmt.Errorf("email cannot be empty: %w", ErrInvalidInput)
 	}
 
-	if oauthConfig == nil {
-		return "", fmt.Errorf("oauth config is not set")
+	store, err := m.userStore()
+	if err != nil {
+		return "", err
 	}
 
-	tempPassword, err := generateSecureTemporaryPassword()
+	tempPassword, err := generateTemporaryPassword(m.GetPasswordPolicy(ctx))
 	if err != nil {
 		return "", fmt.Errorf("failed to generate temporary password: %w", err)
 	}
 
-	err = cognitoSetTemporaryPassword(ctx, email, tempPassword, oauthConfig)
+	err = store.SetPassword(ctx, email, tempPassword, false)
 	if err != nil {
 		return "", fmt.Errorf("failed to set temporary password: %w", err)
 	}
@@ -11,6 +11,8 @@ import (
 )
 
 type mockInvitationCognitoClient struct {
+	cognitoClientStubs
+
 	createUserCalled  bool
 	setPasswordCalled bool
 	deleteUserCalled  bool
@@ -75,7 +77,7 @@ func (m *mockInvitationCognitoClient) AdminSetUserPassword(ctx context.Context,
 		}
 	}
 
-	if err := validateCognitoPassword(*params.Password); err != nil {
+	if err := DefaultPasswordPolicy().Validate(*params.Password); err != nil {
 		return nil, &types.InvalidPasswordException{
 			Message: aws.String(err.Error()),
 		}
@@ -137,7 +139,7 @@ func TestCreateUserWithInvitation(t *testing.T) {
 			t.Errorf("expected password to be at least 12 characters, got %d", len(tempPassword))
 		}
 
-		if err := validateCognitoPassword(tempPassword); err != nil {
+		if err := DefaultPasswordPolicy().Validate(tempPassword); err != nil {
 			t.Errorf("expected returned password to satisfy Cognito policy: %v", err)
 		}
 
@@ -11,6 +11,8 @@ import (
 )
 
 type mockProvisioningCognitoClient struct {
+	cognitoClientStubs
+
 	createUserInput    *cognitoidentityprovider.AdminCreateUserInput
 	setPasswordErr     error
 	deleteUserCalled   bool
@@ -11,6 +11,8 @@ import (
 )
 
 type mockUserMgmtCognitoClient struct {
+	cognitoClientStubs
+
 	disableUserCalled bool
 	enableUserCalled  bool
 	setPasswordCalled bool
@@ -20,6 +22,7 @@ type mockUserMgmtCognitoClient struct {
 	enableUserInput  *cognitoidentityprovider.AdminEnableUserInput
 	setPasswordInput *cognitoidentityprovider.AdminSetUserPasswordInput
 	updateAttrInput  *cognitoidentityprovider.AdminUpdateUserAttributesInput
+	deleteAttrInput  *cognitoidentityprovider.AdminDeleteUserAttributesInput
 
 	disableUserErr error
 	enableUserErr  error
@@ -36,6 +39,11 @@ func (m *mockUserMgmtCognitoClient) AdminUpdateUserAttributes(ctx context.Contex
 	return &cognitoidentityprovider.AdminUpdateUserAttributesOutput{}, nil
 }
 
+func (m *mockUserMgmtCognitoClient) AdminDeleteUserAttributes(ctx context.Context, params *cognitoidentityprovider.AdminDeleteUserAttributesInput, optFns ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.AdminDeleteUserAttributesOutput, error) {
+	m.deleteAttrInput = params
+	return &cognitoidentityprovider.AdminDeleteUserAttributesOutput{}, nil
+}
+
 func (m *mockUserMgmtCognitoClient) AdminGetUser(ctx context.Context, params *cognitoidentityprovider.AdminGetUserInput, optFns ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.AdminGetUserOutput, error) {
 	return &cognitoidentityprovider.AdminGetUserOutput{
 		Username: aws.String("test@example.com"),
@@ -346,7 +354,7 @@ func TestSetUserPassword(t *testing.T) {
 		mockClient.setPasswordErr = errors.New("InvalidParameterException: bad password")
 		ctx := context.Background()
 
-		err := SetUserPassword(ctx, "test@example.com", "weak", false)
+		err := SetUserPassword(ctx, "test@example.com", "TempPass123!", false)
 		if err == nil {
 			t.Fatal("expected error from cognito")
 		}
@@ -0,0 +1,693 @@
+package user
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// API keys are never stored in plaintext. The token attribute holds a short
+// public lookup prefix shared by all of a user's keys (the first
+// apiKeyLookupLength characters of each key) and the hash attribute holds the
+// user's key set as compact JSON, one salted SHA-256 per key:
+//
+//	custom:apiKey     = usr_1a2b3c4d
+//	custom:apiKeyHash = [{"id":"9f8e7d6c","n":"ci","s":["read"],"c":1700000000,"h":"sha256$<salt>$<hash>"}]
+//
+// Validation finds candidates by prefix and compares hashes in constant time.
+const (
+	defaultTokenHashAttributeName = "custom:apiKeyHash"
+
+	apiKeyPrefix       = "usr_"
+	apiKeyLookupLength = len(apiKeyPrefix) + 8
+	apiKeyHashScheme   = "sha256"
+	apiKeySaltBytes    = 16
+
+	// apiKeyCandidateLimit bounds how many prefix collisions are checked per lookup.
+	apiKeyCandidateLimit = 10
+
+	// maxAPIKeysPerUser keeps the key set within Cognito's 2048 character
+	// custom attribute limit. Keys in a rotation grace period do not count,
+	// so a user at the limit can still rotate, but at most as many of them
+	// are kept: beyond that the one closest to expiry is dropped.
+	maxAPIKeysPerUser = 5
+
+	// defaultAPIKeyName is the key managed by GenerateAPIKey, RotateAPIKey and UpdateAPIKey.
+	defaultAPIKeyName = "default"
+
+	// apiKeyLastUsedInterval throttles LastUsedAt writes to one per key per interval.
+	apiKeyLastUsedInterval = 15 * time.Minute
+
+	defaultAPIKeyRotationGrace = 24 * time.Hour
+)
+
+var (
+	tokenHashAttributeName = defaultTokenHashAttributeName
+)
+
+// SetTokenHashAttributeName sets the attribute that stores API key hashes.
+func SetTokenHashAttributeName(name string) {
+	tokenHashAttributeName = name
+}
+
+// GetTokenHashAttributeName returns the attribute that stores API key hashes.
+func GetTokenHashAttributeName() string {
+	return tokenHashAttributeName
+}
+
+// storedAPIKey is the persisted form of one API key. Field names are kept
+// short because the whole set lives in a single custom attribute.
+type storedAPIKey struct {
+	ID        string   `json:"id"`
+	Name      string   `json:"n,omitempty"`
+	Scopes    []string `json:"s,omitempty"`
+	CreatedAt int64    `json:"c"`
+	LastUsed  int64    `json:"u,omitempty"`
+	ExpiresAt int64    `json:"e,omitempty"`
+	Rotated   bool     `json:"r,omitempty"` // Replaced by RotateAPIKeyByID, valid until ExpiresAt
+	Hash      string   `json:"h"`
+}
+
+func (k *storedAPIKey) expired(now time.Time) bool {
+	return k.ExpiresAt != 0 && now.Unix() >= k.ExpiresAt
+}
+
+func (k *storedAPIKey) toAPIKey(prefix string) *APIKey {
+	key := &APIKey{
+		ID:        k.ID,
+		Name:      k.Name,
+		Prefix:    prefix,
+		Scopes:    k.Scopes,
+		CreatedAt: time.Unix(k.CreatedAt, 0).UTC(),
+	}
+	if k.LastUsed != 0 {
+		t := time.Unix(k.LastUsed, 0).UTC()
+		key.LastUsedAt = &t
+	}
+	if k.ExpiresAt != 0 {
+		t := time.Unix(k.ExpiresAt, 0).UTC()
+		key.ExpiresAt = &t
+	}
+	return key
+}
+
+// apiKeyLookupPrefix returns the public prefix stored for key, or false if
+// key is not in the package's usr_<hex> format.
+func apiKeyLookupPrefix(key string) (string, bool) {
+	if !strings.HasPrefix(key, apiKeyPrefix) || len(key) <= apiKeyLookupLength {
+		return "", false
+	}
+	return key[:apiKeyLookupLength], true
+}
+
+// newAPIKey returns a fresh key sharing prefix, or a key with a new prefix
+// when prefix is empty.
+func newAPIKey(prefix string) string {
+	if prefix == "" {
+		return generateSecureAPIKey()
+	}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		panic(fmt.Sprintf("failed to generate secure random bytes: %v", err))
+	}
+	return prefix + hex.EncodeToString(secret)
+}
+
+func newAPIKeyID() (string, error) {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate API key ID: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// hashAPIKey returns the salted hash stored for key.
+func hashAPIKey(key string) (string, error) {
+	salt := make([]byte, apiKeySaltBytes)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to generate API key salt: %w", err)
+	}
+	return fmt.Sprintf("%s$%s$%s", apiKeyHashScheme, hex.EncodeToString(salt), hex.EncodeToString(apiKeyDigest(salt, key))), nil
+}
+
+// verifyAPIKeyHash reports whether key matches a hash produced by hashAPIKey.
+func verifyAPIKeyHash(key, stored string) bool {
+	parts := strings.Split(stored, "$")
+	if len(parts) != 3 || parts[0] != apiKeyHashScheme {
+		return false
+	}
+
+	salt, err := hex.DecodeString(parts[1])
+	if err != nil {
+		return false
+	}
+	expected, err := hex.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare(apiKeyDigest(salt, key), expected) == 1
+}
+
+func apiKeyDigest(salt []byte, key string) []byte {
+	h := sha256.New()
+	h.Write(salt)
+	h.Write([]byte(key))
+	return h.Sum(nil)
+}
+
+// newStoredAPIKey hashes key into a stored entry.
+func newStoredAPIKey(key, name string, scopes []string, expiresAt *time.Time) (storedAPIKey, error) {
+	id, err := newAPIKeyID()
+	if err != nil {
+		return storedAPIKey{}, err
+	}
+	hash, err := hashAPIKey(key)
+	if err != nil {
+		return storedAPIKey{}, err
+	}
+
+	stored := storedAPIKey{
+		ID:        id,
+		Name:      name,
+		Scopes:    scopes,
+		CreatedAt: time.Now().Unix(),
+		Hash:      hash,
+	}
+	if expiresAt != nil {
+		stored.ExpiresAt = expiresAt.Unix()
+	}
+	return stored, nil
+}
+
+// loadAPIKeys returns the lookup prefix and key set stored on record. A bare
+// hash (the single-key format) is read as one key named "default".
+func loadAPIKeys(record *UserRecord) (string, []storedAPIKey, error) {
+	prefix := record.Attributes[normalizeCustomAttributeName(tokenAttributeName)]
+	raw := record.Attributes[normalizeCustomAttributeName(tokenHashAttributeName)]
+	if raw == "" {
+		return prefix, nil, nil
+	}
+
+	if !strings.HasPrefix(raw, "[") {
+		return prefix, []storedAPIKey{{ID: defaultAPIKeyName, Name: defaultAPIKeyName, Hash: raw}}, nil
+	}
+
+	var keys []storedAPIKey
+	if err := json.Unmarshal([]byte(raw), &keys); err != nil {
+		return prefix, nil, fmt.Errorf("failed to parse API keys for %s: %w", record.Username, err)
+	}
+	return prefix, keys, nil
+}
+
+// apiKeySetAttributes returns the attributes that store prefix and keys.
+func apiKeySetAttributes(prefix string, keys []storedAPIKey) (map[string]string, error) {
+	data, err := json.Marshal(keys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode API keys: %w", err)
+	}
+	return map[string]string{
+		normalizeCustomAttributeName(tokenAttributeName):     prefix,
+		normalizeCustomAttributeName(tokenHashAttributeName): string(data),
+	}, nil
+}
+
+// writeAPIKeySet stores prefix and keys for the user. An empty set deletes
+// both attributes.
+func writeAPIKeySet(ctx context.Context, store UserStore, username, prefix string, keys []storedAPIKey) error {
+	if len(keys) == 0 {
+		return deleteStoreAttributes(ctx, store, username, []string{
+			normalizeCustomAttributeName(tokenAttributeName),
+			normalizeCustomAttributeName(tokenHashAttributeName),
+		})
+	}
+
+	attributes, err := apiKeySetAttributes(prefix, keys)
+	if err != nil {
+		return err
+	}
+	return store.UpdateAttributes(ctx, username, attributes)
+}
+
+// apiKeyAttributes returns the attributes that store key as the user's only key.
+func apiKeyAttributes(key string) (map[string]string, error) {
+	prefix, ok := apiKeyLookupPrefix(key)
+	if !ok {
+		return nil, fmt.Errorf("apiKey must start with %q and be longer than %d characters: %w", apiKeyPrefix, apiKeyLookupLength, ErrInvalidInput)
+	}
+
+	stored, err := newStoredAPIKey(key, defaultAPIKeyName, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return apiKeySetAttributes(prefix, []storedAPIKey{stored})
+}
+
+// apiKeyMatch is a user whose stored key matched a presented key. key is nil
+// for plaintext keys that have not been migrated.
+type apiKeyMatch struct {
+	record *UserRecord
+	key    *storedAPIKey
+}
+
+// matchAPIKey returns the users whose stored key matches key. Hashed keys are
+// found by prefix; keys that have not been migrated yet are still matched by
+// their plaintext value. Expired keys and keys of disabled users never match.
+func matchAPIKey(ctx context.Context, store UserStore, key string) ([]apiKeyMatch, error) {
+	var matches []apiKeyMatch
+	now := time.Now()
+
+	if prefix, ok := apiKeyLookupPrefix(key); ok {
+		candidates, err := store.FindUsersByAttribute(ctx, tokenAttributeName, prefix, apiKeyCandidateLimit)
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range candidates {
+			if !record.Enabled {
+				continue
+			}
+			_, keys, err := loadAPIKeys(record)
+			if err != nil {
+				log.Printf("⚠️ [APIKey] %v", err)
+				continue
+			}
+			for i := range keys {
+				if !keys[i].expired(now) && verifyAPIKeyHash(key, keys[i].Hash) {
+					matches = append(matches, apiKeyMatch{record: record, key: &keys[i]})
+					break
+				}
+			}
+		}
+		if len(matches) > 0 {
+			return matches, nil
+		}
+	}
+
+	legacy, err := store.FindUsersByAttribute(ctx, tokenAttributeName, key, 2)
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range legacy {
+		if !record.Enabled {
+			continue
+		}
+		// A hashed user's token attribute is its public prefix, which must
+		// never authenticate on its own.
+		if record.Attributes[normalizeCustomAttributeName(tokenHashAttributeName)] != "" {
+			continue
+		}
+		log.Printf("⚠️ [APIKey] user %s still has a plaintext API key; run MigratePlaintextAPIKeys", record.Username)
+		matches = append(matches, apiKeyMatch{record: record})
+	}
+
+	return matches, nil
+}
+
+// apiKeyLocks serializes read-modify-write cycles on a user's key set within
+// this process, so recording a key's last use cannot undo a concurrent
+// create, revoke, rotation or replacement. Instances sharing a user pool can
+// still race within one GetUser/UpdateAttributes round trip.
+var apiKeyLocks = &keyedMutex{locks: make(map[string]*keyedLock)}
+
+type keyedMutex struct {
+	mu    sync.Mutex
+	locks map[string]*keyedLock
+}
+
+type keyedLock struct {
+	sync.Mutex
+	refs int
+}
+
+// lock locks key and returns the function that unlocks it.
+func (k *keyedMutex) lock(key string) func() {
+	k.mu.Lock()
+	l, ok := k.locks[key]
+	if !ok {
+		l = &keyedLock{}
+		k.locks[key] = l
+	}
+	l.refs++
+	k.mu.Unlock()
+
+	l.Lock()
+	return func() {
+		l.Unlock()
+		k.mu.Lock()
+		if l.refs--; l.refs == 0 {
+			delete(k.locks, key)
+		}
+		k.mu.Unlock()
+	}
+}
+
+// touchAPIKey records the last use of a matched key, at most once per
+// apiKeyLastUsedInterval. The write happens in the background so it never
+// delays or fails authentication; failures are logged.
+func touchAPIKey(ctx context.Context, store UserStore, match apiKeyMatch) {
+	if match.key == nil {
+		return
+	}
+	now := time.Now()
+	if now.Sub(time.Unix(match.key.LastUsed, 0)) < apiKeyLastUsedInterval {
+		return
+	}
+
+	go recordAPIKeyUse(context.WithoutCancel(ctx), store, match.record.Username, match.key.ID, now)
+}
+
+// recordAPIKeyUse re-reads the user's key set and changes only the LastUsedAt
+// of keyID, so other changes to the set are kept.
+func recordAPIKeyUse(ctx context.Context, store UserStore, username, keyID string, now time.Time) {
+	unlock := apiKeyLocks.lock(username)
+	defer unlock()
+
+	record, err := store.GetUser(ctx, username)
+	if err != nil {
+		log.Printf("⚠️ [APIKey] failed to record last use for %s: %v", username, err)
+		return
+	}
+	prefix, keys, err := loadAPIKeys(record)
+	if err != nil {
+		log.Printf("⚠️ [APIKey] failed to record last use for %s: %v", username, err)
+		return
+	}
+
+	for i := range keys {
+		if keys[i].ID != keyID {
+			continue
+		}
+		if now.Sub(time.Unix(keys[i].LastUsed, 0)) < apiKeyLastUsedInterval {
+			return
+		}
+		keys[i].LastUsed = now.Unix()
+		if err := writeAPIKeySet(ctx, store, username, prefix, keys); err != nil {
+			log.Printf("⚠️ [APIKey] failed to record last use for %s: %v", username, err)
+		}
+		return
+	}
+}
+
+// updateAPIKeys loads the user's key set, drops expired keys, applies fn and
+// writes the result back.
+func (m *Manager) updateAPIKeys(ctx context.Context, email string, fn func(prefix string, keys []storedAPIKey) (string, []storedAPIKey, error)) error {
+	if email == "" {
+		return fmt.Errorf("email cannot be empty: %w", ErrInvalidInput)
+	}
+
+	store, err := m.userStore()
+	if err != nil {
+		return err
+	}
+
+	unlock := apiKeyLocks.lock(email)
+	defer unlock()
+
+	record, err := store.GetUser(ctx, email)
+	if err != nil {
+		return err
+	}
+
+	prefix, keys, err := loadAPIKeys(record)
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		// A plaintext key is replaced by the first hashed key.
+		prefix = ""
+	}
+
+	now := time.Now()
+	active := keys[:0]
+	for _, k := range keys {
+		if !k.expired(now) {
+			active = append(active, k)
+		}
+	}
+
+	prefix, keys, err = fn(prefix, active)
+	if err != nil {
+		return err
+	}
+	keys, err = limitAPIKeys(email, keys)
+	if err != nil {
+		return err
+	}
+
+	if err := writeAPIKeySet(ctx, store, email, prefix, keys); err != nil {
+		return err
+	}
+
+	m.tokenCache.invalidateUser(email)
+	return nil
+}
+
+// limitAPIKeys enforces maxAPIKeysPerUser on keys that are not in a rotation
+// grace period and drops the grace keys closest to expiry beyond the same
+// number.
+func limitAPIKeys(email string, keys []storedAPIKey) ([]storedAPIKey, error) {
+	var current, rotated []storedAPIKey
+	for _, k := range keys {
+		if k.Rotated {
+			rotated = append(rotated, k)
+		} else {
+			current = append(current, k)
+		}
+	}
+
+	if len(current) > maxAPIKeysPerUser {
+		return nil, fmt.Errorf("user %s already has %d API keys (max %d): %w", email, len(current)-1, maxAPIKeysPerUser, ErrInvalidInput)
+	}
+	if len(rotated) <= maxAPIKeysPerUser {
+		return keys, nil
+	}
+
+	// Newest first, so of keys expiring together the older ones are dropped
+	for i, j := 0, len(rotated)-1; i < j; i, j = i+1, j-1 {
+		rotated[i], rotated[j] = rotated[j], rotated[i]
+	}
+	sort.SliceStable(rotated, func(i, j int) bool { return rotated[i].ExpiresAt > rotated[j].ExpiresAt })
+	kept := make(map[string]bool, maxAPIKeysPerUser)
+	for _, k := range rotated[:maxAPIKeysPerUser] {
+		kept[k.ID] = true
+	}
+
+	result := keys[:0]
+	for _, k := range keys {
+		if !k.Rotated || kept[k.ID] {
+			result = append(result, k)
+		}
+	}
+	return result, nil
+}
+
+// CreateAPIKey adds a named key to the user's key set and returns the raw key,
+// which is not retrievable afterwards, together with its metadata.
+func (m *Manager) CreateAPIKey(ctx context.Context, email string, req CreateAPIKeyRequest) (string, *APIKey, error) {
+	if req.Name == "" {
+		return "", nil, fmt.Errorf("API key name is required: %w", ErrInvalidInput)
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return "", nil, fmt.Errorf("API key expiry must be in the future: %w", ErrInvalidInput)
+	}
+	for _, scope := range req.Scopes {
+		if scope == "" || strings.ContainsAny(scope, " \t\n") {
+			return "", nil, fmt.Errorf("invalid API key scope %q: %w", scope, ErrInvalidInput)
+		}
+	}
+
+	var rawKey string
+	var created *APIKey
+	err := m.updateAPIKeys(ctx, email, func(prefix string, keys []storedAPIKey) (string, []storedAPIKey, error) {
+		rawKey = newAPIKey(prefix)
+		prefix = rawKey[:apiKeyLookupLength]
+
+		stored, err := newStoredAPIKey(rawKey, req.Name, req.Scopes, req.ExpiresAt)
+		if err != nil {
+			return "", nil, err
+		}
+		created = stored.toAPIKey(prefix)
+		return prefix, append(keys, stored), nil
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	return rawKey, created, nil
+}
+
+// ListAPIKeys returns the metadata of the user's unexpired API keys.
+func (m *Manager) ListAPIKeys(ctx context.Context, email string) ([]*APIKey, error) {
+	if email == "" {
+		return nil, fmt.Errorf("email cannot be empty: %w", ErrInvalidInput)
+	}
+
+	store, err := m.userStore()
+	if err != nil {
+		return nil, err
+	}
+
+	record, err := store.GetUser(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix, keys, err := loadAPIKeys(record)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	result := make([]*APIKey, 0, len(keys))
+	for i := range keys {
+		if !keys[i].expired(now) {
+			result = append(result, keys[i].toAPIKey(prefix))
+		}
+	}
+	return result, nil
+}
+
+// RevokeAPIKey removes a key from the user's key set. It stops working immediately.
+func (m *Manager) RevokeAPIKey(ctx context.Context, email, keyID string) error {
+	return m.updateAPIKeys(ctx, email, func(prefix string, keys []storedAPIKey) (string, []storedAPIKey, error) {
+		for i, k := range keys {
+			if k.ID == keyID {
+				return prefix, append(keys[:i], keys[i+1:]...), nil
+			}
+		}
+		return "", nil, fmt.Errorf("API key %s: %w", keyID, ErrAPIKeyNotFound)
+	})
+}
+
+// RotateAPIKeyByID replaces a key with a new one carrying the same name and
+// scopes. The old key keeps working for grace, so clients can switch over;
+// a zero grace revokes it immediately.
+func (m *Manager) RotateAPIKeyByID(ctx context.Context, email, keyID string, grace time.Duration) (string, *APIKey, error) {
+	var rawKey string
+	var created *APIKey
+	err := m.updateAPIKeys(ctx, email, func(prefix string, keys []storedAPIKey) (string, []storedAPIKey, error) {
+		for i, k := range keys {
+			if k.ID != keyID {
+				continue
+			}
+
+			rawKey = newAPIKey(prefix)
+			prefix = rawKey[:apiKeyLookupLength]
+
+			var expiresAt *time.Time
+			if k.ExpiresAt != 0 {
+				t := time.Unix(k.ExpiresAt, 0)
+				expiresAt = &t
+			}
+			stored, err := newStoredAPIKey(rawKey, k.Name, k.Scopes, expiresAt)
+			if err != nil {
+				return "", nil, err
+			}
+			created = stored.toAPIKey(prefix)
+
+			if grace <= 0 {
+				keys = append(keys[:i], keys[i+1:]...)
+			} else {
+				keys[i].Rotated = true
+				if graceEnd := time.Now().Add(grace).Unix(); k.ExpiresAt == 0 || graceEnd < k.ExpiresAt {
+					keys[i].ExpiresAt = graceEnd
+				}
+			}
+			return prefix, append(keys, stored), nil
+		}
+		return "", nil, fmt.Errorf("API key %s: %w", keyID, ErrAPIKeyNotFound)
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	return rawKey, created, nil
+}
+
+// defaultAPIKeyID returns the ID of the user's key managed by GenerateAPIKey
+// and RotateAPIKey, or "" if there is none.
+func (m *Manager) defaultAPIKeyID(ctx context.Context, email string) (string, error) {
+	keys, err := m.ListAPIKeys(ctx, email)
+	if err != nil {
+		return "", err
+	}
+	for i := len(keys) - 1; i >= 0; i-- {
+		if keys[i].Name == defaultAPIKeyName {
+			return keys[i].ID, nil
+		}
+	}
+	return "", nil
+}
+
+// apiKeyRotationGrace returns the configured rotation grace period.
+func (m *Manager) apiKeyRotationGrace() time.Duration {
+	if config := m.Config(); config != nil && config.APIKeyRotationGraceSeconds > 0 {
+		return time.Duration(config.APIKeyRotationGraceSeconds) * time.Second
+	}
+	return defaultAPIKeyRotationGrace
+}
+
+// APIKeyMigrationResult reports the outcome of MigratePlaintextAPIKeys.
+type APIKeyMigrationResult struct {
+	Migrated int      // users whose plaintext key was replaced by prefix + hash
+	Skipped  []string // usernames whose plaintext key has no usr_ prefix and cannot be migrated
+}
+
+// MigratePlaintextAPIKeys replaces every plaintext API key in the store with
+// its lookup prefix and salted hash. Existing keys keep working; the raw
+// values are no longer readable from the user pool. Users that already have a
+// hashed key are left untouched, so the migration can be re-run safely.
+func (m *Manager) MigratePlaintextAPIKeys(ctx context.Context) (*APIKeyMigrationResult, error) {
+	store, err := m.userStore()
+	if err != nil {
+		return nil, err
+	}
+
+	tokenAttr := normalizeCustomAttributeName(tokenAttributeName)
+	hashAttr := normalizeCustomAttributeName(tokenHashAttributeName)
+	result := &APIKeyMigrationResult{}
+	pageToken := ""
+
+	for {
+		records, nextToken, err := store.ListUsers(ctx, 60, pageToken)
+		if err != nil {
+			return result, fmt.Errorf("failed to list users: %w", err)
+		}
+
+		for _, record := range records {
+			key := record.Attributes[tokenAttr]
+			if key == "" || record.Attributes[hashAttr] != "" {
+				continue
+			}
+
+			attributes, err := apiKeyAttributes(key)
+			if err != nil {
+				result.Skipped = append(result.Skipped, record.Username)
+				continue
+			}
+
+			if err := store.UpdateAttributes(ctx, record.Username, attributes); err != nil {
+				return result, fmt.Errorf("failed to migrate API key for %s: %w", record.Username, err)
+			}
+			result.Migrated++
+		}
+
+		if nextToken == "" {
+			break
+		}
+		pageToken = nextToken
+	}
+
+	log.Printf("✅ [APIKey] migrated %d plaintext API keys (%d skipped)", result.Migrated, len(result.Skipped))
+	return result, nil
+}
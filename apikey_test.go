@@ -0,0 +1,385 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestAPIKey_StoredAsPrefixAndHash(t *testing.T) {
+	store := setupMemoryStore(t)
+	ctx := context.Background()
+
+	if _, err := CreateUser(ctx, CreateUserRequest{Email: "hash@example.com"}); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+
+	apiKey, err := GenerateAPIKey(ctx, "hash@example.com")
+	if err != nil {
+		t.Fatalf("GenerateAPIKey: %v", err)
+	}
+
+	record, err := store.GetUser(ctx, "hash@example.com")
+	if err != nil {
+		t.Fatalf("GetUser: %v", err)
+	}
+	for name, value := range record.Attributes {
+		if strings.Contains(value, apiKey) {
+			t.Errorf("attribute %s contains the raw API key", name)
+		}
+	}
+	if got := record.Attributes["custom:apiKey"]; got != apiKey[:apiKeyLookupLength] {
+		t.Errorf("expected lookup prefix %q, got %q", apiKey[:apiKeyLookupLength], got)
+	}
+	if !strings.Contains(record.Attributes["custom:apiKeyHash"], `"h":"sha256$`) {
+		t.Errorf("expected sha256 hash, got %q", record.Attributes["custom:apiKeyHash"])
+	}
+
+	if _, err := ValidateAPIKey(ctx, apiKey); err != nil {
+		t.Errorf("ValidateAPIKey: %v", err)
+	}
+
+	// Same prefix, different secret.
+	forged := apiKey[:apiKeyLookupLength] + strings.Repeat("0", len(apiKey)-apiKeyLookupLength)
+	if _, err := ValidateAPIKey(ctx, forged); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Errorf("expected ErrInvalidAPIKey for forged key, got %v", err)
+	}
+
+	// The stored prefix is public and must not authenticate by itself.
+	if _, err := ValidateAPIKey(ctx, apiKey[:apiKeyLookupLength]); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Errorf("expected ErrInvalidAPIKey for bare prefix, got %v", err)
+	}
+}
+
+func TestAPIKey_UpdateRejectsUnknownFormat(t *testing.T) {
+	setupMemoryStore(t)
+	ctx := context.Background()
+
+	if _, err := CreateUser(ctx, CreateUserRequest{Email: "fmt@example.com"}); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+
+	if err := UpdateAPIKey(ctx, "fmt@example.com", "short"); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("expected ErrInvalidInput, got %v", err)
+	}
+}
+
+func TestMigratePlaintextAPIKeys(t *testing.T) {
+	store := setupMemoryStore(t)
+	ctx := context.Background()
+
+	legacyKey := generateSecureAPIKey()
+	if _, err := store.CreateUser(ctx, "legacy@example.com", map[string]string{
+		"email":         "legacy@example.com",
+		"custom:apiKey": legacyKey,
+	}); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	if _, err := store.CreateUser(ctx, "odd@example.com", map[string]string{
+		"email":         "odd@example.com",
+		"custom:apiKey": "not-a-usr-key",
+	}); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+
+	// Plaintext keys keep working before the migration.
+	if _, err := ValidateAPIKey(ctx, legacyKey); err != nil {
+		t.Fatalf("ValidateAPIKey before migration: %v", err)
+	}
+
+	result, err := MigratePlaintextAPIKeys(ctx)
+	if err != nil {
+		t.Fatalf("MigratePlaintextAPIKeys: %v", err)
+	}
+	if result.Migrated != 1 || len(result.Skipped) != 1 || result.Skipped[0] != "odd@example.com" {
+		t.Errorf("unexpected migration result: %+v", result)
+	}
+
+	record, _ := store.GetUser(ctx, "legacy@example.com")
+	if record.Attributes["custom:apiKey"] == legacyKey {
+		t.Error("expected plaintext key to be replaced")
+	}
+	if _, err := ValidateAPIKey(ctx, legacyKey); err != nil {
+		t.Errorf("ValidateAPIKey after migration: %v", err)
+	}
+
+	again, err := MigratePlaintextAPIKeys(ctx)
+	if err != nil {
+		t.Fatalf("second MigratePlaintextAPIKeys: %v", err)
+	}
+	if again.Migrated != 0 {
+		t.Errorf("expected re-run to migrate nothing, got %d", again.Migrated)
+	}
+}
+
+func TestAPIKey_NamedKeysWithScopes(t *testing.T) {
+	setupMemoryStore(t)
+	ctx := context.Background()
+
+	if _, err := CreateUser(ctx, CreateUserRequest{Email: "multi@example.com"}); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+
+	ciKey, ciInfo, err := CreateAPIKey(ctx, "multi@example.com", CreateAPIKeyRequest{Name: "ci", Scopes: []string{"deploy"}})
+	if err != nil {
+		t.Fatalf("CreateAPIKey(ci): %v", err)
+	}
+	expiry := time.Now().Add(time.Hour)
+	reportKey, _, err := CreateAPIKey(ctx, "multi@example.com", CreateAPIKeyRequest{Name: "reports", Scopes: []string{"read"}, ExpiresAt: &expiry})
+	if err != nil {
+		t.Fatalf("CreateAPIKey(reports): %v", err)
+	}
+
+	keys, err := ListAPIKeys(ctx, "multi@example.com")
+	if err != nil {
+		t.Fatalf("ListAPIKeys: %v", err)
+	}
+	if len(keys) != 2 || keys[1].ExpiresAt == nil || keys[0].Prefix != keys[1].Prefix {
+		t.Errorf("unexpected keys: %+v", keys)
+	}
+
+	claims, err := FindUserByToken(ctx, reportKey)
+	if err != nil {
+		t.Fatalf("FindUserByToken: %v", err)
+	}
+	if !claims.HasScope("read") || claims.HasScope("deploy") || claims.APIKeyID != keys[1].ID {
+		t.Errorf("unexpected claims for reports key: %+v", claims)
+	}
+
+	if err := RevokeAPIKey(ctx, "multi@example.com", ciInfo.ID); err != nil {
+		t.Fatalf("RevokeAPIKey: %v", err)
+	}
+	if _, err := FindUserByToken(ctx, ciKey); err == nil {
+		t.Error("expected revoked key to be rejected")
+	}
+	if _, err := FindUserByToken(ctx, reportKey); err != nil {
+		t.Errorf("expected remaining key to work, got %v", err)
+	}
+	if err := RevokeAPIKey(ctx, "multi@example.com", ciInfo.ID); !errors.Is(err, ErrAPIKeyNotFound) {
+		t.Errorf("expected ErrAPIKeyNotFound, got %v", err)
+	}
+}
+
+func TestAPIKey_RevokingLastKeyDeletesAttributes(t *testing.T) {
+	store := setupMemoryStore(t)
+	ctx := context.Background()
+
+	if _, err := CreateUser(ctx, CreateUserRequest{Email: "last@example.com"}); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	_, info, err := CreateAPIKey(ctx, "last@example.com", CreateAPIKeyRequest{Name: "ci"})
+	if err != nil {
+		t.Fatalf("CreateAPIKey: %v", err)
+	}
+	if err := RevokeAPIKey(ctx, "last@example.com", info.ID); err != nil {
+		t.Fatalf("RevokeAPIKey: %v", err)
+	}
+
+	record, _ := store.GetUser(ctx, "last@example.com")
+	for _, name := range []string{"custom:apiKey", "custom:apiKeyHash"} {
+		if _, ok := record.Attributes[name]; ok {
+			t.Errorf("expected %s to be deleted, got %v", name, record.Attributes)
+		}
+	}
+}
+
+func TestAPIKey_ExpiredKeyRejected(t *testing.T) {
+	store := setupMemoryStore(t)
+	ctx := context.Background()
+
+	if _, err := CreateUser(ctx, CreateUserRequest{Email: "exp@example.com"}); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	apiKey, err := GenerateAPIKey(ctx, "exp@example.com")
+	if err != nil {
+		t.Fatalf("GenerateAPIKey: %v", err)
+	}
+
+	record, _ := store.GetUser(ctx, "exp@example.com")
+	prefix, keys, err := loadAPIKeys(record)
+	if err != nil {
+		t.Fatalf("loadAPIKeys: %v", err)
+	}
+	keys[0].ExpiresAt = time.Now().Add(-time.Minute).Unix()
+	attributes, _ := apiKeySetAttributes(prefix, keys)
+	if err := store.UpdateAttributes(ctx, "exp@example.com", attributes); err != nil {
+		t.Fatalf("UpdateAttributes: %v", err)
+	}
+
+	if _, err := ValidateAPIKey(ctx, apiKey); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Errorf("expected ErrInvalidAPIKey for expired key, got %v", err)
+	}
+}
+
+func TestAPIKey_RotationGracePeriod(t *testing.T) {
+	setupMemoryStore(t)
+	ctx := context.Background()
+
+	if _, err := CreateUser(ctx, CreateUserRequest{Email: "rotate@example.com"}); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	oldKey, err := GenerateAPIKey(ctx, "rotate@example.com")
+	if err != nil {
+		t.Fatalf("GenerateAPIKey: %v", err)
+	}
+
+	newKey, err := RotateAPIKey(ctx, "rotate@example.com")
+	if err != nil {
+		t.Fatalf("RotateAPIKey: %v", err)
+	}
+	for name, key := range map[string]string{"old": oldKey, "new": newKey} {
+		if _, err := ValidateAPIKey(ctx, key); err != nil {
+			t.Errorf("expected %s key to work during grace period, got %v", name, err)
+		}
+	}
+
+	keys, _ := ListAPIKeys(ctx, "rotate@example.com")
+	if len(keys) != 2 || keys[0].ExpiresAt == nil || keys[1].ExpiresAt != nil {
+		t.Fatalf("expected old key with grace expiry and new key without, got %+v", keys)
+	}
+
+	// A zero grace revokes the old key immediately.
+	latest, _, err := RotateAPIKeyByID(ctx, "rotate@example.com", keys[1].ID, 0)
+	if err != nil {
+		t.Fatalf("RotateAPIKeyByID: %v", err)
+	}
+	if _, err := ValidateAPIKey(ctx, newKey); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Errorf("expected rotated-out key to be rejected, got %v", err)
+	}
+	if _, err := ValidateAPIKey(ctx, latest); err != nil {
+		t.Errorf("expected latest key to work, got %v", err)
+	}
+}
+
+func TestAPIKey_RotationAtKeyLimit(t *testing.T) {
+	setupMemoryStore(t)
+	ctx := context.Background()
+
+	if _, err := CreateUser(ctx, CreateUserRequest{Email: "full@example.com"}); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	var first *APIKey
+	for i := 0; i < maxAPIKeysPerUser; i++ {
+		_, key, err := CreateAPIKey(ctx, "full@example.com", CreateAPIKeyRequest{Name: "ci"})
+		if err != nil {
+			t.Fatalf("CreateAPIKey %d: %v", i, err)
+		}
+		if first == nil {
+			first = key
+		}
+	}
+	if _, _, err := CreateAPIKey(ctx, "full@example.com", CreateAPIKeyRequest{Name: "one-too-many"}); !errors.Is(err, ErrInvalidInput) {
+		t.Fatalf("expected the key limit to be enforced, got %v", err)
+	}
+
+	// Rotating keeps the old key in its grace period without counting it.
+	keyID := first.ID
+	for i := 0; i <= maxAPIKeysPerUser; i++ {
+		_, rotated, err := RotateAPIKeyByID(ctx, "full@example.com", keyID, time.Hour)
+		if err != nil {
+			t.Fatalf("rotation %d at the key limit: %v", i, err)
+		}
+		keyID = rotated.ID
+	}
+
+	keys, _ := ListAPIKeys(ctx, "full@example.com")
+	if len(keys) != 2*maxAPIKeysPerUser {
+		t.Errorf("expected %d current keys plus %d in grace, got %d", maxAPIKeysPerUser, maxAPIKeysPerUser, len(keys))
+	}
+	for _, k := range keys {
+		if k.ID == first.ID {
+			t.Errorf("expected the oldest grace key to be dropped beyond the limit")
+		}
+	}
+}
+
+func TestRecordAPIKeyUse_KeepsConcurrentChanges(t *testing.T) {
+	store := setupMemoryStore(t)
+	ctx := context.Background()
+
+	if _, err := CreateUser(ctx, CreateUserRequest{Email: "touch@example.com"}); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	_, used, err := CreateAPIKey(ctx, "touch@example.com", CreateAPIKeyRequest{Name: "used"})
+	if err != nil {
+		t.Fatalf("CreateAPIKey: %v", err)
+	}
+	_, revoked, err := CreateAPIKey(ctx, "touch@example.com", CreateAPIKeyRequest{Name: "revoked"})
+	if err != nil {
+		t.Fatalf("CreateAPIKey: %v", err)
+	}
+
+	// The key set changes between authentication and the last-use write.
+	if err := RevokeAPIKey(ctx, "touch@example.com", revoked.ID); err != nil {
+		t.Fatalf("RevokeAPIKey: %v", err)
+	}
+	recordAPIKeyUse(ctx, store, "touch@example.com", used.ID, time.Now())
+
+	keys, _ := ListAPIKeys(ctx, "touch@example.com")
+	if len(keys) != 1 || keys[0].ID != used.ID {
+		t.Fatalf("expected only the used key to remain, got %+v", keys)
+	}
+	if keys[0].LastUsedAt == nil {
+		t.Error("expected LastUsedAt to be recorded")
+	}
+}
+
+// blockingGetUserStore holds its next GetUser call, once armed, until
+// release is closed.
+type blockingGetUserStore struct {
+	*MemoryUserStore
+	armed   atomic.Bool
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (s *blockingGetUserStore) GetUser(ctx context.Context, username string) (*UserRecord, error) {
+	if s.armed.CompareAndSwap(true, false) {
+		close(s.entered)
+		<-s.release
+	}
+	return s.MemoryUserStore.GetUser(ctx, username)
+}
+
+func TestUpdateAPIKey_SerializedWithRecordAPIKeyUse(t *testing.T) {
+	ctx := context.Background()
+	store := &blockingGetUserStore{MemoryUserStore: NewMemoryUserStore(), entered: make(chan struct{}), release: make(chan struct{})}
+	m := newTestManager(t, &OAuthConfig{}, store, []CreateUserRequest{{Email: "race@example.com"}})
+	oldKey, used, err := m.CreateAPIKey(ctx, "race@example.com", CreateAPIKeyRequest{Name: "old"})
+	if err != nil {
+		t.Fatalf("CreateAPIKey: %v", err)
+	}
+	newKey := generateSecureAPIKey()
+
+	store.armed.Store(true)
+	touched := make(chan struct{})
+	go func() {
+		recordAPIKeyUse(ctx, store, "race@example.com", used.ID, time.Now())
+		close(touched)
+	}()
+	<-store.entered
+
+	// The replacement must wait for the last-use write that read the old set.
+	updated := make(chan error, 1)
+	go func() { updated <- m.UpdateAPIKey(ctx, "race@example.com", newKey) }()
+	select {
+	case err := <-updated:
+		t.Fatalf("expected UpdateAPIKey to wait for recordAPIKeyUse, got %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(store.release)
+	<-touched
+	if err := <-updated; err != nil {
+		t.Fatalf("UpdateAPIKey: %v", err)
+	}
+
+	if _, err := m.ValidateAPIKey(ctx, oldKey); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Errorf("expected the replaced key to stay revoked, got %v", err)
+	}
+	if _, err := m.ValidateAPIKey(ctx, newKey); err != nil {
+		t.Errorf("ValidateAPIKey(new): %v", err)
+	}
+}
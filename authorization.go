@@ -0,0 +1,257 @@
+package user
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+)
+
+// AllPermissions grants every permission to the role that holds it.
+const AllPermissions = "*"
+
+// RoleDefinition describes one role of the permission model: the
+// permissions it grants directly and the roles whose permissions it inherits.
+type RoleDefinition struct {
+	Inherits    []string `json:"inherits,omitempty"`
+	Permissions []string `json:"permissions,omitempty"`
+}
+
+// DefaultRoles returns the role hierarchy used when OAuthConfig.Roles is nil:
+// admin inherits manager, which inherits user. No permissions are granted, so
+// RequirePermission needs a configured map while RequireRole works as is.
+func DefaultRoles() map[string]RoleDefinition {
+	return map[string]RoleDefinition{
+		"admin":   {Inherits: []string{"manager"}},
+		"manager": {Inherits: []string{"user"}},
+		"user":    {},
+	}
+}
+
+// ParseRoles parses a role-to-permission map from JSON, e.g.
+//
+//	{"admin": {"inherits": ["manager"], "permissions": ["users:delete"]},
+//	 "manager": {"inherits": ["user"], "permissions": ["users:write"]},
+//	 "user": {"permissions": ["users:read"]}}
+//
+// Unknown parent roles and inheritance cycles are rejected.
+func ParseRoles(data []byte) (map[string]RoleDefinition, error) {
+	var roles map[string]RoleDefinition
+	if err := json.Unmarshal(data, &roles); err != nil {
+		return nil, fmt.Errorf("failed to parse roles: %w", err)
+	}
+	if _, err := compileRoles(roles); err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+// LoadRolesFile reads a role-to-permission map from a JSON file (see ParseRoles).
+func LoadRolesFile(path string) (map[string]RoleDefinition, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read roles file: %w", err)
+	}
+	return ParseRoles(data)
+}
+
+// rolePolicy is a role map with inheritance resolved.
+type rolePolicy struct {
+	implied     map[string]map[string]bool // role -> itself and every inherited role
+	permissions map[string]map[string]bool // role -> direct and inherited permissions
+}
+
+// compileRoles resolves inheritance, rejecting unknown parents and cycles.
+func compileRoles(roles map[string]RoleDefinition) (*rolePolicy, error) {
+	p := &rolePolicy{
+		implied:     make(map[string]map[string]bool, len(roles)),
+		permissions: make(map[string]map[string]bool, len(roles)),
+	}
+
+	visiting := make(map[string]bool)
+	var resolve func(role string, path []string) error
+	resolve = func(role string, path []string) error {
+		if _, done := p.implied[role]; done {
+			return nil
+		}
+		if visiting[role] {
+			return fmt.Errorf("role inheritance cycle %s: %w", strings.Join(append(path, role), " -> "), ErrInvalidInput)
+		}
+		visiting[role] = true
+		defer delete(visiting, role)
+
+		implied := map[string]bool{role: true}
+		permissions := make(map[string]bool)
+		for _, permission := range roles[role].Permissions {
+			permissions[permission] = true
+		}
+		for _, parent := range roles[role].Inherits {
+			if _, ok := roles[parent]; !ok {
+				return fmt.Errorf("role %q inherits unknown role %q: %w", role, parent, ErrInvalidInput)
+			}
+			if err := resolve(parent, append(path, role)); err != nil {
+				return err
+			}
+			for r := range p.implied[parent] {
+				implied[r] = true
+			}
+			for permission := range p.permissions[parent] {
+				permissions[permission] = true
+			}
+		}
+
+		p.implied[role] = implied
+		p.permissions[role] = permissions
+		return nil
+	}
+
+	names := make([]string, 0, len(roles))
+	for role := range roles {
+		names = append(names, role)
+	}
+	sort.Strings(names)
+	for _, role := range names {
+		if err := resolve(role, nil); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// claimsRoles returns the roles carried by claims. Both Role and the Cognito
+// custom:userRole attribute count.
+func claimsRoles(claims *Claims) []string {
+	var roles []string
+	if claims.Role != "" {
+		roles = append(roles, claims.Role)
+	}
+	if claims.UserRole != "" && claims.UserRole != claims.Role {
+		roles = append(roles, claims.UserRole)
+	}
+	return roles
+}
+
+func (p *rolePolicy) hasRole(claims *Claims, role string) bool {
+	for _, r := range claimsRoles(claims) {
+		if p.implied[r][role] {
+			return true
+		}
+	}
+	return false
+}
+
+// hasPermission reports whether one of the claims' roles grants permission.
+// Requests made with a scoped API key are further limited to its scopes.
+func (p *rolePolicy) hasPermission(claims *Claims, permission string) bool {
+	if len(claims.Scopes) > 0 && !claims.HasScope(permission) {
+		return false
+	}
+	for _, r := range claimsRoles(claims) {
+		if p.permissions[r][permission] || p.permissions[r][AllPermissions] {
+			return true
+		}
+	}
+	return false
+}
+
+// rolePolicy compiles the configured role map, or DefaultRoles.
+func (m *Manager) rolePolicy() (*rolePolicy, error) {
+	roles := DefaultRoles()
+	if config := m.Config(); config != nil && config.Roles != nil {
+		roles = config.Roles
+	}
+	return compileRoles(roles)
+}
+
+// HasRole reports whether claims hold role, directly or through inheritance.
+func (m *Manager) HasRole(claims *Claims, role string) bool {
+	if claims == nil {
+		return false
+	}
+	policy, err := m.rolePolicy()
+	if err != nil {
+		log.Printf("❌ [HasRole] Invalid role configuration: %v", err)
+		return false
+	}
+	return policy.hasRole(claims, role)
+}
+
+// HasPermission reports whether the roles in claims grant permission.
+func (m *Manager) HasPermission(claims *Claims, permission string) bool {
+	if claims == nil {
+		return false
+	}
+	policy, err := m.rolePolicy()
+	if err != nil {
+		log.Printf("❌ [HasPermission] Invalid role configuration: %v", err)
+		return false
+	}
+	return policy.hasPermission(claims, permission)
+}
+
+// RequireRole creates middleware that only lets through users holding role,
+// directly or through inheritance (an admin passes RequireRole("manager")).
+// It must run after RequireAuthMiddleware.
+func (m *Manager) RequireRole(role string) func(http.Handler) http.Handler {
+	return m.RequireAnyRole(role)
+}
+
+// RequireAnyRole creates middleware that lets through users holding at least
+// one of roles. It must run after RequireAuthMiddleware.
+func (m *Manager) RequireAnyRole(roles ...string) func(http.Handler) http.Handler {
+	return m.authorize("RequireAnyRole", func(policy *rolePolicy, claims *Claims) map[string]string {
+		for _, role := range roles {
+			if policy.hasRole(claims, role) {
+				return nil
+			}
+		}
+		return map[string]string{"required_role": strings.Join(roles, ",")}
+	})
+}
+
+// RequirePermission creates middleware that lets through users whose roles
+// grant every one of permissions. Requests made with a scoped API key also
+// need each permission among the key's scopes. It must run after
+// RequireAuthMiddleware.
+func (m *Manager) RequirePermission(permissions ...string) func(http.Handler) http.Handler {
+	return m.authorize("RequirePermission", func(policy *rolePolicy, claims *Claims) map[string]string {
+		for _, permission := range permissions {
+			if !policy.hasPermission(claims, permission) {
+				return map[string]string{"required_permission": permission}
+			}
+		}
+		return nil
+	})
+}
+
+// authorize wraps a check that returns the 403 details on denial, or nil.
+func (m *Manager) authorize(name string, check func(*rolePolicy, *Claims) map[string]string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := GetClaimsFromContext(r)
+			if !ok || claims == nil {
+				log.Printf("❌ [%s] No claims in context - is RequireAuthMiddleware applied?", name)
+				writeError(w, http.StatusUnauthorized, "unauthorized", nil)
+				return
+			}
+
+			policy, err := m.rolePolicy()
+			if err != nil {
+				log.Printf("❌ [%s] Invalid role configuration: %v", name, err)
+				writeError(w, http.StatusInternalServerError, "invalid role configuration", nil)
+				return
+			}
+
+			if details := check(policy, claims); details != nil {
+				log.Printf("❌ [%s] Access denied for %s (roles %v)", name, claims.Email, claimsRoles(claims))
+				writeError(w, http.StatusForbidden, "forbidden", details)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,188 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const testRolesJSON = `{
+	"admin":   {"inherits": ["manager"], "permissions": ["users:delete"]},
+	"manager": {"inherits": ["user"], "permissions": ["users:write"]},
+	"user":    {"permissions": ["users:read"]},
+	"auditor": {"permissions": ["audit:read"]},
+	"root":    {"permissions": ["*"]}
+}`
+
+func serveWithClaims(handler func(http.Handler) http.Handler, claims *Claims) *httptest.ResponseRecorder {
+	h := handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if claims != nil {
+		req = req.WithContext(context.WithValue(req.Context(), ClaimsKey, claims))
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func TestRequireRole_DefaultHierarchy(t *testing.T) {
+	m := NewManager(&OAuthConfig{})
+
+	tests := []struct {
+		name   string
+		claims *Claims
+		role   string
+		want   int
+	}{
+		{"admin passes manager", &Claims{Role: "admin"}, "manager", http.StatusOK},
+		{"admin passes user", &Claims{Role: "admin"}, "user", http.StatusOK},
+		{"manager passes manager", &Claims{Role: "manager"}, "manager", http.StatusOK},
+		{"user denied manager", &Claims{Role: "user"}, "manager", http.StatusForbidden},
+		{"unknown role denied", &Claims{Role: "guest"}, "user", http.StatusForbidden},
+		{"custom:userRole counts", &Claims{Role: "user", UserRole: "admin"}, "admin", http.StatusOK},
+		{"no claims", nil, "user", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := serveWithClaims(m.RequireRole(tt.role), tt.claims); w.Code != tt.want {
+				t.Errorf("expected %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestRequireAnyRole(t *testing.T) {
+	m := NewManager(&OAuthConfig{})
+
+	if w := serveWithClaims(m.RequireAnyRole("admin", "manager"), &Claims{Role: "manager"}); w.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d", w.Code)
+	}
+	w := serveWithClaims(m.RequireAnyRole("admin", "manager"), &Claims{Role: "user"})
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", w.Code)
+	}
+
+	var body ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("expected JSON error body: %v", err)
+	}
+	if body.Error != "forbidden" || body.Details["required_role"] != "admin,manager" {
+		t.Errorf("unexpected error body: %+v", body)
+	}
+}
+
+func TestRequirePermission(t *testing.T) {
+	roles, err := ParseRoles([]byte(testRolesJSON))
+	if err != nil {
+		t.Fatalf("ParseRoles: %v", err)
+	}
+	m := NewManager(&OAuthConfig{Roles: roles})
+
+	tests := []struct {
+		name        string
+		claims      *Claims
+		permissions []string
+		want        int
+	}{
+		{"direct permission", &Claims{Role: "user"}, []string{"users:read"}, http.StatusOK},
+		{"inherited permission", &Claims{Role: "admin"}, []string{"users:read", "users:write"}, http.StatusOK},
+		{"missing permission", &Claims{Role: "manager"}, []string{"users:delete"}, http.StatusForbidden},
+		{"all permissions required", &Claims{Role: "user"}, []string{"users:read", "users:write"}, http.StatusForbidden},
+		{"unrelated role", &Claims{Role: "auditor"}, []string{"users:read"}, http.StatusForbidden},
+		{"wildcard", &Claims{Role: "root"}, []string{"anything"}, http.StatusOK},
+		{"scoped key within scope", &Claims{Role: "admin", Scopes: []string{"users:read"}}, []string{"users:read"}, http.StatusOK},
+		{"scoped key outside scope", &Claims{Role: "admin", Scopes: []string{"users:read"}}, []string{"users:write"}, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := serveWithClaims(m.RequirePermission(tt.permissions...), tt.claims); w.Code != tt.want {
+				t.Errorf("expected %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+		})
+	}
+
+	w := serveWithClaims(m.RequirePermission("users:delete"), &Claims{Role: "user"})
+	var body ErrorResponse
+	json.Unmarshal(w.Body.Bytes(), &body)
+	if body.Details["required_permission"] != "users:delete" {
+		t.Errorf("expected required_permission detail, got %+v", body)
+	}
+}
+
+func TestParseRoles_RejectsInvalidHierarchy(t *testing.T) {
+	tests := map[string]string{
+		"cycle":          `{"a": {"inherits": ["b"]}, "b": {"inherits": ["a"]}}`,
+		"self cycle":     `{"a": {"inherits": ["a"]}}`,
+		"unknown parent": `{"a": {"inherits": ["missing"]}}`,
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ParseRoles([]byte(data)); !errors.Is(err, ErrInvalidInput) {
+				t.Errorf("expected ErrInvalidInput, got %v", err)
+			}
+		})
+	}
+
+	if _, err := ParseRoles([]byte(`not json`)); err == nil {
+		t.Error("expected error for malformed JSON")
+	}
+}
+
+func TestHasPermission(t *testing.T) {
+	roles, _ := ParseRoles([]byte(testRolesJSON))
+	m := NewManager(&OAuthConfig{Roles: roles})
+
+	if !m.HasPermission(&Claims{Role: "manager"}, "users:read") {
+		t.Error("expected manager to inherit users:read")
+	}
+	if m.HasPermission(nil, "users:read") {
+		t.Error("expected nil claims to have no permissions")
+	}
+	if !m.HasRole(&Claims{Role: "admin"}, "user") {
+		t.Error("expected admin to hold user")
+	}
+}
+
+func TestRequireRole_JWTRoleAttribute(t *testing.T) {
+	tests := []struct {
+		name       string
+		roleAttr   string
+		calculated string // CalculateDefaultRole's result, unset when empty
+		claims     map[string]any
+		want       int
+	}{
+		{"default custom:role", "", "", map[string]any{"custom:role": "admin"}, http.StatusOK},
+		{"configured attribute", "custom:accessLevel", "", map[string]any{"custom:accessLevel": "admin"}, http.StatusOK},
+		{"other attribute ignored", "custom:accessLevel", "", map[string]any{"custom:role": "admin"}, http.StatusForbidden},
+		{"no role attribute", "", "", nil, http.StatusForbidden},
+		{"calculated role wins over attribute", "", "admin", map[string]any{"custom:role": "user"}, http.StatusOK},
+		{"attribute does not override calculated role", "", "user", map[string]any{"custom:role": "admin"}, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issuer, sign := newTestIssuer(t)
+			config := &OAuthConfig{ClientID: testIssuerClientID, IssuerURL: issuer, RoleAttributeName: tt.roleAttr}
+			if tt.calculated != "" {
+				config.CalculateDefaultRole = func(*OIDCClaims) string { return tt.calculated }
+			}
+			m := NewManager(config)
+			handler := m.RequireAuthMiddleware()(m.RequireRole("admin")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})))
+
+			req := httptest.NewRequest(http.MethodGet, "/api/admin/users", nil)
+			req.AddCookie(&http.Cookie{Name: "jwt", Value: sign("sub-1", time.Now(), tt.claims)})
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("expected %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+		})
+	}
+}
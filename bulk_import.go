@@ -0,0 +1,387 @@
+package user
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/mail"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ImportFormat is the file format of BulkImportUsers and ExportUsers.
+type ImportFormat string
+
+const (
+	// ImportFormatCSV is a CSV file with a header row. Columns are the
+	// ExportedUser JSON names (email, givenName, familyName, name, picture,
+	// role, enabled, ...); "custom:<name>" or "customAttributes.<name>"
+	// columns become custom attributes.
+	ImportFormatCSV ImportFormat = "csv"
+	// ImportFormatJSONLines is one ExportedUser (or plain CreateUserRequest)
+	// JSON object per line.
+	ImportFormatJSONLines ImportFormat = "jsonl"
+)
+
+// ImportRowStatus is the outcome of one imported row.
+type ImportRowStatus string
+
+const (
+	ImportRowCreated         ImportRowStatus = "created"
+	ImportRowSkippedExisting ImportRowStatus = "skipped_existing"
+	ImportRowFailed          ImportRowStatus = "failed"
+	ImportRowWouldCreate     ImportRowStatus = "would_create" // Dry run only
+)
+
+const defaultImportConcurrency = 4
+
+// BulkImportOptions configures BulkImportUsers.
+type BulkImportOptions struct {
+	Format          ImportFormat
+	DryRun          bool   // Validate and check for existing users without creating any
+	Concurrency     int    // Parallel user creations (defaults to 4)
+	SendInvitations bool   // Email each created user their temporary password
+	LoginURL        string // Login link for invitation emails
+}
+
+// ImportRowResult reports what happened to one input row.
+type ImportRowResult struct {
+	Row    int             `json:"row"` // 1-based line number in the input
+	Email  string          `json:"email,omitempty"`
+	Status ImportRowStatus `json:"status"`
+	Reason string          `json:"reason,omitempty"`
+	// TemporaryPassword is set for created users when no invitation email
+	// was sent, so the caller can deliver it another way.
+	TemporaryPassword string `json:"temporaryPassword,omitempty"`
+	InvitationSent    bool   `json:"invitationSent,omitempty"`
+}
+
+// BulkImportReport is the per-row result of BulkImportUsers, in input order.
+type BulkImportReport struct {
+	DryRun          bool              `json:"dryRun"`
+	Rows            []ImportRowResult `json:"rows"`
+	Created         int               `json:"created"`
+	SkippedExisting int               `json:"skippedExisting"`
+	Failed          int               `json:"failed"`
+	WouldCreate     int               `json:"wouldCreate,omitempty"`
+}
+
+// importRow is a parsed input row awaiting import.
+type importRow struct {
+	line int
+	user ExportedUser
+	err  error // Parse or validation failure
+}
+
+// BulkImportUsers creates users from CSV or JSON lines input with
+// CreateUserWithInvitation. Every row is parsed and validated (email, custom
+// attribute normalization, attribute schema, duplicates within the input)
+// before the user pool is touched; invalid rows are reported as failed and the rest still import.
+// Existing users are skipped, not updated. Rows with "enabled" false are
+// created disabled and get no invitation email.
+//
+// The returned error is only for input that cannot be read at all (unknown
+// format, malformed CSV header); per-row problems are in the report.
+func (m *Manager) BulkImportUsers(ctx context.Context, r io.Reader, opts BulkImportOptions) (*BulkImportReport, error) {
+	var rows []importRow
+	var err error
+	switch opts.Format {
+	case ImportFormatCSV:
+		rows, err = parseImportCSV(r)
+	case ImportFormatJSONLines:
+		rows, err = parseImportJSONLines(r)
+	default:
+		return nil, fmt.Errorf("unsupported import format %q: %w", opts.Format, ErrInvalidInput)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	m.validateImportRows(rows)
+
+	store, err := m.userStore()
+	if err != nil {
+		return nil, err
+	}
+
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultImportConcurrency
+	}
+
+	results := make([]ImportRowResult, len(rows))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				if opts.DryRun {
+					results[idx] = m.checkImportRow(ctx, store, rows[idx])
+				} else {
+					results[idx] = m.importRow(ctx, rows[idx], opts)
+				}
+			}
+		}()
+	}
+
+	for idx, row := range rows {
+		if row.err != nil {
+			results[idx] = ImportRowResult{Row: row.line, Email: row.user.Email, Status: ImportRowFailed, Reason: row.err.Error()}
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			results[idx] = ImportRowResult{Row: row.line, Email: row.user.Email, Status: ImportRowFailed, Reason: err.Error()}
+			continue
+		}
+		jobs <- idx
+	}
+	close(jobs)
+	wg.Wait()
+
+	report := &BulkImportReport{DryRun: opts.DryRun, Rows: results}
+	for _, result := range results {
+		switch result.Status {
+		case ImportRowCreated:
+			report.Created++
+		case ImportRowSkippedExisting:
+			report.SkippedExisting++
+		case ImportRowFailed:
+			report.Failed++
+		case ImportRowWouldCreate:
+			report.WouldCreate++
+		}
+	}
+
+	log.Printf("✅ [BulkImportUsers] %d rows: %d created, %d skipped, %d failed, %d would create (dry run: %v)",
+		len(rows), report.Created, report.SkippedExisting, report.Failed, report.WouldCreate, opts.DryRun)
+	return report, nil
+}
+
+// validateImportRows marks rows that cannot be imported, without any
+// backend calls.
+func (m *Manager) validateImportRows(rows []importRow) {
+	roleAttr := m.roleAttributeName()
+	firstLine := make(map[string]int)
+
+	for i := range rows {
+		row := &rows[i]
+		if row.err != nil {
+			continue
+		}
+
+		email := row.user.Email
+		if email == "" {
+			row.err = fmt.Errorf("email is required: %w", ErrInvalidInput)
+			continue
+		}
+		if addr, err := mail.ParseAddress(email); err != nil || addr.Address != email {
+			row.err = fmt.Errorf("invalid email %q: %w", email, ErrInvalidInput)
+			continue
+		}
+		attributes, err := newUserAttributes(row.user.CreateUserRequest, roleAttr)
+		if err == nil {
+			err = m.validateNewUserAttributes(attributes)
+		}
+		if err != nil {
+			row.err = err
+			continue
+		}
+
+		key := strings.ToLower(email)
+		if line, seen := firstLine[key]; seen {
+			row.err = fmt.Errorf("duplicate of row %d: %w", line, ErrInvalidInput)
+			continue
+		}
+		firstLine[key] = row.line
+	}
+}
+
+// checkImportRow reports what importing a valid row would do.
+func (m *Manager) checkImportRow(ctx context.Context, store UserStore, row importRow) ImportRowResult {
+	result := ImportRowResult{Row: row.line, Email: row.user.Email}
+
+	_, err := store.GetUser(ctx, row.user.Email)
+	switch {
+	case err == nil:
+		result.Status = ImportRowSkippedExisting
+	case errors.Is(err, ErrUserNotFound):
+		result.Status = ImportRowWouldCreate
+	default:
+		result.Status = ImportRowFailed
+		result.Reason = err.Error()
+	}
+	return result
+}
+
+// importRow creates one valid row's user and optionally emails the invitation.
+func (m *Manager) importRow(ctx context.Context, row importRow, opts BulkImportOptions) ImportRowResult {
+	result := ImportRowResult{Row: row.line, Email: row.user.Email}
+
+	user, tempPassword, err := m.CreateUserWithInvitation(ctx, row.user.CreateUserRequest)
+	if err != nil {
+		if errors.Is(err, ErrUserAlreadyExists) {
+			result.Status = ImportRowSkippedExisting
+			return result
+		}
+		result.Status = ImportRowFailed
+		result.Reason = err.Error()
+		return result
+	}
+	result.Status = ImportRowCreated
+
+	if row.user.Enabled != nil && !*row.user.Enabled {
+		result.TemporaryPassword = tempPassword
+		if err := m.DisableUser(ctx, user.Email); err != nil {
+			result.Reason = fmt.Sprintf("user created but could not be disabled: %v", err)
+		}
+		return result
+	}
+
+	if !opts.SendInvitations {
+		result.TemporaryPassword = tempPassword
+		return result
+	}
+
+	err = m.SendInvitationEmail(ctx, InvitationEmailRequest{
+		Email:        user.Email,
+		Username:     user.Username,
+		TempPassword: tempPassword,
+		Role:         user.Role,
+		LoginURL:     opts.LoginURL,
+	})
+	if err != nil {
+		log.Printf("⚠️ [BulkImportUsers] Invitation email to %s failed: %v", user.Email, err)
+		result.TemporaryPassword = tempPassword
+		result.Reason = fmt.Sprintf("user created but invitation email failed: %v", err)
+		return result
+	}
+	result.InvitationSent = true
+	return result
+}
+
+// importCSVSetter stores one CSV cell in a row's user.
+type importCSVSetter func(u *ExportedUser, value string) error
+
+// importCSVColumns maps lower-cased CSV header names to ExportedUser fields.
+var importCSVColumns = map[string]importCSVSetter{
+	"email":      func(u *ExportedUser, v string) error { u.Email = v; return nil },
+	"givenname":  func(u *ExportedUser, v string) error { u.GivenName = v; return nil },
+	"familyname": func(u *ExportedUser, v string) error { u.FamilyName = v; return nil },
+	"name":       func(u *ExportedUser, v string) error { u.Name = v; return nil },
+	"picture":    func(u *ExportedUser, v string) error { u.Picture = v; return nil },
+	"role":       func(u *ExportedUser, v string) error { u.Role = v; return nil },
+	"username":   func(u *ExportedUser, v string) error { u.Username = v; return nil },
+	"userstatus": func(u *ExportedUser, v string) error { u.UserStatus = v; return nil },
+	"enabled": func(u *ExportedUser, v string) error {
+		if v == "" {
+			return nil
+		}
+		enabled, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("invalid enabled value %q: %w", v, ErrInvalidInput)
+		}
+		u.Enabled = &enabled
+		return nil
+	},
+}
+
+func parseImportCSV(r io.Reader) ([]importRow, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+
+	setters := make([]importCSVSetter, len(header))
+	for i, column := range header {
+		column = strings.TrimSpace(column)
+		if i == 0 {
+			column = strings.TrimPrefix(column, "\ufeff")
+		}
+
+		var custom string
+		switch {
+		case strings.HasPrefix(column, "custom:"):
+			custom = strings.TrimPrefix(column, "custom:")
+		case strings.HasPrefix(column, "customAttributes."):
+			custom = strings.TrimPrefix(column, "customAttributes.")
+		}
+		if custom != "" {
+			setters[i] = func(u *ExportedUser, v string) error {
+				if u.CustomAttributes == nil {
+					u.CustomAttributes = make(map[string]string)
+				}
+				u.CustomAttributes[custom] = v
+				return nil
+			}
+			continue
+		}
+
+		setter, ok := importCSVColumns[strings.ToLower(column)]
+		if !ok {
+			return nil, fmt.Errorf("unknown CSV column %q: %w", column, ErrInvalidInput)
+		}
+		setters[i] = setter
+	}
+
+	var rows []importRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return nil, fmt.Errorf("failed to read CSV: %w", err)
+			}
+			rows = append(rows, importRow{line: parseErr.StartLine, err: fmt.Errorf("malformed CSV row: %w", ErrInvalidInput)})
+			continue
+		}
+
+		line, _ := reader.FieldPos(0)
+		row := importRow{line: line}
+		for i, value := range record {
+			if err := setters[i](&row.user, strings.TrimSpace(value)); err != nil && row.err == nil {
+				row.err = err
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func parseImportJSONLines(r io.Reader) ([]importRow, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var rows []importRow
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		row := importRow{line: line}
+		decoder := json.NewDecoder(strings.NewReader(text))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&row.user); err != nil {
+			row.err = fmt.Errorf("malformed JSON: %v: %w", err, ErrInvalidInput)
+		}
+		rows = append(rows, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read JSON lines: %w", err)
+	}
+	return rows, nil
+}
@@ -0,0 +1,245 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ses"
+)
+
+// mockSESClient records the recipients and text bodies of every SendEmail call.
+type mockSESClient struct {
+	mu     sync.Mutex
+	sent   []string
+	bodies []string
+	fail   string // Recipient whose send fails
+}
+
+func (m *mockSESClient) SendEmail(ctx context.Context, params *ses.SendEmailInput, optFns ...func(*ses.Options)) (*ses.SendEmailOutput, error) {
+	to := params.Destination.ToAddresses[0]
+	if to == m.fail {
+		return nil, errors.New("message rejected")
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent = append(m.sent, to)
+	m.bodies = append(m.bodies, aws.ToString(params.Message.Body.Text.Data))
+	return &ses.SendEmailOutput{MessageId: aws.String("id")}, nil
+}
+
+// concurrencyUserStore tracks the peak number of concurrent CreateUser calls.
+type concurrencyUserStore struct {
+	*MemoryUserStore
+	mu      sync.Mutex
+	active  int
+	peak    int
+	creates int
+}
+
+func (s *concurrencyUserStore) CreateUser(ctx context.Context, username string, attributes map[string]string) (*UserRecord, error) {
+	s.mu.Lock()
+	s.active++
+	s.creates++
+	if s.active > s.peak {
+		s.peak = s.active
+	}
+	s.mu.Unlock()
+
+	time.Sleep(5 * time.Millisecond)
+
+	s.mu.Lock()
+	s.active--
+	s.mu.Unlock()
+	return s.MemoryUserStore.CreateUser(ctx, username, attributes)
+}
+
+func TestBulkImportUsers_CSV(t *testing.T) {
+	ctx := context.Background()
+	m := newTestManager(t, &OAuthConfig{}, NewMemoryUserStore(), []CreateUserRequest{{Email: "existing@example.com"}})
+
+	input := strings.Join([]string{
+		"email,givenName,familyName,role,custom:tenantId,customAttributes.serviceProviderId",
+		"alice@example.com,Alice,Smith,admin,acme,sp1",
+		"existing@example.com,Ex,Isting,,acme,",
+		",No,Email,,,",
+		"not-an-email,Bad,Email,,,",
+		"ALICE@example.com,Alice,Again,,,",
+		"bob@example.com,Bob,Jones,,globex,",
+	}, "\n")
+
+	report, err := m.BulkImportUsers(ctx, strings.NewReader(input), BulkImportOptions{Format: ImportFormatCSV})
+	if err != nil {
+		t.Fatalf("BulkImportUsers: %v", err)
+	}
+
+	want := []struct {
+		email  string
+		status ImportRowStatus
+		reason string
+	}{
+		{"alice@example.com", ImportRowCreated, ""},
+		{"existing@example.com", ImportRowSkippedExisting, ""},
+		{"", ImportRowFailed, "email is required"},
+		{"not-an-email", ImportRowFailed, "invalid email"},
+		{"ALICE@example.com", ImportRowFailed, "duplicate of row 2"},
+		{"bob@example.com", ImportRowCreated, ""},
+	}
+	if len(report.Rows) != len(want) {
+		t.Fatalf("expected %d rows, got %+v", len(want), report.Rows)
+	}
+	for i, w := range want {
+		row := report.Rows[i]
+		if row.Row != i+2 || row.Email != w.email || row.Status != w.status || !strings.Contains(row.Reason, w.reason) {
+			t.Errorf("row %d: expected %s %s %q, got %+v", i+2, w.email, w.status, w.reason, row)
+		}
+	}
+	if report.Created != 2 || report.SkippedExisting != 1 || report.Failed != 3 {
+		t.Errorf("unexpected totals: %+v", report)
+	}
+	if report.Rows[0].TemporaryPassword == "" {
+		t.Error("expected the temporary password when no invitation is sent")
+	}
+
+	alice, err := m.GetUser(ctx, "alice@example.com")
+	if err != nil {
+		t.Fatalf("GetUser: %v", err)
+	}
+	if alice.Role != "admin" || alice.TenantID != "acme" || alice.ServiceProviderID != "sp1" || alice.GivenName != "Alice" {
+		t.Errorf("unexpected imported user: %+v", alice)
+	}
+}
+
+func TestBulkImportUsers_ValidatesBeforeCreating(t *testing.T) {
+	store := &concurrencyUserStore{MemoryUserStore: NewMemoryUserStore()}
+	m := NewManager(&OAuthConfig{}, WithUserStore(store))
+
+	input := "email,custom:role\nmallory@example.com,admin\n"
+	report, err := m.BulkImportUsers(context.Background(), strings.NewReader(input), BulkImportOptions{Format: ImportFormatCSV})
+	if err != nil {
+		t.Fatalf("BulkImportUsers: %v", err)
+	}
+	if report.Failed != 1 || !strings.Contains(report.Rows[0].Reason, "collides with role attribute") {
+		t.Errorf("expected the role collision to fail the row, got %+v", report.Rows)
+	}
+	if store.creates != 0 {
+		t.Errorf("expected no CreateUser calls for invalid rows, got %d", store.creates)
+	}
+}
+
+func TestBulkImportUsers_DryRun(t *testing.T) {
+	ctx := context.Background()
+	store := &concurrencyUserStore{MemoryUserStore: NewMemoryUserStore()}
+	m := NewManager(&OAuthConfig{}, WithUserStore(store))
+	store.MemoryUserStore.CreateUser(ctx, "existing@example.com", map[string]string{"email": "existing@example.com"})
+
+	input := `{"email":"new@example.com","customAttributes":{"tenantId":"acme"}}
+
+{"email":"existing@example.com"}
+{"email":"bad@example.com","unknown":true}
+`
+	report, err := m.BulkImportUsers(ctx, strings.NewReader(input), BulkImportOptions{Format: ImportFormatJSONLines, DryRun: true})
+	if err != nil {
+		t.Fatalf("BulkImportUsers: %v", err)
+	}
+
+	if !report.DryRun || report.WouldCreate != 1 || report.SkippedExisting != 1 || report.Failed != 1 {
+		t.Errorf("unexpected totals: %+v", report)
+	}
+	if report.Rows[1].Row != 3 || report.Rows[2].Row != 4 {
+		t.Errorf("expected rows to keep their line numbers across blank lines, got %+v", report.Rows)
+	}
+	if store.creates != 0 {
+		t.Errorf("expected a dry run to create nothing, got %d CreateUser calls", store.creates)
+	}
+	if _, err := m.GetUser(ctx, "new@example.com"); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("expected new@example.com not to exist after a dry run, got %v", err)
+	}
+}
+
+func TestBulkImportUsers_ReportsMalformedCSVRow(t *testing.T) {
+	m := NewManager(&OAuthConfig{}, WithUserStore(NewMemoryUserStore()))
+
+	input := "email,role\n\"a@b.c,user\n"
+	report, err := m.BulkImportUsers(context.Background(), strings.NewReader(input), BulkImportOptions{Format: ImportFormatCSV, DryRun: true})
+	if err != nil {
+		t.Fatalf("BulkImportUsers: %v", err)
+	}
+
+	if report.Failed != 1 || len(report.Rows) != 1 {
+		t.Fatalf("expected one failed row, got %+v", report)
+	}
+	if report.Rows[0].Row != 2 {
+		t.Errorf("expected the malformed row to be reported on line 2, got %d", report.Rows[0].Row)
+	}
+}
+
+func TestBulkImportUsers_BoundedConcurrency(t *testing.T) {
+	store := &concurrencyUserStore{MemoryUserStore: NewMemoryUserStore()}
+	m := NewManager(&OAuthConfig{}, WithUserStore(store))
+
+	var b strings.Builder
+	b.WriteString("email\n")
+	for _, name := range []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j"} {
+		b.WriteString(name + "@example.com\n")
+	}
+
+	report, err := m.BulkImportUsers(context.Background(), strings.NewReader(b.String()), BulkImportOptions{Format: ImportFormatCSV, Concurrency: 3})
+	if err != nil {
+		t.Fatalf("BulkImportUsers: %v", err)
+	}
+	if report.Created != 10 {
+		t.Fatalf("expected 10 users created, got %+v", report)
+	}
+	if store.peak > 3 {
+		t.Errorf("expected at most 3 concurrent creations, saw %d", store.peak)
+	}
+	for i, row := range report.Rows {
+		if row.Row != i+2 {
+			t.Errorf("expected results in input order, row %d reported as %d", i+2, row.Row)
+		}
+	}
+}
+
+func TestBulkImportUsers_SendsInvitations(t *testing.T) {
+	sesClient := &mockSESClient{fail: "bounce@example.com"}
+	m := NewManager(&OAuthConfig{FromEmail: "noreply@example.com", AppName: "Test"},
+		WithUserStore(NewMemoryUserStore()), WithSESClient(sesClient))
+
+	input := "email\nalice@example.com\nbounce@example.com\n"
+	report, err := m.BulkImportUsers(context.Background(), strings.NewReader(input), BulkImportOptions{
+		Format:          ImportFormatCSV,
+		SendInvitations: true,
+		LoginURL:        "https://app.example.com/login",
+	})
+	if err != nil {
+		t.Fatalf("BulkImportUsers: %v", err)
+	}
+
+	alice, bounce := report.Rows[0], report.Rows[1]
+	if !alice.InvitationSent || alice.TemporaryPassword != "" {
+		t.Errorf("expected an invitation without the password in the report, got %+v", alice)
+	}
+	if bounce.Status != ImportRowCreated || bounce.InvitationSent || bounce.TemporaryPassword == "" || !strings.Contains(bounce.Reason, "invitation email failed") {
+		t.Errorf("expected a created row reporting the failed invitation, got %+v", bounce)
+	}
+	if len(sesClient.sent) != 1 || sesClient.sent[0] != "alice@example.com" {
+		t.Errorf("unexpected emails sent: %v", sesClient.sent)
+	}
+}
+
+func TestBulkImportUsers_RejectsUnreadableInput(t *testing.T) {
+	m := NewManager(&OAuthConfig{}, WithUserStore(NewMemoryUserStore()))
+	ctx := context.Background()
+
+	if _, err := m.BulkImportUsers(ctx, strings.NewReader("email,phone\n"), BulkImportOptions{Format: ImportFormatCSV}); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("expected ErrInvalidInput for an unknown column, got %v", err)
+	}
+	if _, err := m.BulkImportUsers(ctx, strings.NewReader(""), BulkImportOptions{Format: "xml"}); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("expected ErrInvalidInput for an unknown format, got %v", err)
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"sort"
 	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -13,34 +12,42 @@ import (
 )
 
 func getCognitoClient(ctx context.Context, oauthConfig *OAuthConfig) (CognitoClient, error) {
-	if oauthConfig == nil {
+	return defaultAWSClients(oauthConfig).cognitoClient(ctx)
+}
+
+func (c *awsClients) cognitoClient(ctx context.Context) (CognitoClient, error) {
+	if c.config == nil {
 		return nil, fmt.Errorf("oauth config is required")
 	}
 
-	if oauthConfig.UserPoolID == "" {
+	if c.config.UserPoolID == "" {
 		return nil, fmt.Errorf("userPoolID is required")
 	}
 
-	if oauthConfig.Region == "" {
+	if c.config.Region == "" {
 		return nil, fmt.Errorf("region is required")
 	}
 
-	cfg, err := loadAWSConfig(ctx, oauthConfig)
+	if c.cognito != nil {
+		return c.cognito, nil
+	}
+
+	cfg, err := c.loadAWSConfig(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load AWS config: %w", err)
 	}
 
-	return cognitoClientFactory(ctx, cfg, oauthConfig.UserPoolID), nil
+	return cognitoClientFactory(ctx, cfg, c.config.UserPoolID), nil
 }
 
-func cognitoGetUser(ctx context.Context, email string, oauthConfig *OAuthConfig) (*types.UserType, error) {
-	client, err := getCognitoClient(ctx, oauthConfig)
+func cognitoGetUser(ctx context.Context, email string, clients *awsClients) (*cognitoidentityprovider.AdminGetUserOutput, error) {
+	client, err := clients.cognitoClient(ctx)
 	if err != nil {
 		return nil, err
 	}
 
 	input := &cognitoidentityprovider.AdminGetUserInput{
-		UserPoolId: aws.String(oauthConfig.UserPoolID),
+		UserPoolId: aws.String(clients.config.UserPoolID),
 		Username:   aws.String(email),
 	}
 
@@ -49,92 +56,28 @@ func cognitoGetUser(ctx context.Context, email string, oauthConfig *OAuthConfig)
 		return nil, wrapCognitoError(err, "AdminGetUser")
 	}
 
-	user := &types.UserType{
-		Username:   result.Username,
-		Attributes: result.UserAttributes,
-		UserStatus: result.UserStatus,
-		Enabled:    result.Enabled,
-	}
-
-	return user, nil
+	return result, nil
 }
 
 func cognitoCreateUser(ctx context.Context, req CreateUserRequest, oauthConfig *OAuthConfig) (*types.UserType, error) {
-	client, err := getCognitoClient(ctx, oauthConfig)
+	attributes, err := newUserAttributes(req, roleAttributeName(oauthConfig))
 	if err != nil {
 		return nil, err
 	}
 
-	attributes := []types.AttributeType{
-		{
-			Name:  aws.String("email"),
-			Value: aws.String(req.Email),
-		},
-		{
-			Name:  aws.String("email_verified"),
-			Value: aws.String("true"),
-		},
-	}
-
-	if req.GivenName != "" {
-		attributes = append(attributes, types.AttributeType{
-			Name:  aws.String("given_name"),
-			Value: aws.String(req.GivenName),
-		})
-	}
-
-	if req.FamilyName != "" {
-		attributes = append(attributes, types.AttributeType{
-			Name:  aws.String("family_name"),
-			Value: aws.String(req.FamilyName),
-		})
-	}
-
-	if req.Name != "" {
-		attributes = append(attributes, types.AttributeType{
-			Name:  aws.String("name"),
-			Value: aws.String(req.Name),
-		})
-	}
-
-	if req.Picture != "" {
-		attributes = append(attributes, types.AttributeType{
-			Name:  aws.String("picture"),
-			Value: aws.String(req.Picture),
-		})
-	}
-
-	role := req.Role
-	if role == "" {
-		role = "user"
-	}
-	attributes = append(attributes, types.AttributeType{
-		Name:  aws.String(getRoleAttributeName()),
-		Value: aws.String(role),
-	})
+	return cognitoCreateUserWithAttributes(ctx, req.Email, attributes, defaultAWSClients(oauthConfig))
+}
 
-	if len(req.CustomAttributes) > 0 {
-		normAttrs, err := normalizeCustomAttributes(req.CustomAttributes, getRoleAttributeName())
-		if err != nil {
-			return nil, err
-		}
-		keys := make([]string, 0, len(normAttrs))
-		for k := range normAttrs {
-			keys = append(keys, k)
-		}
-		sort.Strings(keys)
-		for _, attrName := range keys {
-			attributes = append(attributes, types.AttributeType{
-				Name:  aws.String(attrName),
-				Value: aws.String(normAttrs[attrName]),
-			})
-		}
+func cognitoCreateUserWithAttributes(ctx context.Context, username string, attributes map[string]string, clients *awsClients) (*types.UserType, error) {
+	client, err := clients.cognitoClient(ctx)
+	if err != nil {
+		return nil, err
 	}
 
 	input := &cognitoidentityprovider.AdminCreateUserInput{
-		UserPoolId:     aws.String(oauthConfig.UserPoolID),
-		Username:       aws.String(req.Email),
-		UserAttributes: attributes,
+		UserPoolId:     aws.String(clients.config.UserPoolID),
+		Username:       aws.String(username),
+		UserAttributes: toCognitoAttributes(attributes),
 		MessageAction:  types.MessageActionTypeSuppress,
 	}
 
@@ -146,18 +89,18 @@ func cognitoCreateUser(ctx context.Context, req CreateUserRequest, oauthConfig *
 	return result.User, nil
 }
 
-func cognitoSetTemporaryPassword(ctx context.Context, email, password string, oauthConfig *OAuthConfig) error {
-	return cognitoSetUserPassword(ctx, email, password, false, oauthConfig)
+func cognitoSetTemporaryPassword(ctx context.Context, email, password string, clients *awsClients) error {
+	return cognitoSetUserPassword(ctx, email, password, false, clients)
 }
 
-func cognitoSetUserPassword(ctx context.Context, email, password string, permanent bool, oauthConfig *OAuthConfig) error {
-	client, err := getCognitoClient(ctx, oauthConfig)
+func cognitoSetUserPassword(ctx context.Context, email, password string, permanent bool, clients *awsClients) error {
+	client, err := clients.cognitoClient(ctx)
 	if err != nil {
 		return err
 	}
 
 	input := &cognitoidentityprovider.AdminSetUserPasswordInput{
-		UserPoolId: aws.String(oauthConfig.UserPoolID),
+		UserPoolId: aws.String(clients.config.UserPoolID),
 		Username:   aws.String(email),
 		Password:   aws.String(password),
 		Permanent:  permanent,
@@ -171,8 +114,8 @@ func cognitoSetUserPassword(ctx context.Context, email, password string, permane
 	return nil
 }
 
-func cognitoUpdateUserAttributes(ctx context.Context, email string, attributes []types.AttributeType, oauthConfig *OAuthConfig) error {
-	client, err := getCognitoClient(ctx, oauthConfig)
+func cognitoUpdateUserAttributes(ctx context.Context, email string, attributes []types.AttributeType, clients *awsClients) error {
+	client, err := clients.cognitoClient(ctx)
 	if err != nil {
 		return err
 	}
@@ -182,7 +125,7 @@ func cognitoUpdateUserAttributes(ctx context.Context, email string, attributes [
 	}
 
 	input := &cognitoidentityprovider.AdminUpdateUserAttributesInput{
-		UserPoolId:     aws.String(oauthConfig.UserPoolID),
+		UserPoolId:     aws.String(clients.config.UserPoolID),
 		Username:       aws.String(email),
 		UserAttributes: attributes,
 	}
@@ -195,14 +138,38 @@ func cognitoUpdateUserAttributes(ctx context.Context, email string, attributes [
 	return nil
 }
 
-func cognitoDeleteUser(ctx context.Context, email string, oauthConfig *OAuthConfig) error {
-	client, err := getCognitoClient(ctx, oauthConfig)
+func cognitoDeleteUserAttributes(ctx context.Context, email string, names []string, clients *awsClients) error {
+	client, err := clients.cognitoClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(names) == 0 {
+		return nil
+	}
+
+	input := &cognitoidentityprovider.AdminDeleteUserAttributesInput{
+		UserPoolId:         aws.String(clients.config.UserPoolID),
+		Username:           aws.String(email),
+		UserAttributeNames: names,
+	}
+
+	_, err = client.AdminDeleteUserAttributes(ctx, input)
+	if err != nil {
+		return wrapCognitoError(err, "AdminDeleteUserAttributes")
+	}
+
+	return nil
+}
+
+func cognitoDeleteUser(ctx context.Context, email string, clients *awsClients) error {
+	client, err := clients.cognitoClient(ctx)
 	if err != nil {
 		return err
 	}
 
 	input := &cognitoidentityprovider.AdminDeleteUserInput{
-		UserPoolId: aws.String(oauthConfig.UserPoolID),
+		UserPoolId: aws.String(clients.config.UserPoolID),
 		Username:   aws.String(email),
 	}
 
@@ -214,8 +181,10 @@ func cognitoDeleteUser(ctx context.Context, email string, oauthConfig *OAuthConf
 	return nil
 }
 
-func cognitoListUsers(ctx context.Context, limit int32, paginationToken *string, oauthConfig *OAuthConfig) ([]types.UserType, *string, error) {
-	client, err := getCognitoClient(ctx, oauthConfig)
+// cognitoListUsers lists one page of users. filter is a Cognito ListUsers
+// filter expression, or "" for all users.
+func cognitoListUsers(ctx context.Context, limit int32, paginationToken *string, filter string, clients *awsClients) ([]types.UserType, *string, error) {
+	client, err := clients.cognitoClient(ctx)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -228,13 +197,16 @@ func cognitoListUsers(ctx context.Context, limit int32, paginationToken *string,
 	}
 
 	input := &cognitoidentityprovider.ListUsersInput{
-		UserPoolId: aws.String(oauthConfig.UserPoolID),
+		UserPoolId: aws.String(clients.config.UserPoolID),
 		Limit:      aws.Int32(limit),
 	}
 
 	if paginationToken != nil {
 		input.PaginationToken = paginationToken
 	}
+	if filter != "" {
+		input.Filter = aws.String(filter)
+	}
 
 	result, err := client.ListUsers(ctx, input)
 	if err != nil {
@@ -245,123 +217,17 @@ func cognitoListUsers(ctx context.Context, limit int32, paginationToken *string,
 }
 
 func cognitoUserToUser(cognitoUser types.UserType) (*User, error) {
-	user := &User{}
-
-	// Store Cognito username (stable identifier)
-	if cognitoUser.Username != nil {
-		user.Username = *cognitoUser.Username
-	}
-
-	for _, attr := range cognitoUser.Attributes {
-		if attr.Name == nil || attr.Value == nil {
-			continue
-		}
-		switch *attr.Name {
-		case "email":
-			user.Email = *attr.Value
-		case "given_name":
-			user.GivenName = *attr.Value
-		case "family_name":
-			user.FamilyName = *attr.Value
-		case "name":
-			user.Name = *attr.Value
-		case "picture":
-			user.Picture = *attr.Value
-		case "custom:role", "custom:userRole":
-			user.Role = *attr.Value
-		case "custom:apiKey", "custom:api_key":
-			user.APIKey = *attr.Value
-		case "custom:tenantId":
-			user.TenantID = *attr.Value
-		case "custom:serviceProviderId":
-			user.ServiceProviderID = *attr.Value
-		}
-	}
-
-	if user.Email == "" {
-		if cognitoUser.Username != nil {
-			user.Email = *cognitoUser.Username
-		} else {
-			return nil, fmt.Errorf("user has no email attribute")
-		}
-	}
-
-	user.UserStatus = string(cognitoUser.UserStatus)
-	user.Enabled = cognitoUser.Enabled
-
-	if user.Role == "" {
-		user.Role = "user"
-	}
-
-	return user, nil
+	return recordToUser(cognitoUserToRecord(cognitoUser), getRoleAttributeName())
 }
 
-func userToCognitoAttributes(user *User, update *ProfileUpdate) []types.AttributeType {
-	attributes := []types.AttributeType{}
-
-	if update != nil {
-		if update.GivenName != nil {
-			attributes = append(attributes, types.AttributeType{
-				Name:  aws.String("given_name"),
-				Value: aws.String(*update.GivenName),
-			})
-		}
-		if update.FamilyName != nil {
-			attributes = append(attributes, types.AttributeType{
-				Name:  aws.String("family_name"),
-				Value: aws.String(*update.FamilyName),
-			})
-		}
-		if update.Name != nil {
-			attributes = append(attributes, types.AttributeType{
-				Name:  aws.String("name"),
-				Value: aws.String(*update.Name),
-			})
-		}
-		if update.Picture != nil {
-			attributes = append(attributes, types.AttributeType{
-				Name:  aws.String("picture"),
-				Value: aws.String(*update.Picture),
-			})
-		}
-	} else if user != nil {
-		if user.GivenName != "" {
-			attributes = append(attributes, types.AttributeType{
-				Name:  aws.String("given_name"),
-				Value: aws.String(user.GivenName),
-			})
-		}
-		if user.FamilyName != "" {
-			attributes = append(attributes, types.AttributeType{
-				Name:  aws.String("family_name"),
-				Value: aws.String(user.FamilyName),
-			})
-		}
-		if user.Name != "" {
-			attributes = append(attributes, types.AttributeType{
-				Name:  aws.String("name"),
-				Value: aws.String(user.Name),
-			})
-		}
-		if user.Picture != "" {
-			attributes = append(attributes, types.AttributeType{
-				Name:  aws.String("picture"),
-				Value: aws.String(user.Picture),
-			})
-		}
-	}
-
-	return attributes
-}
-
-func cognitoDisableUser(ctx context.Context, email string, oauthConfig *OAuthConfig) error {
-	client, err := getCognitoClient(ctx, oauthConfig)
+func cognitoDisableUser(ctx context.Context, email string, clients *awsClients) error {
+	client, err := clients.cognitoClient(ctx)
 	if err != nil {
 		return err
 	}
 
 	input := &cognitoidentityprovider.AdminDisableUserInput{
-		UserPoolId: aws.String(oauthConfig.UserPoolID),
+		UserPoolId: aws.String(clients.config.UserPoolID),
 		Username:   aws.String(email),
 	}
 
@@ -373,14 +239,14 @@ func cognitoDisableUser(ctx context.Context, email string, oauthConfig *OAuthCon
 	return nil
 }
 
-func cognitoEnableUser(ctx context.Context, email string, oauthConfig *OAuthConfig) error {
-	client, err := getCognitoClient(ctx, oauthConfig)
+func cognitoEnableUser(ctx context.Context, email string, clients *awsClients) error {
+	client, err := clients.cognitoClient(ctx)
 	if err != nil {
 		return err
 	}
 
 	input := &cognitoidentityprovider.AdminEnableUserInput{
-		UserPoolId: aws.String(oauthConfig.UserPoolID),
+		UserPoolId: aws.String(clients.config.UserPoolID),
 		Username:   aws.String(email),
 	}
 
@@ -392,16 +258,35 @@ func cognitoEnableUser(ctx context.Context, email string, oauthConfig *OAuthConf
 	return nil
 }
 
+func cognitoGlobalSignOut(ctx context.Context, username string, clients *awsClients) error {
+	client, err := clients.cognitoClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	input := &cognitoidentityprovider.AdminUserGlobalSignOutInput{
+		UserPoolId: aws.String(clients.config.UserPoolID),
+		Username:   aws.String(username),
+	}
+
+	_, err = client.AdminUserGlobalSignOut(ctx, input)
+	if err != nil {
+		return wrapCognitoError(err, "AdminUserGlobalSignOut")
+	}
+
+	return nil
+}
+
 // GetUserPoolPreSignUpARN returns the ARN of the pre-sign-up Lambda trigger
 // configured on the given Cognito user pool, or an empty string if no
 // pre-sign-up trigger is configured. Returns an error if the describe call
 // fails or the pool does not exist.
-func GetUserPoolPreSignUpARN(ctx context.Context, userPoolID string) (string, error) {
+func (m *Manager) GetUserPoolPreSignUpARN(ctx context.Context, userPoolID string) (string, error) {
 	if userPoolID == "" {
 		return "", fmt.Errorf("userPoolID is required")
 	}
 
-	client, err := getCognitoClient(ctx, oauthConfig)
+	client, err := m.clients().cognitoClient(ctx)
 	if err != nil {
 		return "", fmt.Errorf("get pre-sign-up ARN: %w", err)
 	}
@@ -418,6 +303,34 @@ func GetUserPoolPreSignUpARN(ctx context.Context, userPoolID string) (string, er
 	return *out.UserPool.LambdaConfig.PreSignUp, nil
 }
 
+// cognitoPasswordPolicy reads the user pool's password policy, or nil when
+// the pool reports none.
+func cognitoPasswordPolicy(ctx context.Context, clients *awsClients) (*PasswordPolicy, error) {
+	client, err := clients.cognitoClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := client.DescribeUserPool(ctx, &cognitoidentityprovider.DescribeUserPoolInput{
+		UserPoolId: aws.String(clients.config.UserPoolID),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("describe user pool %s: %w", clients.config.UserPoolID, err)
+	}
+	if out.UserPool == nil || out.UserPool.Policies == nil || out.UserPool.Policies.PasswordPolicy == nil {
+		return nil, nil
+	}
+
+	p := out.UserPool.Policies.PasswordPolicy
+	return &PasswordPolicy{
+		MinimumLength:    int(aws.ToInt32(p.MinimumLength)),
+		RequireLowercase: p.RequireLowercase,
+		RequireUppercase: p.RequireUppercase,
+		RequireNumbers:   p.RequireNumbers,
+		RequireSymbols:   p.RequireSymbols,
+	}, nil
+}
+
 func wrapCognitoError(err error, operation string) error {
 	log.Printf("❌ [Cognito] %s failed: %v", operation, err)
 
@@ -428,6 +341,9 @@ func wrapCognitoError(err error, operation string) error {
 		if strings.Contains(err.Error(), "UsernameExistsException") || strings.Contains(err.Error(), "already exists") {
 			return fmt.Errorf("%s: %w", operation, ErrUserAlreadyExists)
 		}
+		if strings.Contains(err.Error(), "InvalidPasswordException") {
+			return fmt.Errorf("%s: InvalidPasswordException: %w", operation, ErrInvalidInput)
+		}
 		if strings.Contains(err.Error(), "InvalidParameterException") {
 			return fmt.Errorf("%s: %w", operation, ErrInvalidInput)
 		}
@@ -0,0 +1,205 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider/types"
+)
+
+func (s *cognitoUserStore) CreateGroup(ctx context.Context, group Group) (*Group, error) {
+	client, err := s.clients.cognitoClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	input := &cognitoidentityprovider.CreateGroupInput{
+		UserPoolId: aws.String(s.clients.config.UserPoolID),
+		GroupName:  aws.String(group.Name),
+		Precedence: group.Precedence,
+	}
+	if group.Description != "" {
+		input.Description = aws.String(group.Description)
+	}
+	if group.RoleARN != "" {
+		input.RoleArn = aws.String(group.RoleARN)
+	}
+
+	result, err := client.CreateGroup(ctx, input)
+	if err != nil {
+		return nil, wrapCognitoGroupError(err, "CreateGroup")
+	}
+	if result.Group == nil {
+		return &group, nil
+	}
+	return cognitoGroupToGroup(*result.Group), nil
+}
+
+func (s *cognitoUserStore) DeleteGroup(ctx context.Context, name string) error {
+	client, err := s.clients.cognitoClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = client.DeleteGroup(ctx, &cognitoidentityprovider.DeleteGroupInput{
+		UserPoolId: aws.String(s.clients.config.UserPoolID),
+		GroupName:  aws.String(name),
+	})
+	if err != nil {
+		return wrapCognitoGroupError(err, "DeleteGroup")
+	}
+	return nil
+}
+
+func (s *cognitoUserStore) ListGroups(ctx context.Context, limit int, pageToken string) ([]*Group, string, error) {
+	client, err := s.clients.cognitoClient(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	input := &cognitoidentityprovider.ListGroupsInput{
+		UserPoolId: aws.String(s.clients.config.UserPoolID),
+		Limit:      aws.Int32(cognitoPageLimit(limit)),
+	}
+	if pageToken != "" {
+		input.NextToken = aws.String(pageToken)
+	}
+
+	result, err := client.ListGroups(ctx, input)
+	if err != nil {
+		return nil, "", wrapCognitoGroupError(err, "ListGroups")
+	}
+	return cognitoGroupsToGroups(result.Groups), aws.ToString(result.NextToken), nil
+}
+
+func (s *cognitoUserStore) AddUserToGroup(ctx context.Context, username, group string) error {
+	client, err := s.clients.cognitoClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = client.AdminAddUserToGroup(ctx, &cognitoidentityprovider.AdminAddUserToGroupInput{
+		UserPoolId: aws.String(s.clients.config.UserPoolID),
+		Username:   aws.String(username),
+		GroupName:  aws.String(group),
+	})
+	if err != nil {
+		return wrapCognitoGroupError(err, "AdminAddUserToGroup")
+	}
+	return nil
+}
+
+func (s *cognitoUserStore) RemoveUserFromGroup(ctx context.Context, username, group string) error {
+	client, err := s.clients.cognitoClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = client.AdminRemoveUserFromGroup(ctx, &cognitoidentityprovider.AdminRemoveUserFromGroupInput{
+		UserPoolId: aws.String(s.clients.config.UserPoolID),
+		Username:   aws.String(username),
+		GroupName:  aws.String(group),
+	})
+	if err != nil {
+		return wrapCognitoGroupError(err, "AdminRemoveUserFromGroup")
+	}
+	return nil
+}
+
+func (s *cognitoUserStore) ListUsersInGroup(ctx context.Context, group string, limit int, pageToken string) ([]*UserRecord, string, error) {
+	client, err := s.clients.cognitoClient(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	input := &cognitoidentityprovider.ListUsersInGroupInput{
+		UserPoolId: aws.String(s.clients.config.UserPoolID),
+		GroupName:  aws.String(group),
+		Limit:      aws.Int32(cognitoPageLimit(limit)),
+	}
+	if pageToken != "" {
+		input.NextToken = aws.String(pageToken)
+	}
+
+	result, err := client.ListUsersInGroup(ctx, input)
+	if err != nil {
+		return nil, "", wrapCognitoGroupError(err, "ListUsersInGroup")
+	}
+
+	records := make([]*UserRecord, 0, len(result.Users))
+	for _, u := range result.Users {
+		records = append(records, cognitoUserToRecord(u))
+	}
+	return records, aws.ToString(result.NextToken), nil
+}
+
+func (s *cognitoUserStore) ListGroupsForUser(ctx context.Context, username string) ([]*Group, error) {
+	client, err := s.clients.cognitoClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var groups []*Group
+	var token *string
+	for {
+		result, err := client.AdminListGroupsForUser(ctx, &cognitoidentityprovider.AdminListGroupsForUserInput{
+			UserPoolId: aws.String(s.clients.config.UserPoolID),
+			Username:   aws.String(username),
+			Limit:      aws.Int32(60),
+			NextToken:  token,
+		})
+		if err != nil {
+			return nil, wrapCognitoError(err, "AdminListGroupsForUser")
+		}
+		groups = append(groups, cognitoGroupsToGroups(result.Groups)...)
+
+		if aws.ToString(result.NextToken) == "" {
+			return groups, nil
+		}
+		token = result.NextToken
+	}
+}
+
+// cognitoPageLimit clamps limit to Cognito's 1-60 page size.
+func cognitoPageLimit(limit int) int32 {
+	if limit <= 0 || limit > 60 {
+		return 60
+	}
+	return int32(limit)
+}
+
+func cognitoGroupToGroup(g types.GroupType) *Group {
+	return &Group{
+		Name:        aws.ToString(g.GroupName),
+		Description: aws.ToString(g.Description),
+		Precedence:  g.Precedence,
+		RoleARN:     aws.ToString(g.RoleArn),
+	}
+}
+
+func cognitoGroupsToGroups(groups []types.GroupType) []*Group {
+	out := make([]*Group, 0, len(groups))
+	for _, g := range groups {
+		out = append(out, cognitoGroupToGroup(g))
+	}
+	return out
+}
+
+// wrapCognitoGroupError maps group errors before falling back to
+// wrapCognitoError. Cognito reports a missing group as
+// ResourceNotFoundException, which must not read as a missing user.
+func wrapCognitoGroupError(err error, operation string) error {
+	switch {
+	case strings.Contains(err.Error(), "GroupExistsException"):
+		log.Printf("❌ [Cognito] %s failed: %v", operation, err)
+		return fmt.Errorf("%s: %w", operation, ErrGroupAlreadyExists)
+	case strings.Contains(err.Error(), "ResourceNotFoundException"):
+		log.Printf("❌ [Cognito] %s failed: %v", operation, err)
+		return fmt.Errorf("%s: %w", operation, ErrGroupNotFound)
+	}
+	return wrapCognitoError(err, operation)
+}
@@ -0,0 +1,126 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider/types"
+)
+
+func (s *cognitoUserStore) SetMFAPreference(ctx context.Context, username string, methods []MFAMethod, preferred MFAMethod) error {
+	client, err := s.clients.cognitoClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = client.AdminSetUserMFAPreference(ctx, &cognitoidentityprovider.AdminSetUserMFAPreferenceInput{
+		UserPoolId: aws.String(s.clients.config.UserPoolID),
+		Username:   aws.String(username),
+		SMSMfaSettings: &types.SMSMfaSettingsType{
+			Enabled:      hasMFAMethod(methods, MFAMethodSMS),
+			PreferredMfa: preferred == MFAMethodSMS,
+		},
+		SoftwareTokenMfaSettings: &types.SoftwareTokenMfaSettingsType{
+			Enabled:      hasMFAMethod(methods, MFAMethodTOTP),
+			PreferredMfa: preferred == MFAMethodTOTP,
+		},
+	})
+	if err != nil {
+		return wrapCognitoMFAError(err, "AdminSetUserMFAPreference")
+	}
+	return nil
+}
+
+func (s *cognitoUserStore) AccessTokenUsername(ctx context.Context, accessToken string) (string, error) {
+	client, err := s.clients.cognitoClient(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	result, err := client.GetUser(ctx, &cognitoidentityprovider.GetUserInput{
+		AccessToken: aws.String(accessToken),
+	})
+	if err != nil {
+		return "", wrapCognitoMFAError(err, "GetUser")
+	}
+	if aws.ToString(result.Username) == "" {
+		return "", fmt.Errorf("GetUser returned no username")
+	}
+	return aws.ToString(result.Username), nil
+}
+
+func (s *cognitoUserStore) AssociateSoftwareToken(ctx context.Context, accessToken string) (string, error) {
+	client, err := s.clients.cognitoClient(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	result, err := client.AssociateSoftwareToken(ctx, &cognitoidentityprovider.AssociateSoftwareTokenInput{
+		AccessToken: aws.String(accessToken),
+	})
+	if err != nil {
+		return "", wrapCognitoMFAError(err, "AssociateSoftwareToken")
+	}
+	if aws.ToString(result.SecretCode) == "" {
+		return "", fmt.Errorf("AssociateSoftwareToken returned no secret")
+	}
+	return aws.ToString(result.SecretCode), nil
+}
+
+func (s *cognitoUserStore) VerifySoftwareToken(ctx context.Context, accessToken, code, deviceName string) error {
+	client, err := s.clients.cognitoClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	input := &cognitoidentityprovider.VerifySoftwareTokenInput{
+		AccessToken: aws.String(accessToken),
+		UserCode:    aws.String(code),
+	}
+	if deviceName != "" {
+		input.FriendlyDeviceName = aws.String(deviceName)
+	}
+
+	result, err := client.VerifySoftwareToken(ctx, input)
+	if err != nil {
+		return wrapCognitoMFAError(err, "VerifySoftwareToken")
+	}
+	if result.Status != types.VerifySoftwareTokenResponseTypeSuccess {
+		return fmt.Errorf("VerifySoftwareToken: code not accepted: %w", ErrInvalidInput)
+	}
+	return nil
+}
+
+// mfaMethodsOf maps AdminGetUser's UserMFASettingList.
+func mfaMethodsOf(settings []string) []MFAMethod {
+	if len(settings) == 0 {
+		return nil
+	}
+	methods := make([]MFAMethod, 0, len(settings))
+	for _, s := range settings {
+		methods = append(methods, MFAMethod(s))
+	}
+	return methods
+}
+
+// wrapCognitoMFAError maps MFA errors before falling back to
+// wrapCognitoError: a wrong code, an invalid access token and enabling a
+// method the user has not set up are all bad input.
+func wrapCognitoMFAError(err error, operation string) error {
+	for _, exception := range []string{
+		"CodeMismatchException",
+		"EnableSoftwareTokenMFAException",
+		"SoftwareTokenMFANotFoundException",
+		"NotAuthorizedException",
+	} {
+		if strings.Contains(err.Error(), exception) {
+			log.Printf("❌ [Cognito] %s failed: %v", operation, err)
+			return fmt.Errorf("%s: %s: %w", operation, exception, ErrInvalidInput)
+		}
+	}
+	return wrapCognitoError(err, operation)
+}
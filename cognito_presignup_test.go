@@ -11,6 +11,8 @@ import (
 )
 
 type mockPreSignUpCognitoClient struct {
+	cognitoClientStubs
+
 	output *cognitoidentityprovider.DescribeUserPoolOutput
 	err    error
 
@@ -2,6 +2,7 @@ package user
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -14,10 +15,12 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider/types"
 )
 
-// CognitoClient interface for Cognito operations (exported for testing)
+// CognitoClient interface for Cognito operations (exported for testing).
+// It grows with the package; see the README migration guide when upgrading.
 type CognitoClient interface {
 	ListUsers(ctx context.Context, params *cognitoidentityprovider.ListUsersInput, optFns ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.ListUsersOutput, error)
 	AdminUpdateUserAttributes(ctx context.Context, params *cognitoidentityprovider.AdminUpdateUserAttributesInput, optFns ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.AdminUpdateUserAttributesOutput, error)
+	AdminDeleteUserAttributes(ctx context.Context, params *cognitoidentityprovider.AdminDeleteUserAttributesInput, optFns ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.AdminDeleteUserAttributesOutput, error)
 	AdminGetUser(ctx context.Context, params *cognitoidentityprovider.AdminGetUserInput, optFns ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.AdminGetUserOutput, error)
 	AdminCreateUser(ctx context.Context, params *cognitoidentityprovider.AdminCreateUserInput, optFns ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.AdminCreateUserOutput, error)
 	AdminDeleteUser(ctx context.Context, params *cognitoidentityprovider.AdminDeleteUserInput, optFns ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.AdminDeleteUserOutput, error)
@@ -25,6 +28,22 @@ type CognitoClient interface {
 	AdminDisableUser(ctx context.Context, params *cognitoidentityprovider.AdminDisableUserInput, optFns ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.AdminDisableUserOutput, error)
 	AdminEnableUser(ctx context.Context, params *cognitoidentityprovider.AdminEnableUserInput, optFns ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.AdminEnableUserOutput, error)
 	DescribeUserPool(ctx context.Context, params *cognitoidentityprovider.DescribeUserPoolInput, optFns ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.DescribeUserPoolOutput, error)
+	CreateGroup(ctx context.Context, params *cognitoidentityprovider.CreateGroupInput, optFns ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.CreateGroupOutput, error)
+	DeleteGroup(ctx context.Context, params *cognitoidentityprovider.DeleteGroupInput, optFns ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.DeleteGroupOutput, error)
+	ListGroups(ctx context.Context, params *cognitoidentityprovider.ListGroupsInput, optFns ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.ListGroupsOutput, error)
+	AdminAddUserToGroup(ctx context.Context, params *cognitoidentityprovider.AdminAddUserToGroupInput, optFns ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.AdminAddUserToGroupOutput, error)
+	AdminRemoveUserFromGroup(ctx context.Context, params *cognitoidentityprovider.AdminRemoveUserFromGroupInput, optFns ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.AdminRemoveUserFromGroupOutput, error)
+	ListUsersInGroup(ctx context.Context, params *cognitoidentityprovider.ListUsersInGroupInput, optFns ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.ListUsersInGroupOutput, error)
+	AdminListGroupsForUser(ctx context.Context, params *cognitoidentityprovider.AdminListGroupsForUserInput, optFns ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.AdminListGroupsForUserOutput, error)
+	AdminUserGlobalSignOut(ctx context.Context, params *cognitoidentityprovider.AdminUserGlobalSignOutInput, optFns ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.AdminUserGlobalSignOutOutput, error)
+	AdminSetUserMFAPreference(ctx context.Context, params *cognitoidentityprovider.AdminSetUserMFAPreferenceInput, optFns ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.AdminSetUserMFAPreferenceOutput, error)
+	GetUser(ctx context.Context, params *cognitoidentityprovider.GetUserInput, optFns ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.GetUserOutput, error)
+	AssociateSoftwareToken(ctx context.Context, params *cognitoidentityprovider.AssociateSoftwareTokenInput, optFns ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.AssociateSoftwareTokenOutput, error)
+	VerifySoftwareToken(ctx context.Context, params *cognitoidentityprovider.VerifySoftwareTokenInput, optFns ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.VerifySoftwareTokenOutput, error)
+	InitiateAuth(ctx context.Context, params *cognitoidentityprovider.InitiateAuthInput, optFns ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.InitiateAuthOutput, error)
+	AdminInitiateAuth(ctx context.Context, params *cognitoidentityprovider.AdminInitiateAuthInput, optFns ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.AdminInitiateAuthOutput, error)
+	RespondToAuthChallenge(ctx context.Context, params *cognitoidentityprovider.RespondToAuthChallengeInput, optFns ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.RespondToAuthChallengeOutput, error)
+	AdminRespondToAuthChallenge(ctx context.Context, params *cognitoidentityprovider.AdminRespondToAuthChallengeInput, optFns ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.AdminRespondToAuthChallengeOutput, error)
 }
 
 var (
@@ -76,95 +95,74 @@ func FindUserClaimsByToken(ctx context.Context, token string, oauthConfig *OAuth
 		return nil, fmt.Errorf("region is required")
 	}
 
-	cfg, err := loadAWSConfig(ctx, oauthConfig)
-	if err != nil {
-		return nil, fmt.Errorf("failed to load AWS config: %w", err)
-	}
+	return findClaimsByToken(ctx, NewCognitoUserStore(oauthConfig), token, oauthConfig)
+}
 
-	client := cognitoClientFactory(ctx, cfg, oauthConfig.UserPoolID)
+// errTokenNotFound is returned when no user holds the presented token.
+var errTokenNotFound = errors.New("no user found with token")
+
+// findClaimsByToken looks up the single user whose stored API key matches token.
+func findClaimsByToken(ctx context.Context, store UserStore, token string, oauthConfig *OAuthConfig) (*Claims, error) {
+	claims, _, err := findTokenClaims(ctx, store, token, oauthConfig)
+	return claims, err
+}
 
-	filter := fmt.Sprintf("%s = \"%s\"", tokenAttributeName, token)
-	input := &cognitoidentityprovider.ListUsersInput{
-		UserPoolId: aws.String(oauthConfig.UserPoolID),
-		Filter:     aws.String(filter),
-		Limit:      aws.Int32(2),
+// findTokenClaims is findClaimsByToken that also returns the matched key,
+// or nil for a plaintext key that has not been migrated.
+func findTokenClaims(ctx context.Context, store UserStore, token string, oauthConfig *OAuthConfig) (*Claims, *storedAPIKey, error) {
+	if token == "" {
+		return nil, nil, fmt.Errorf("token cannot be empty")
 	}
 
-	result, err := client.ListUsers(ctx, input)
+	matches, err := matchAPIKey(ctx, store, token)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query Cognito users: %w", err)
+		return nil, nil, fmt.Errorf("failed to query users: %w", err)
 	}
 
-	if len(result.Users) == 0 {
-		return nil, fmt.Errorf("no user found with token")
+	if len(matches) == 0 {
+		return nil, nil, errTokenNotFound
 	}
 
-	if len(result.Users) > 1 {
-		return nil, fmt.Errorf("multiple users found with same token")
+	if len(matches) > 1 {
+		return nil, nil, fmt.Errorf("multiple users found with same token")
 	}
 
-	user := result.Users[0]
-	claims, err := cognitoUserToClaims(user, oauthConfig)
+	claims, err := recordToClaims(matches[0].record, oauthConfig)
 	if err != nil {
-		return nil, fmt.Errorf("failed to convert Cognito user to claims: %w", err)
+		return nil, nil, fmt.Errorf("failed to convert user to claims: %w", err)
 	}
 
-	return claims, nil
+	key := matches[0].key
+	if key != nil {
+		claims.APIKeyID = key.ID
+		claims.Scopes = key.Scopes
+	}
+	touchAPIKey(ctx, store, matches[0])
+
+	return claims, key, nil
 }
 
-func cognitoUserToClaims(cognitoUser types.UserType, oauthConfig *OAuthConfig) (*Claims, error) {
+func recordToClaims(record *UserRecord, oauthConfig *OAuthConfig) (*Claims, error) {
+	attrs := record.Attributes
 	claims := &Claims{
-		Provider: "token",
-	}
-
-	var username string
-	email := ""
-	givenName := ""
-	familyName := ""
-	picture := ""
-	apiKey := ""
-	role := ""
-	userRole := ""
-
-	for _, attr := range cognitoUser.Attributes {
-		switch *attr.Name {
-		case "sub":
-			claims.Sub = *attr.Value
-		case "email":
-			email = *attr.Value
-			claims.Email = email
-		case "given_name":
-			givenName = *attr.Value
-			claims.GivenName = givenName
-		case "family_name":
-			familyName = *attr.Value
-			claims.FamilyName = familyName
-		case "name":
-			claims.Name = *attr.Value
-		case "picture":
-			picture = *attr.Value
-			claims.Picture = picture
-		case "custom:apiKey", "custom:api_key":
-			apiKey = *attr.Value
-			claims.APIKey = apiKey
-		case "custom:role":
-			role = *attr.Value
-			claims.Role = role
-		case "custom:userRole":
-			userRole = *attr.Value
-			claims.UserRole = userRole
-		case "custom:tenantId":
-			claims.TenantID = *attr.Value
-		case "custom:serviceProviderId":
-			claims.ServiceProviderID = *attr.Value
-		}
-	}
-
-	if cognitoUser.Username != nil {
-		username = *cognitoUser.Username
-		claims.Username = username
-	} else if email != "" {
-		claims.Username = email
+		Provider:          "token",
+		Sub:               attrs["sub"],
+		Email:             attrs["email"],
+		GivenName:         attrs["given_name"],
+		FamilyName:        attrs["family_name"],
+		Name:              attrs["name"],
+		Picture:           attrs["picture"],
+		APIKey:            firstNonEmpty(attrs["custom:apiKey"], attrs["custom:api_key"]),
+		Role:              attrs[roleAttributeName(oauthConfig)],
+		UserRole:          attrs["custom:userRole"],
+		TenantID:          attrs["custom:tenantId"],
+		ServiceProviderID: attrs["custom:serviceProviderId"],
+	}
+
+	if record.Username != "" {
+		claims.Username = record.Username
+	} else if claims.Email != "" {
+		claims.Username = claims.Email
 	} else {
 		return nil, fmt.Errorf("user has no username or email")
 	}
@@ -180,7 +178,7 @@ func cognitoUserToClaims(cognitoUser types.UserType, oauthConfig *OAuthConfig) (
 
 	if claims.Role == "" {
 		defaultRole := "user"
-		if oauthConfig.CalculateDefaultRole != nil {
+		if oauthConfig != nil && oauthConfig.CalculateDefaultRole != nil {
 			oidcClaims := &OIDCClaims{
 				Email:      claims.Email,
 				GivenName:  claims.GivenName,
@@ -230,12 +228,19 @@ func WithUserAWSCredentials(r *http.Request) context.Context {
 }
 
 func loadAWSConfig(ctx context.Context, oauthConfig *OAuthConfig) (aws.Config, error) {
+	return defaultAWSClients(oauthConfig).loadAWSConfig(ctx)
+}
+
+// loadAWSConfig builds an AWS config for the client's region, exchanging a JWT
+// carried on the context for STS credentials when one is present.
+func (c *awsClients) loadAWSConfig(ctx context.Context) (aws.Config, error) {
+	oauthConfig := c.config
 	if oauthConfig.Region == "" {
 		return aws.Config{}, fmt.Errorf("region is required")
 	}
 
 	if token := JWTFromContext(ctx); token != "" {
-		stsCreds, err := GetSTSCredentials(ctx, token, oauthConfig)
+		stsCreds, err := c.stsCredentials(ctx, token)
 		if err != nil {
 			log.Printf("[loadAWSConfig] STS credential exchange failed, falling back to default chain: %v", err)
 		} else {
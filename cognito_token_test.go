@@ -12,6 +12,8 @@ import (
 )
 
 type mockCognitoClient struct {
+	cognitoClientStubs
+
 	users map[string]*cognitoidentityprovider.ListUsersOutput
 }
 
@@ -66,6 +68,7 @@ func TestFindUserClaimsByToken(t *testing.T) {
 					Users: []types.UserType{
 						{
 							Username: aws.String("testuser"),
+							Enabled:  true,
 							Attributes: []types.AttributeType{
 								{Name: aws.String("sub"), Value: aws.String("user-123")},
 								{Name: aws.String("email"), Value: aws.String("test@example.com")},
@@ -132,6 +135,7 @@ func TestFindUserClaimsByToken(t *testing.T) {
 					Users: []types.UserType{
 						{
 							Username: aws.String("nameduser"),
+							Enabled:  true,
 							Attributes: []types.AttributeType{
 								{Name: aws.String("sub"), Value: aws.String("user-456")},
 								{Name: aws.String("email"), Value: aws.String("named@example.com")},
@@ -223,12 +227,14 @@ func TestFindUserClaimsByToken(t *testing.T) {
 					Users: []types.UserType{
 						{
 							Username: aws.String("user1"),
+							Enabled:  true,
 							Attributes: []types.AttributeType{
 								{Name: aws.String("email"), Value: aws.String("user1@example.com")},
 							},
 						},
 						{
 							Username: aws.String("user2"),
+							Enabled:  true,
 							Attributes: []types.AttributeType{
 								{Name: aws.String("email"), Value: aws.String("user2@example.com")},
 							},
@@ -10,6 +10,8 @@ import (
 )
 
 type mockUpdateCognitoClient struct {
+	cognitoClientStubs
+
 	updateCalls []*cognitoidentityprovider.AdminUpdateUserAttributesInput
 }
 
@@ -0,0 +1,153 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// AttributeDeleter is an optional UserStore extension for backends that can
+// remove attributes outright rather than set them to "". The Cognito store
+// implements it with AdminDeleteUserAttributes.
+type AttributeDeleter interface {
+	// DeleteAttributes removes the named attributes; names the user does not have are ignored.
+	DeleteAttributes(ctx context.Context, username string, names []string) error
+}
+
+// deleteStoreAttributes removes the named attributes, falling back to
+// setting them to "" on stores that cannot delete.
+func deleteStoreAttributes(ctx context.Context, store UserStore, username string, names []string) error {
+	if deleter, ok := store.(AttributeDeleter); ok {
+		return deleter.DeleteAttributes(ctx, username, names)
+	}
+	cleared := make(map[string]string, len(names))
+	for _, name := range names {
+		cleared[name] = ""
+	}
+	return store.UpdateAttributes(ctx, username, cleared)
+}
+
+// protectedAttributes returns the custom attributes that the custom attribute
+// API refuses to touch: the role attributes (changed with UpdateRole) and the
+// API key attributes (managed by the API key functions).
+func protectedAttributes(roleAttr string) map[string]bool {
+	return map[string]bool{
+		normalizeCustomAttributeName(roleAttr):               true,
+		"custom:role":                                        true,
+		"custom:userRole":                                    true,
+		normalizeCustomAttributeName(tokenAttributeName):     true,
+		normalizeCustomAttributeName(tokenHashAttributeName): true,
+		"custom:api_key":                                     true,
+	}
+}
+
+// isAPIKeyAttribute reports whether name holds API key material.
+func isAPIKeyAttribute(name string) bool {
+	return name == normalizeCustomAttributeName(tokenAttributeName) ||
+		name == normalizeCustomAttributeName(tokenHashAttributeName) ||
+		name == "custom:api_key"
+}
+
+// customAttributesOf returns the non-empty custom:* attributes of attrs keyed
+// without the "custom:" prefix, leaving out API key material. Nil when there
+// are none.
+func customAttributesOf(attrs map[string]string) map[string]string {
+	var custom map[string]string
+	for name, value := range attrs {
+		if !strings.HasPrefix(name, "custom:") || value == "" || isAPIKeyAttribute(name) {
+			continue
+		}
+		if custom == nil {
+			custom = make(map[string]string)
+		}
+		custom[strings.TrimPrefix(name, "custom:")] = value
+	}
+	return custom
+}
+
+// GetCustomAttributes returns the user's custom attributes keyed without the
+// "custom:" prefix. API key attributes are never included.
+func (m *Manager) GetCustomAttributes(ctx context.Context, email string) (map[string]string, error) {
+	user, err := m.GetUser(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	if user.CustomAttributes == nil {
+		return map[string]string{}, nil
+	}
+	return user.CustomAttributes, nil
+}
+
+// UpdateCustomAttributes sets the given custom attributes, leaving all others
+// untouched. Names may be given with or without the "custom:" prefix and are
+// normalized like CreateUserRequest.CustomAttributes; names that collide with
+// different values, the role attributes or the API key attributes are
+// rejected with ErrInvalidInput. Empty values are ignored; use
+// DeleteCustomAttributes to clear an attribute.
+func (m *Manager) UpdateCustomAttributes(ctx context.Context, email string, attributes map[string]string) (*User, error) {
+	if email == "" {
+		return nil, fmt.Errorf("email cannot be empty: %w", ErrInvalidInput)
+	}
+
+	roleAttr := m.roleAttributeName()
+	normalized, err := normalizeCustomAttributes(attributes, roleAttr)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkUnprotectedAttributes(keysOf(normalized), roleAttr); err != nil {
+		return nil, err
+	}
+	if len(normalized) == 0 {
+		return m.GetUser(ctx, email)
+	}
+
+	return m.updateAttributes(ctx, email, normalized)
+}
+
+// DeleteCustomAttributes removes the named custom attributes. Names follow
+// the same rules as UpdateCustomAttributes; removing an attribute the user
+// does not have is not an error.
+func (m *Manager) DeleteCustomAttributes(ctx context.Context, email string, names ...string) (*User, error) {
+	if email == "" {
+		return nil, fmt.Errorf("email cannot be empty: %w", ErrInvalidInput)
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("at least one attribute name is required: %w", ErrInvalidInput)
+	}
+
+	cleared := make(map[string]string, len(names))
+	for _, name := range names {
+		if name == "" || name == "custom:" {
+			return nil, fmt.Errorf("attribute name cannot be empty: %w", ErrInvalidInput)
+		}
+		cleared[normalizeCustomAttributeName(name)] = ""
+	}
+	if err := checkUnprotectedAttributes(keysOf(cleared), m.roleAttributeName()); err != nil {
+		return nil, err
+	}
+
+	return m.deleteAttributes(ctx, email, keysOf(cleared))
+}
+
+// checkUnprotectedAttributes rejects any normalized name in names that is a
+// role or API key attribute.
+func checkUnprotectedAttributes(names []string, roleAttr string) error {
+	protected := protectedAttributes(roleAttr)
+	for _, name := range names {
+		if protected[name] {
+			return fmt.Errorf("custom attribute %q is managed by the package and cannot be changed directly: %w", name, ErrInvalidInput)
+		}
+	}
+	return nil
+}
+
+// keysOf returns the keys of attrs in sorted order, for deterministic errors.
+func keysOf(attrs map[string]string) []string {
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
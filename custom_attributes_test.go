@@ -0,0 +1,139 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider/types"
+)
+
+func newCustomAttributesTestManager(t *testing.T) *Manager {
+	t.Helper()
+	m := newTestManager(t, &OAuthConfig{}, NewMemoryUserStore(), []CreateUserRequest{{
+		Email:            "alice@example.com",
+		CustomAttributes: map[string]string{"tenantId": "acme", "department": "sales"},
+	}})
+	if _, err := m.GenerateAPIKey(context.Background(), "alice@example.com"); err != nil {
+		t.Fatalf("GenerateAPIKey: %v", err)
+	}
+	return m
+}
+
+func TestUser_ExposesAllCustomAttributes(t *testing.T) {
+	cognitoUser := cognitoTestUser("alice@example.com", "admin")
+	cognitoUser.Attributes = append(cognitoUser.Attributes,
+		types.AttributeType{Name: aws.String("custom:costCenter"), Value: aws.String("cc-42")},
+		types.AttributeType{Name: aws.String("custom:apiKeyHash"), Value: aws.String("secret")},
+	)
+
+	user, err := cognitoUserToUser(cognitoUser)
+	if err != nil {
+		t.Fatalf("cognitoUserToUser: %v", err)
+	}
+	want := map[string]string{"role": "admin", "costCenter": "cc-42"}
+	if !reflect.DeepEqual(user.CustomAttributes, want) {
+		t.Errorf("expected %v, got %v", want, user.CustomAttributes)
+	}
+}
+
+func TestGetCustomAttributes(t *testing.T) {
+	m := newCustomAttributesTestManager(t)
+
+	attrs, err := m.GetCustomAttributes(context.Background(), "alice@example.com")
+	if err != nil {
+		t.Fatalf("GetCustomAttributes: %v", err)
+	}
+	want := map[string]string{"role": "user", "tenantId": "acme", "department": "sales"}
+	if !reflect.DeepEqual(attrs, want) {
+		t.Errorf("expected %v without API key attributes, got %v", want, attrs)
+	}
+
+	if _, err := m.GetCustomAttributes(context.Background(), "nobody@example.com"); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("expected ErrUserNotFound, got %v", err)
+	}
+}
+
+func TestUpdateCustomAttributes(t *testing.T) {
+	m := newCustomAttributesTestManager(t)
+	ctx := context.Background()
+
+	user, err := m.UpdateCustomAttributes(ctx, "alice@example.com", map[string]string{
+		"department":        "engineering",
+		"custom:costCenter": "cc-42",
+		"costCenter":        "cc-42", // Same value under both spellings is fine
+	})
+	if err != nil {
+		t.Fatalf("UpdateCustomAttributes: %v", err)
+	}
+	if user.CustomAttributes["department"] != "engineering" || user.CustomAttributes["costCenter"] != "cc-42" || user.TenantID != "acme" {
+		t.Errorf("unexpected attributes after update: %v", user.CustomAttributes)
+	}
+
+	rejected := []map[string]string{
+		{"costCenter": "a", "custom:costCenter": "b"},
+		{"role": "admin"},
+		{"custom:userRole": "admin"},
+		{"apiKey": "usr_stolen"},
+		{"custom:apiKeyHash": "[]"},
+	}
+	for _, attrs := range rejected {
+		if _, err := m.UpdateCustomAttributes(ctx, "alice@example.com", attrs); !errors.Is(err, ErrInvalidInput) {
+			t.Errorf("%v: expected ErrInvalidInput, got %v", attrs, err)
+		}
+	}
+
+	if _, err := m.UpdateCustomAttributes(ctx, "nobody@example.com", map[string]string{"a": "b"}); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("expected ErrUserNotFound, got %v", err)
+	}
+}
+
+func TestUpdateCustomAttributes_CustomRoleAttribute(t *testing.T) {
+	m := newTestManager(t, &OAuthConfig{RoleAttributeName: "custom:appRole"}, NewMemoryUserStore(), []CreateUserRequest{{Email: "alice@example.com"}})
+	ctx := context.Background()
+
+	if _, err := m.UpdateCustomAttributes(ctx, "alice@example.com", map[string]string{"appRole": "admin"}); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("expected the configured role attribute to be refused, got %v", err)
+	}
+	if _, err := m.UpdateCustomAttributes(ctx, "alice@example.com", map[string]string{"role": "admin"}); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("expected the legacy role attribute to be refused, got %v", err)
+	}
+}
+
+func TestDeleteCustomAttributes(t *testing.T) {
+	m := newCustomAttributesTestManager(t)
+	ctx := context.Background()
+
+	user, err := m.DeleteCustomAttributes(ctx, "alice@example.com", "department", "custom:missing")
+	if err != nil {
+		t.Fatalf("DeleteCustomAttributes: %v", err)
+	}
+	if _, ok := user.CustomAttributes["department"]; ok || user.TenantID != "acme" {
+		t.Errorf("expected only department to be cleared, got %v", user.CustomAttributes)
+	}
+
+	for _, names := range [][]string{nil, {""}, {"role"}, {"custom:apiKey"}} {
+		if _, err := m.DeleteCustomAttributes(ctx, "alice@example.com", names...); !errors.Is(err, ErrInvalidInput) {
+			t.Errorf("%q: expected ErrInvalidInput, got %v", names, err)
+		}
+	}
+	if key, _ := m.GetAPIKey(ctx, "alice@example.com"); key == "" {
+		t.Error("expected the API key to survive")
+	}
+}
+
+func TestDeleteCustomAttributes_Cognito(t *testing.T) {
+	mockClient := setupMockUserMgmt(t)
+
+	if _, err := DeleteCustomAttributes(context.Background(), "test@example.com", "department"); err != nil {
+		t.Fatalf("DeleteCustomAttributes: %v", err)
+	}
+	if mockClient.updateAttrCalled {
+		t.Error("expected no AdminUpdateUserAttributes call")
+	}
+	if mockClient.deleteAttrInput == nil || !reflect.DeepEqual(mockClient.deleteAttrInput.UserAttributeNames, []string{"custom:department"}) {
+		t.Errorf("expected AdminDeleteUserAttributes for custom:department, got %+v", mockClient.deleteAttrInput)
+	}
+}
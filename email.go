@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/ses"
@@ -31,6 +32,29 @@ func ResetSESClientFactory() {
 	sesClientFactory = defaultSESClientFactory
 }
 
+// sesClient returns the injected SES client or builds one for SESRegion,
+// falling back to the user pool region.
+func (c *awsClients) sesClient(ctx context.Context) (SESClient, error) {
+	if c.ses != nil {
+		return c.ses, nil
+	}
+
+	region := c.config.SESRegion
+	if region == "" {
+		region = c.config.Region
+	}
+
+	cfg, err := c.loadAWSConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+	if region != c.config.Region {
+		cfg.Region = region
+	}
+
+	return sesClientFactory(ctx, cfg), nil
+}
+
 // InvitationEmailRequest contains the data needed to send an invitation email.
 type InvitationEmailRequest struct {
 	Email        string
@@ -42,8 +66,8 @@ type InvitationEmailRequest struct {
 }
 
 // SendInvitationEmail sends an email with login credentials to a newly invited user.
-func SendInvitationEmail(ctx context.Context, req InvitationEmailRequest) error {
-	oauthConfig := GetOAuthConfig()
+func (m *Manager) SendInvitationEmail(ctx context.Context, req InvitationEmailRequest) error {
+	oauthConfig := m.Config()
 	if oauthConfig == nil {
 		return fmt.Errorf("OAuth config not set")
 	}
@@ -52,31 +76,65 @@ func SendInvitationEmail(ctx context.Context, req InvitationEmailRequest) error
 		return fmt.Errorf("FromEmail not configured - cannot send invitation email")
 	}
 
-	region := oauthConfig.SESRegion
-	if region == "" {
-		region = oauthConfig.Region
+	if req.AppName == "" {
+		req.AppName = oauthConfig.AppName
 	}
-
-	cfg, err := loadAWSConfig(ctx, oauthConfig)
-	if err != nil {
-		return fmt.Errorf("failed to load AWS config: %w", err)
+	subject := fmt.Sprintf("Your %s Account Credentials", req.AppName)
+	if err := m.sendEmail(ctx, req.Email, subject, generateInvitationHTML(req), generateInvitationText(req)); err != nil {
+		return err
 	}
-	if region != oauthConfig.Region {
-		cfg.Region = region
+
+	log.Printf("[go-user-management] Invitation email sent to %s", req.Email)
+	return nil
+}
+
+// PasswordResetEmailRequest contains the data needed to send a password
+// reset code.
+type PasswordResetEmailRequest struct {
+	Email     string
+	Code      string
+	ExpiresIn time.Duration
+	ResetURL  string // Populated from OAuthConfig.PasswordResetURL; optional
+	AppName   string // Populated from OAuthConfig
+}
+
+// SendPasswordResetEmail sends a password reset code through SES, from
+// OAuthConfig.FromEmail, in place of Cognito's default message.
+func (m *Manager) SendPasswordResetEmail(ctx context.Context, req PasswordResetEmailRequest) error {
+	oauthConfig := m.Config()
+	if oauthConfig == nil {
+		return fmt.Errorf("OAuth config not set")
 	}
 
-	client := sesClientFactory(ctx, cfg)
+	if oauthConfig.FromEmail == "" {
+		return fmt.Errorf("FromEmail not configured - cannot send password reset email")
+	}
 
 	if req.AppName == "" {
 		req.AppName = oauthConfig.AppName
 	}
-	subject := fmt.Sprintf("Your %s Account Credentials", req.AppName)
-	htmlBody := generateInvitationHTML(req)
-	textBody := generateInvitationText(req)
+	if req.ResetURL == "" {
+		req.ResetURL = oauthConfig.PasswordResetURL
+	}
+	subject := fmt.Sprintf("Your %s password reset code", req.AppName)
+	if err := m.sendEmail(ctx, req.Email, subject, generatePasswordResetHTML(req), generatePasswordResetText(req)); err != nil {
+		return err
+	}
+
+	log.Printf("[go-user-management] Password reset email sent to %s", req.Email)
+	return nil
+}
+
+// sendEmail sends one HTML and plain text message from OAuthConfig.FromEmail.
+func (m *Manager) sendEmail(ctx context.Context, to, subject, htmlBody, textBody string) error {
+	client, err := m.clients().sesClient(ctx)
+	if err != nil {
+		return err
+	}
 
 	input := &ses.SendEmailInput{
 		Destination: &types.Destination{
-			ToAddresses: []string{req.Email},
+			ToAddresses: []string{to},
 		},
 		Message: &types.Message{
 			Subject: &types.Content{
@@ -94,15 +152,13 @@ func SendInvitationEmail(ctx context.Context, req InvitationEmailRequest) error
 				},
 			},
 		},
-		Source: aws.String(oauthConfig.FromEmail),
+		Source: aws.String(m.Config().FromEmail),
 	}
 
 	_, err = client.SendEmail(ctx, input)
 	if err != nil {
-		log.Printf("[go-user-management] Failed to send invitation email to %s: %v", req.Email, err)
+		log.Printf("[go-user-management] Failed to send email to %s: %v", to, err)
 		return fmt.Errorf("failed to send email: %w", err)
 	}
-
-	log.Printf("[go-user-management] Invitation email sent to %s", req.Email)
 	return nil
 }
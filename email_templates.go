@@ -3,6 +3,7 @@ package user
 import (
 	"fmt"
 	"html"
+	"time"
 )
 
 // generateInvitationHTML generates the HTML email body for invitation emails.
@@ -123,3 +124,103 @@ If you did not expect this email, please contact your administrator.
 		loginURL,
 	)
 }
+
+// generatePasswordResetHTML generates the HTML email body for password reset emails.
+func generatePasswordResetHTML(req PasswordResetEmailRequest) string {
+	button := ""
+	if req.ResetURL != "" {
+		button = fmt.Sprintf(`
+							<table role="presentation" style="width: 100%%; border-collapse: collapse; margin: 30px 0;">
+								<tr>
+									<td align="center">
+										<a href="%s" style="display: inline-block; padding: 16px 40px; background-color: #3B82F6; color: #ffffff; text-decoration: none; font-weight: bold; border-radius: 6px; font-size: 16px;">
+											Reset Password
+										</a>
+									</td>
+								</tr>
+							</table>`, html.EscapeString(req.ResetURL))
+	}
+
+	return fmt.Sprintf(`<!DOCTYPE html>
+<html lang="en">
+<head>
+	<meta charset="UTF-8">
+	<meta name="viewport" content="width=device-width, initial-scale=1.0">
+	<title>Password Reset</title>
+</head>
+<body style="margin: 0; padding: 0; font-family: 'Segoe UI', Tahoma, Geneva, Verdana, sans-serif; background-color: #f4f4f4;">
+	<table role="presentation" style="width: 100%%; border-collapse: collapse;">
+		<tr>
+			<td align="center" style="padding: 40px 0;">
+				<table role="presentation" style="width: 600px; border-collapse: collapse; background-color: #ffffff; border-radius: 8px; box-shadow: 0 2px 4px rgba(0,0,0,0.1);">
+					<tr>
+						<td style="padding: 40px 40px 20px; text-align: center; background-color: #3B82F6; border-radius: 8px 8px 0 0;">
+							<h1 style="margin: 0; color: #ffffff; font-size: 24px;">Reset your %s password</h1>
+						</td>
+					</tr>
+					<tr>
+						<td style="padding: 30px 40px;">
+							<p style="margin: 0 0 20px; font-size: 16px; line-height: 1.5; color: #333333;">
+								Use the code below to choose a new password. It expires in %s.
+							</p>
+							<p style="margin: 20px 0; padding: 20px; text-align: center; font-size: 32px; font-weight: bold; letter-spacing: 8px; background-color: #f8f9fa; border-radius: 6px; color: #333333;">
+								%s
+							</p>%s
+						</td>
+					</tr>
+					<tr>
+						<td style="padding: 20px 40px; text-align: center; background-color: #f8f9fa; border-radius: 0 0 8px 8px;">
+							<p style="margin: 0; font-size: 12px; color: #666666;">
+								If you did not ask to reset your password, you can ignore this email.
+							</p>
+						</td>
+					</tr>
+				</table>
+			</td>
+		</tr>
+	</table>
+</body>
+</html>`,
+		html.EscapeString(req.AppName),
+		html.EscapeString(formatExpiry(req.ExpiresIn)),
+		html.EscapeString(req.Code),
+		button,
+	)
+}
+
+// generatePasswordResetText generates the plain text email body for password reset emails.
+func generatePasswordResetText(req PasswordResetEmailRequest) string {
+	link := ""
+	if req.ResetURL != "" {
+		link = fmt.Sprintf("\nReset your password at: %s\n", req.ResetURL)
+	}
+
+	return fmt.Sprintf(`Reset your %s password
+
+Use this code to choose a new password: %s
+
+The code expires in %s.
+%s
+If you did not ask to reset your password, you can ignore this email.
+`,
+		req.AppName,
+		req.Code,
+		formatExpiry(req.ExpiresIn),
+		link,
+	)
+}
+
+// formatExpiry renders a code lifetime such as "15 minutes" or "1 hour".
+func formatExpiry(d time.Duration) string {
+	if d >= time.Hour && d%time.Hour == 0 {
+		if d == time.Hour {
+			return "1 hour"
+		}
+		return fmt.Sprintf("%d hours", d/time.Hour)
+	}
+	minutes := int((d + time.Minute - 1) / time.Minute)
+	if minutes == 1 {
+		return "1 minute"
+	}
+	return fmt.Sprintf("%d minutes", minutes)
+}
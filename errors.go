@@ -3,9 +3,16 @@ package user
 import "errors"
 
 var (
-	ErrUserNotFound      = errors.New("user not found")
-	ErrUserAlreadyExists = errors.New("user already exists")
-	ErrInvalidInput      = errors.New("invalid input")
-	ErrInvalidAPIKey = errors.New("invalid API key")
+	ErrUserNotFound          = errors.New("user not found")
+	ErrUserAlreadyExists     = errors.New("user already exists")
+	ErrInvalidInput          = errors.New("invalid input")
+	ErrInvalidAPIKey         = errors.New("invalid API key")
+	ErrAPIKeyNotFound        = errors.New("API key not found")
+	ErrSessionNotFound       = errors.New("session not found")
+	ErrGroupNotFound         = errors.New("group not found")
+	ErrGroupAlreadyExists    = errors.New("group already exists")
+	ErrTokenRevoked          = errors.New("token revoked")
+	ErrInvalidCredentials    = errors.New("invalid credentials")
+	ErrPasswordResetRequired = errors.New("password reset required")
+	ErrInvalidResetCode      = errors.New("invalid or expired reset code")
 )
-
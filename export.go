@@ -0,0 +1,162 @@
+package user
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ExportedUser is one user as written by ExportUsers and read back by
+// BulkImportUsers. CustomAttributes holds every custom attribute except the
+// role attribute, keyed without the "custom:" prefix (tenantId,
+// serviceProviderId, ...).
+type ExportedUser struct {
+	CreateUserRequest
+	Username   string `json:"username,omitempty"`   // Informational; imports use the email
+	UserStatus string `json:"userStatus,omitempty"` // Informational; imported users start in FORCE_CHANGE_PASSWORD
+	Enabled    *bool  `json:"enabled,omitempty"`    // Imported users are disabled when false; nil means enabled
+}
+
+// ExportOptions configures ExportUsers.
+type ExportOptions struct {
+	Format ImportFormat
+	// RedactAPIKeys leaves out API key attributes. Unredacted exports carry
+	// the key prefix and hashes (or legacy plaintext keys), so imported users
+	// keep working keys.
+	RedactAPIKeys bool
+	Filter        UserFilter    // Same semantics as ListUsersPage
+	PageSize      int           // See AllUsersOptions
+	PageInterval  time.Duration // See AllUsersOptions
+}
+
+// exportCSVColumns are the fixed leading columns of a CSV export; custom
+// attribute columns follow in name order.
+var exportCSVColumns = []string{"username", "email", "givenName", "familyName", "name", "picture", "role", "userStatus", "enabled"}
+
+// ExportUsers writes every user matching opts.Filter to w as CSV or JSON
+// lines and returns how many were written. The output is accepted by
+// BulkImportUsers, so users can be copied between pools.
+//
+// JSON lines are streamed page by page. CSV exports are buffered until the
+// last page, because the header lists every custom attribute in use.
+func (m *Manager) ExportUsers(ctx context.Context, w io.Writer, opts ExportOptions) (int, error) {
+	if opts.Format != ImportFormatCSV && opts.Format != ImportFormatJSONLines {
+		return 0, fmt.Errorf("unsupported export format %q: %w", opts.Format, ErrInvalidInput)
+	}
+
+	roleAttr := m.roleAttributeName()
+	iterOpts := AllUsersOptions{PageSize: opts.PageSize, PageInterval: opts.PageInterval, Filter: opts.Filter}
+
+	var users []ExportedUser
+	encoder := json.NewEncoder(w)
+	count := 0
+	var writeErr error
+	err := m.walkUsers(ctx, iterOpts, func(record *UserRecord, user *User) bool {
+		exported := exportUser(record, user, roleAttr, opts.RedactAPIKeys)
+		if opts.Format == ImportFormatCSV {
+			users = append(users, exported)
+			return true
+		}
+		if err := encoder.Encode(exported); err != nil {
+			writeErr = fmt.Errorf("failed to write user %s: %w", user.Email, err)
+			return false
+		}
+		count++
+		return true
+	})
+	if writeErr != nil {
+		return count, writeErr
+	}
+	if err != nil {
+		return count, err
+	}
+
+	if opts.Format == ImportFormatCSV {
+		if err := writeExportCSV(w, users); err != nil {
+			return 0, err
+		}
+		count = len(users)
+	}
+
+	log.Printf("✅ [ExportUsers] Exported %d users as %s (API keys redacted: %v)", count, opts.Format, opts.RedactAPIKeys)
+	return count, nil
+}
+
+// exportUser converts a store record to its export form.
+func exportUser(record *UserRecord, user *User, roleAttr string, redactAPIKeys bool) ExportedUser {
+	var custom map[string]string
+	for name, value := range record.Attributes {
+		if !strings.HasPrefix(name, "custom:") || name == roleAttr || value == "" {
+			continue
+		}
+		if redactAPIKeys && isAPIKeyAttribute(name) {
+			continue
+		}
+		if custom == nil {
+			custom = make(map[string]string)
+		}
+		custom[strings.TrimPrefix(name, "custom:")] = value
+	}
+
+	enabled := user.Enabled
+	return ExportedUser{
+		CreateUserRequest: CreateUserRequest{
+			Email:            user.Email,
+			GivenName:        user.GivenName,
+			FamilyName:       user.FamilyName,
+			Name:             user.Name,
+			Picture:          user.Picture,
+			Role:             user.Role,
+			CustomAttributes: custom,
+		},
+		Username:   user.Username,
+		UserStatus: user.UserStatus,
+		Enabled:    &enabled,
+	}
+}
+
+func writeExportCSV(w io.Writer, users []ExportedUser) error {
+	names := make(map[string]bool)
+	for _, u := range users {
+		for name := range u.CustomAttributes {
+			names[name] = true
+		}
+	}
+	customColumns := make([]string, 0, len(names))
+	for name := range names {
+		customColumns = append(customColumns, name)
+	}
+	sort.Strings(customColumns)
+
+	writer := csv.NewWriter(w)
+	header := append([]string{}, exportCSVColumns...)
+	for _, name := range customColumns {
+		header = append(header, "custom:"+name)
+	}
+	if err := writer.Write(header); err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+
+	for _, u := range users {
+		row := []string{u.Username, u.Email, u.GivenName, u.FamilyName, u.Name, u.Picture, u.Role, u.UserStatus, strconv.FormatBool(u.Enabled == nil || *u.Enabled)}
+		for _, name := range customColumns {
+			row = append(row, u.CustomAttributes[name])
+		}
+		if err := writer.Write(row); err != nil {
+			return fmt.Errorf("failed to write user %s: %w", u.Email, err)
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("failed to write CSV: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package user
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// newExportSourceManager returns a manager with a mix of users: custom
+// attributes, an API key and a disabled account.
+func newExportSourceManager(t *testing.T) (*Manager, string) {
+	t.Helper()
+	ctx := context.Background()
+	m := NewManager(&OAuthConfig{}, WithUserStore(NewMemoryUserStore()))
+
+	for _, req := range []CreateUserRequest{
+		{Email: "alice@example.com", GivenName: "Alice", FamilyName: "Smith", Role: "admin",
+			CustomAttributes: map[string]string{"tenantId": "acme", "serviceProviderId": "sp1", "department": "Sales, EMEA"}},
+		{Email: "bob@example.com", Name: "Bob", CustomAttributes: map[string]string{"tenantId": "globex"}},
+	} {
+		if _, err := m.CreateUser(ctx, req); err != nil {
+			t.Fatalf("CreateUser: %v", err)
+		}
+	}
+	if err := m.DisableUser(ctx, "bob@example.com"); err != nil {
+		t.Fatalf("DisableUser: %v", err)
+	}
+	key, err := m.GenerateAPIKey(ctx, "alice@example.com")
+	if err != nil {
+		t.Fatalf("GenerateAPIKey: %v", err)
+	}
+	return m, key
+}
+
+func TestExportUsers_RoundTrip(t *testing.T) {
+	for _, format := range []ImportFormat{ImportFormatCSV, ImportFormatJSONLines} {
+		t.Run(string(format), func(t *testing.T) {
+			ctx := context.Background()
+			src, key := newExportSourceManager(t)
+
+			var buf bytes.Buffer
+			n, err := src.ExportUsers(ctx, &buf, ExportOptions{Format: format})
+			if err != nil {
+				t.Fatalf("ExportUsers: %v", err)
+			}
+			if n != 2 {
+				t.Fatalf("expected 2 users exported, got %d", n)
+			}
+
+			dst := NewManager(&OAuthConfig{}, WithUserStore(NewMemoryUserStore()))
+			report, err := dst.BulkImportUsers(ctx, &buf, BulkImportOptions{Format: format})
+			if err != nil {
+				t.Fatalf("BulkImportUsers: %v", err)
+			}
+			if report.Created != 2 || report.Failed != 0 {
+				t.Fatalf("expected both users imported, got %+v", report.Rows)
+			}
+
+			for _, email := range []string{"alice@example.com", "bob@example.com"} {
+				want, _ := src.GetUser(ctx, email)
+				got, err := dst.GetUser(ctx, email)
+				if err != nil {
+					t.Fatalf("GetUser(%s): %v", email, err)
+				}
+				want.UserStatus = got.UserStatus // Imported users always start over
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("round trip changed %s:\n want %+v\n got  %+v", email, want, got)
+				}
+			}
+
+			wantAttrs, _ := src.store.GetUser(ctx, "alice@example.com")
+			gotAttrs, _ := dst.store.GetUser(ctx, "alice@example.com")
+			if gotAttrs.Attributes["custom:department"] != wantAttrs.Attributes["custom:department"] {
+				t.Errorf("expected custom:department to round-trip, got %q", gotAttrs.Attributes["custom:department"])
+			}
+
+			claims, err := dst.FindUserByToken(ctx, key)
+			if err != nil || claims.Email != "alice@example.com" {
+				t.Errorf("expected alice's API key to work in the target pool, got %+v (%v)", claims, err)
+			}
+		})
+	}
+}
+
+func TestExportUsers_RedactsAPIKeys(t *testing.T) {
+	ctx := context.Background()
+	src, key := newExportSourceManager(t)
+
+	var buf bytes.Buffer
+	if _, err := src.ExportUsers(ctx, &buf, ExportOptions{Format: ImportFormatJSONLines, RedactAPIKeys: true}); err != nil {
+		t.Fatalf("ExportUsers: %v", err)
+	}
+	if strings.Contains(strings.ToLower(buf.String()), "apikey") {
+		t.Errorf("expected no API key attributes in a redacted export:\n%s", buf.String())
+	}
+
+	var first ExportedUser
+	if err := json.NewDecoder(strings.NewReader(buf.String())).Decode(&first); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if first.Email != "alice@example.com" || first.CustomAttributes["tenantId"] != "acme" || first.Enabled == nil || !*first.Enabled {
+		t.Errorf("unexpected exported user: %+v", first)
+	}
+
+	dst := NewManager(&OAuthConfig{}, WithUserStore(NewMemoryUserStore()))
+	if _, err := dst.BulkImportUsers(ctx, &buf, BulkImportOptions{Format: ImportFormatJSONLines}); err != nil {
+		t.Fatalf("BulkImportUsers: %v", err)
+	}
+	if _, err := dst.FindUserByToken(ctx, key); err == nil {
+		t.Error("expected a redacted API key not to carry over")
+	}
+}
+
+func TestExportUsers_CSVColumns(t *testing.T) {
+	src, _ := newExportSourceManager(t)
+
+	var buf bytes.Buffer
+	if _, err := src.ExportUsers(context.Background(), &buf, ExportOptions{Format: ImportFormatCSV, RedactAPIKeys: true, Filter: UserFilter{TenantID: "globex"}}); err != nil {
+		t.Fatalf("ExportUsers: %v", err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("read CSV: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected a header and one filtered user, got %v", records)
+	}
+
+	header := strings.Join(records[0], ",")
+	if header != "username,email,givenName,familyName,name,picture,role,userStatus,enabled,custom:tenantId" {
+		t.Errorf("unexpected header %q", header)
+	}
+	if row := records[1]; row[1] != "bob@example.com" || row[8] != "false" || row[9] != "globex" {
+		t.Errorf("unexpected row %v", row)
+	}
+}
+
+func TestExportUsers_RejectsUnknownFormat(t *testing.T) {
+	m := NewManager(&OAuthConfig{}, WithUserStore(NewMemoryUserStore()))
+	if _, err := m.ExportUsers(context.Background(), &bytes.Buffer{}, ExportOptions{Format: "xml"}); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("expected ErrInvalidInput, got %v", err)
+	}
+}
@@ -0,0 +1,273 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+)
+
+// Group is a user pool group. Cognito puts a user's group names in the
+// cognito:groups claim, their role ARNs in cognito:roles and the role of the
+// highest-precedence group in cognito:preferred_role, which drives STS role
+// selection.
+type Group struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Precedence  *int32 `json:"precedence,omitempty"` // Lower values win; nil ranks after every set value
+	RoleARN     string `json:"roleArn,omitempty"`    // IAM role assumed by members
+}
+
+// GroupStore is an optional UserStore extension for backends that support
+// groups. Both the Cognito store and MemoryUserStore implement it.
+type GroupStore interface {
+	// CreateGroup creates a group, or returns an error wrapping ErrGroupAlreadyExists.
+	CreateGroup(ctx context.Context, group Group) (*Group, error)
+	// DeleteGroup deletes a group, or returns an error wrapping ErrGroupNotFound.
+	DeleteGroup(ctx context.Context, name string) error
+	// ListGroups returns up to limit groups starting at pageToken, plus the
+	// token for the next page ("" when there are no more groups).
+	ListGroups(ctx context.Context, limit int, pageToken string) ([]*Group, string, error)
+	AddUserToGroup(ctx context.Context, username, group string) error
+	RemoveUserFromGroup(ctx context.Context, username, group string) error
+	// ListUsersInGroup pages through a group's members like ListGroups.
+	ListUsersInGroup(ctx context.Context, group string, limit int, pageToken string) ([]*UserRecord, string, error)
+	// ListGroupsForUser returns every group the user belongs to.
+	ListGroupsForUser(ctx context.Context, username string) ([]*Group, error)
+}
+
+// groupStore returns the user store's GroupStore implementation.
+func (m *Manager) groupStore() (GroupStore, error) {
+	store, err := m.userStore()
+	if err != nil {
+		return nil, err
+	}
+	groups, ok := store.(GroupStore)
+	if !ok {
+		return nil, fmt.Errorf("user store %T does not support groups", store)
+	}
+	return groups, nil
+}
+
+// CreateGroup creates a user pool group.
+func (m *Manager) CreateGroup(ctx context.Context, group Group) (*Group, error) {
+	if group.Name == "" {
+		return nil, fmt.Errorf("group name cannot be empty: %w", ErrInvalidInput)
+	}
+	if group.Precedence != nil && *group.Precedence < 0 {
+		return nil, fmt.Errorf("group precedence cannot be negative: %w", ErrInvalidInput)
+	}
+
+	store, err := m.groupStore()
+	if err != nil {
+		return nil, err
+	}
+	return store.CreateGroup(ctx, group)
+}
+
+// DeleteGroup deletes a user pool group. Members stay in the pool.
+func (m *Manager) DeleteGroup(ctx context.Context, name string) error {
+	if name == "" {
+		return fmt.Errorf("group name cannot be empty: %w", ErrInvalidInput)
+	}
+
+	store, err := m.groupStore()
+	if err != nil {
+		return err
+	}
+	return store.DeleteGroup(ctx, name)
+}
+
+// ListGroups returns every group in the pool, sorted by precedence.
+func (m *Manager) ListGroups(ctx context.Context) ([]*Group, error) {
+	store, err := m.groupStore()
+	if err != nil {
+		return nil, err
+	}
+
+	var groups []*Group
+	pageToken := ""
+	for {
+		page, nextToken, err := store.ListGroups(ctx, 60, pageToken)
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, page...)
+		if nextToken == "" {
+			break
+		}
+		pageToken = nextToken
+	}
+
+	sortGroups(groups)
+	return groups, nil
+}
+
+// AddUserToGroup adds a user to a group. New tokens issued to the user carry
+// the group; existing tokens do not.
+func (m *Manager) AddUserToGroup(ctx context.Context, email, group string) error {
+	if email == "" || group == "" {
+		return fmt.Errorf("email and group cannot be empty: %w", ErrInvalidInput)
+	}
+
+	store, err := m.groupStore()
+	if err != nil {
+		return err
+	}
+	return store.AddUserToGroup(ctx, email, group)
+}
+
+// RemoveUserFromGroup removes a user from a group.
+func (m *Manager) RemoveUserFromGroup(ctx context.Context, email, group string) error {
+	if email == "" || group == "" {
+		return fmt.Errorf("email and group cannot be empty: %w", ErrInvalidInput)
+	}
+
+	store, err := m.groupStore()
+	if err != nil {
+		return err
+	}
+	return store.RemoveUserFromGroup(ctx, email, group)
+}
+
+// ListUsersInGroup returns a page of a group's members, with the same limit
+// and offset semantics as ListUsers.
+func (m *Manager) ListUsersInGroup(ctx context.Context, group string, limit, offset int) ([]*User, error) {
+	if group == "" {
+		return nil, fmt.Errorf("group name cannot be empty: %w", ErrInvalidInput)
+	}
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > 60 {
+		limit = 60
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	store, err := m.groupStore()
+	if err != nil {
+		return nil, err
+	}
+
+	var records []*UserRecord
+	pageToken := ""
+	for {
+		page, nextToken, err := store.ListUsersInGroup(ctx, group, 60, pageToken)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, page...)
+		if nextToken == "" || len(records) >= limit+offset {
+			break
+		}
+		pageToken = nextToken
+	}
+
+	if offset >= len(records) {
+		return []*User{}, nil
+	}
+	records = records[offset:]
+	if len(records) > limit {
+		records = records[:limit]
+	}
+	return recordsToUsers(records, m.roleAttributeName()), nil
+}
+
+// ListGroupsForUser returns the groups a user belongs to, sorted by precedence.
+func (m *Manager) ListGroupsForUser(ctx context.Context, email string) ([]*Group, error) {
+	if email == "" {
+		return nil, fmt.Errorf("email cannot be empty: %w", ErrInvalidInput)
+	}
+
+	store, err := m.groupStore()
+	if err != nil {
+		return nil, err
+	}
+	groups, err := store.ListGroupsForUser(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	sortGroups(groups)
+	return groups, nil
+}
+
+// addGroupMembership fills the group fields of user when
+// OAuthConfig.LoadUserGroups is set and the store supports groups. A failure
+// is logged rather than returned, so GetUser keeps working for pools whose
+// credentials lack group permissions.
+func (m *Manager) addGroupMembership(ctx context.Context, user *User) {
+	if config := m.Config(); config == nil || !config.LoadUserGroups {
+		return
+	}
+	store, err := m.userStore()
+	if err != nil {
+		return
+	}
+	groupStore, ok := store.(GroupStore)
+	if !ok {
+		return
+	}
+
+	groups, err := groupStore.ListGroupsForUser(ctx, user.Username)
+	if err != nil {
+		log.Printf("⚠️ [GetUser] Could not list groups for %s: %v", user.Email, err)
+		return
+	}
+	sortGroups(groups)
+	user.Groups, user.GroupRoleARNs, user.PreferredRoleARN = groupMembership(groups)
+}
+
+// groupMembership returns the group names, role ARNs and preferred role of
+// groups sorted by precedence, following Cognito's token rules: the preferred
+// role is the role of the group with the lowest precedence, and is left
+// unset when several groups tie for it.
+func groupMembership(groups []*Group) (names, roleARNs []string, preferredRole string) {
+	var best *Group
+	tied := false
+	for _, g := range groups {
+		names = append(names, g.Name)
+		if g.RoleARN == "" {
+			continue
+		}
+		roleARNs = append(roleARNs, g.RoleARN)
+
+		switch {
+		case best == nil:
+			best = g
+		case comparePrecedence(g.Precedence, best.Precedence) == 0:
+			tied = true
+		}
+	}
+	if best != nil && !tied {
+		preferredRole = best.RoleARN
+	}
+	return names, roleARNs, preferredRole
+}
+
+// sortGroups orders groups by precedence (unset last), then name.
+func sortGroups(groups []*Group) {
+	sort.SliceStable(groups, func(i, j int) bool {
+		if c := comparePrecedence(groups[i].Precedence, groups[j].Precedence); c != 0 {
+			return c < 0
+		}
+		return groups[i].Name < groups[j].Name
+	})
+}
+
+func comparePrecedence(a, b *int32) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return 1
+	case b == nil:
+		return -1
+	case *a < *b:
+		return -1
+	case *a > *b:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,235 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider/types"
+)
+
+// mockGroupsCognitoClient records group requests and serves a fixed
+// membership for AdminListGroupsForUser, in two pages.
+type mockGroupsCognitoClient struct {
+	mockUserMgmtCognitoClient
+	createInput *cognitoidentityprovider.CreateGroupInput
+	addInput    *cognitoidentityprovider.AdminAddUserToGroupInput
+	listCalls   int
+}
+
+func (m *mockGroupsCognitoClient) CreateGroup(_ context.Context, params *cognitoidentityprovider.CreateGroupInput, _ ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.CreateGroupOutput, error) {
+	if aws.ToString(params.GroupName) == "taken" {
+		return nil, errors.New("GroupExistsException: A group with the name taken already exists")
+	}
+	m.createInput = params
+	return &cognitoidentityprovider.CreateGroupOutput{Group: &types.GroupType{
+		GroupName:  params.GroupName,
+		Precedence: params.Precedence,
+		RoleArn:    params.RoleArn,
+	}}, nil
+}
+
+func (m *mockGroupsCognitoClient) AdminAddUserToGroup(_ context.Context, params *cognitoidentityprovider.AdminAddUserToGroupInput, _ ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.AdminAddUserToGroupOutput, error) {
+	if aws.ToString(params.GroupName) == "missing" {
+		return nil, errors.New("ResourceNotFoundException: Group not found.")
+	}
+	m.addInput = params
+	return &cognitoidentityprovider.AdminAddUserToGroupOutput{}, nil
+}
+
+func (m *mockGroupsCognitoClient) AdminListGroupsForUser(_ context.Context, params *cognitoidentityprovider.AdminListGroupsForUserInput, _ ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.AdminListGroupsForUserOutput, error) {
+	m.listCalls++
+	if params.NextToken == nil {
+		return &cognitoidentityprovider.AdminListGroupsForUserOutput{
+			Groups:    []types.GroupType{{GroupName: aws.String("readers"), RoleArn: aws.String("arn:aws:iam::123:role/Reader")}},
+			NextToken: aws.String("page-2"),
+		}, nil
+	}
+	return &cognitoidentityprovider.AdminListGroupsForUserOutput{
+		Groups: []types.GroupType{{GroupName: aws.String("admins"), Precedence: aws.Int32(1), RoleArn: aws.String("arn:aws:iam::123:role/Admin")}},
+	}, nil
+}
+
+func TestGroups_MemoryStoreLifecycle(t *testing.T) {
+	ctx := context.Background()
+	m := newTestManager(t, &OAuthConfig{LoadUserGroups: true}, NewMemoryUserStore(), []CreateUserRequest{{Email: "alice@example.com"}, {Email: "bob@example.com"}})
+
+	for _, g := range []Group{
+		{Name: "readers", RoleARN: "arn:aws:iam::123:role/Reader"},
+		{Name: "admins", Precedence: aws.Int32(1), RoleARN: "arn:aws:iam::123:role/Admin"},
+		{Name: "staff", Precedence: aws.Int32(5)},
+	} {
+		if _, err := m.CreateGroup(ctx, g); err != nil {
+			t.Fatalf("CreateGroup(%s): %v", g.Name, err)
+		}
+	}
+	if _, err := m.CreateGroup(ctx, Group{Name: "admins"}); !errors.Is(err, ErrGroupAlreadyExists) {
+		t.Errorf("expected ErrGroupAlreadyExists, got %v", err)
+	}
+
+	groups, err := m.ListGroups(ctx)
+	if err != nil {
+		t.Fatalf("ListGroups: %v", err)
+	}
+	if got := groupNames(groups); fmt.Sprint(got) != "[admins staff readers]" {
+		t.Errorf("expected groups by precedence, got %v", got)
+	}
+
+	for _, g := range []string{"readers", "admins", "staff"} {
+		if err := m.AddUserToGroup(ctx, "alice@example.com", g); err != nil {
+			t.Fatalf("AddUserToGroup(%s): %v", g, err)
+		}
+	}
+	if err := m.AddUserToGroup(ctx, "bob@example.com", "readers"); err != nil {
+		t.Fatalf("AddUserToGroup: %v", err)
+	}
+	if err := m.AddUserToGroup(ctx, "alice@example.com", "missing"); !errors.Is(err, ErrGroupNotFound) {
+		t.Errorf("expected ErrGroupNotFound, got %v", err)
+	}
+	if err := m.AddUserToGroup(ctx, "nobody@example.com", "readers"); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("expected ErrUserNotFound, got %v", err)
+	}
+
+	alice, err := m.GetUser(ctx, "alice@example.com")
+	if err != nil {
+		t.Fatalf("GetUser: %v", err)
+	}
+	if fmt.Sprint(alice.Groups) != "[admins staff readers]" ||
+		fmt.Sprint(alice.GroupRoleARNs) != "[arn:aws:iam::123:role/Admin arn:aws:iam::123:role/Reader]" ||
+		alice.PreferredRoleARN != "arn:aws:iam::123:role/Admin" {
+		t.Errorf("unexpected membership: %v %v %q", alice.Groups, alice.GroupRoleARNs, alice.PreferredRoleARN)
+	}
+
+	members, err := m.ListUsersInGroup(ctx, "readers", 1, 1)
+	if err != nil {
+		t.Fatalf("ListUsersInGroup: %v", err)
+	}
+	if len(members) != 1 || members[0].Email != "bob@example.com" {
+		t.Errorf("expected bob on the second page, got %+v", members)
+	}
+
+	if err := m.RemoveUserFromGroup(ctx, "alice@example.com", "admins"); err != nil {
+		t.Fatalf("RemoveUserFromGroup: %v", err)
+	}
+	if err := m.DeleteGroup(ctx, "staff"); err != nil {
+		t.Fatalf("DeleteGroup: %v", err)
+	}
+	if err := m.DeleteGroup(ctx, "staff"); !errors.Is(err, ErrGroupNotFound) {
+		t.Errorf("expected ErrGroupNotFound, got %v", err)
+	}
+	groups, err = m.ListGroupsForUser(ctx, "alice@example.com")
+	if err != nil || fmt.Sprint(groupNames(groups)) != "[readers]" {
+		t.Errorf("expected only readers left, got %v (%v)", groupNames(groups), err)
+	}
+
+	if err := m.DeleteUser(ctx, "bob@example.com"); err != nil {
+		t.Fatalf("DeleteUser: %v", err)
+	}
+	if members, _ := m.ListUsersInGroup(ctx, "readers", 10, 0); len(members) != 1 {
+		t.Errorf("expected deleted users to leave their groups, got %d members", len(members))
+	}
+}
+
+func TestGroupMembership_PreferredRole(t *testing.T) {
+	tests := []struct {
+		name   string
+		groups []*Group
+		want   string
+	}{
+		{"lowest precedence wins", []*Group{{Name: "a", Precedence: aws.Int32(1), RoleARN: "A"}, {Name: "b", Precedence: aws.Int32(2), RoleARN: "B"}}, "A"},
+		{"groups without roles are skipped", []*Group{{Name: "a", Precedence: aws.Int32(0)}, {Name: "b", Precedence: aws.Int32(2), RoleARN: "B"}}, "B"},
+		{"tie leaves it unset", []*Group{{Name: "a", Precedence: aws.Int32(1), RoleARN: "A"}, {Name: "b", Precedence: aws.Int32(1), RoleARN: "B"}}, ""},
+		{"set precedence beats unset", []*Group{{Name: "a", Precedence: aws.Int32(9), RoleARN: "A"}, {Name: "b", RoleARN: "B"}}, "A"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sortGroups(tt.groups)
+			if _, _, got := groupMembership(tt.groups); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestGroups_Cognito(t *testing.T) {
+	client := &mockGroupsCognitoClient{}
+	m := NewManager(&OAuthConfig{UserPoolID: "pool", Region: "us-east-1", LoadUserGroups: true}, WithCognitoClient(client))
+	ctx := context.Background()
+
+	group, err := m.CreateGroup(ctx, Group{Name: "admins", Precedence: aws.Int32(1), RoleARN: "arn:aws:iam::123:role/Admin"})
+	if err != nil {
+		t.Fatalf("CreateGroup: %v", err)
+	}
+	if aws.ToString(client.createInput.UserPoolId) != "pool" || aws.ToString(client.createInput.RoleArn) != "arn:aws:iam::123:role/Admin" {
+		t.Errorf("unexpected CreateGroup input: %+v", client.createInput)
+	}
+	if !reflect.DeepEqual(group, &Group{Name: "admins", Precedence: aws.Int32(1), RoleARN: "arn:aws:iam::123:role/Admin"}) {
+		t.Errorf("unexpected group: %+v", group)
+	}
+	if _, err := m.CreateGroup(ctx, Group{Name: "taken"}); !errors.Is(err, ErrGroupAlreadyExists) {
+		t.Errorf("expected ErrGroupAlreadyExists, got %v", err)
+	}
+
+	if err := m.AddUserToGroup(ctx, "test@example.com", "admins"); err != nil {
+		t.Fatalf("AddUserToGroup: %v", err)
+	}
+	if aws.ToString(client.addInput.Username) != "test@example.com" {
+		t.Errorf("unexpected AdminAddUserToGroup input: %+v", client.addInput)
+	}
+	if err := m.AddUserToGroup(ctx, "test@example.com", "missing"); !errors.Is(err, ErrGroupNotFound) {
+		t.Errorf("expected ErrGroupNotFound rather than a missing user, got %v", err)
+	}
+
+	user, err := m.GetUser(ctx, "test@example.com")
+	if err != nil {
+		t.Fatalf("GetUser: %v", err)
+	}
+	if client.listCalls != 2 {
+		t.Errorf("expected both group pages to be read, got %d calls", client.listCalls)
+	}
+	if fmt.Sprint(user.Groups) != "[admins readers]" || user.PreferredRoleARN != "arn:aws:iam::123:role/Admin" {
+		t.Errorf("unexpected membership: %v %q", user.Groups, user.PreferredRoleARN)
+	}
+}
+
+func TestGetUser_SkipsGroupsByDefault(t *testing.T) {
+	client := &mockGroupsCognitoClient{}
+	m := NewManager(&OAuthConfig{UserPoolID: "pool", Region: "us-east-1"}, WithCognitoClient(client))
+
+	user, err := m.GetUser(context.Background(), "test@example.com")
+	if err != nil {
+		t.Fatalf("GetUser: %v", err)
+	}
+	if client.listCalls != 0 || user.Groups != nil {
+		t.Errorf("expected no group lookup, got %d calls and groups %v", client.listCalls, user.Groups)
+	}
+}
+
+func TestGroups_UnsupportedStore(t *testing.T) {
+	m := NewManager(&OAuthConfig{}, WithUserStore(&pagingUserStore{MemoryUserStore: NewMemoryUserStore()}))
+	if _, err := m.ListGroups(context.Background()); err != nil {
+		t.Fatalf("expected the embedded MemoryUserStore to provide groups, got %v", err)
+	}
+
+	m = NewManager(&OAuthConfig{}, WithUserStore(userStoreOnly{NewMemoryUserStore()}))
+	if _, err := m.ListGroups(context.Background()); err == nil {
+		t.Error("expected an error for a store without group support")
+	}
+}
+
+// userStoreOnly hides every optional extension of the wrapped store.
+type userStoreOnly struct {
+	UserStore
+}
+
+func groupNames(groups []*Group) []string {
+	names := make([]string, 0, len(groups))
+	for _, g := range groups {
+		names = append(names, g.Name)
+	}
+	return names
+}
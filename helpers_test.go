@@ -0,0 +1,80 @@
+package user
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
+)
+
+// newTestManager returns a Manager for config with opts applied, backed by
+// store unless it is nil, and creates users in it.
+func newTestManager(t *testing.T, config *OAuthConfig, store UserStore, users []CreateUserRequest, opts ...ManagerOption) *Manager {
+	t.Helper()
+	if store != nil {
+		opts = append([]ManagerOption{WithUserStore(store)}, opts...)
+	}
+	m := NewManager(config, opts...)
+	for _, req := range users {
+		if _, err := m.CreateUser(context.Background(), req); err != nil {
+			t.Fatalf("CreateUser(%s): %v", req.Email, err)
+		}
+	}
+	return m
+}
+
+// cognitoClientStubs gives CognitoClient mocks empty implementations of the
+// operations their tests do not exercise.
+type cognitoClientStubs struct{}
+
+func (cognitoClientStubs) AdminDeleteUserAttributes(_ context.Context, _ *cognitoidentityprovider.AdminDeleteUserAttributesInput, _ ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.AdminDeleteUserAttributesOutput, error) {
+	return &cognitoidentityprovider.AdminDeleteUserAttributesOutput{}, nil
+}
+func (cognitoClientStubs) CreateGroup(_ context.Context, _ *cognitoidentityprovider.CreateGroupInput, _ ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.CreateGroupOutput, error) {
+	return &cognitoidentityprovider.CreateGroupOutput{}, nil
+}
+func (cognitoClientStubs) DeleteGroup(_ context.Context, _ *cognitoidentityprovider.DeleteGroupInput, _ ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.DeleteGroupOutput, error) {
+	return &cognitoidentityprovider.DeleteGroupOutput{}, nil
+}
+func (cognitoClientStubs) ListGroups(_ context.Context, _ *cognitoidentityprovider.ListGroupsInput, _ ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.ListGroupsOutput, error) {
+	return &cognitoidentityprovider.ListGroupsOutput{}, nil
+}
+func (cognitoClientStubs) AdminAddUserToGroup(_ context.Context, _ *cognitoidentityprovider.AdminAddUserToGroupInput, _ ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.AdminAddUserToGroupOutput, error) {
+	return &cognitoidentityprovider.AdminAddUserToGroupOutput{}, nil
+}
+func (cognitoClientStubs) AdminRemoveUserFromGroup(_ context.Context, _ *cognitoidentityprovider.AdminRemoveUserFromGroupInput, _ ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.AdminRemoveUserFromGroupOutput, error) {
+	return &cognitoidentityprovider.AdminRemoveUserFromGroupOutput{}, nil
+}
+func (cognitoClientStubs) ListUsersInGroup(_ context.Context, _ *cognitoidentityprovider.ListUsersInGroupInput, _ ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.ListUsersInGroupOutput, error) {
+	return &cognitoidentityprovider.ListUsersInGroupOutput{}, nil
+}
+func (cognitoClientStubs) AdminListGroupsForUser(_ context.Context, _ *cognitoidentityprovider.AdminListGroupsForUserInput, _ ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.AdminListGroupsForUserOutput, error) {
+	return &cognitoidentityprovider.AdminListGroupsForUserOutput{}, nil
+}
+func (cognitoClientStubs) AdminUserGlobalSignOut(_ context.Context, _ *cognitoidentityprovider.AdminUserGlobalSignOutInput, _ ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.AdminUserGlobalSignOutOutput, error) {
+	return &cognitoidentityprovider.AdminUserGlobalSignOutOutput{}, nil
+}
+func (cognitoClientStubs) AdminSetUserMFAPreference(_ context.Context, _ *cognitoidentityprovider.AdminSetUserMFAPreferenceInput, _ ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.AdminSetUserMFAPreferenceOutput, error) {
+	return &cognitoidentityprovider.AdminSetUserMFAPreferenceOutput{}, nil
+}
+func (cognitoClientStubs) GetUser(_ context.Context, _ *cognitoidentityprovider.GetUserInput, _ ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.GetUserOutput, error) {
+	return &cognitoidentityprovider.GetUserOutput{}, nil
+}
+func (cognitoClientStubs) AssociateSoftwareToken(_ context.Context, _ *cognitoidentityprovider.AssociateSoftwareTokenInput, _ ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.AssociateSoftwareTokenOutput, error) {
+	return &cognitoidentityprovider.AssociateSoftwareTokenOutput{}, nil
+}
+func (cognitoClientStubs) VerifySoftwareToken(_ context.Context, _ *cognitoidentityprovider.VerifySoftwareTokenInput, _ ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.VerifySoftwareTokenOutput, error) {
+	return &cognitoidentityprovider.VerifySoftwareTokenOutput{}, nil
+}
+func (cognitoClientStubs) InitiateAuth(_ context.Context, _ *cognitoidentityprovider.InitiateAuthInput, _ ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.InitiateAuthOutput, error) {
+	return &cognitoidentityprovider.InitiateAuthOutput{}, nil
+}
+func (cognitoClientStubs) AdminInitiateAuth(_ context.Context, _ *cognitoidentityprovider.AdminInitiateAuthInput, _ ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.AdminInitiateAuthOutput, error) {
+	return &cognitoidentityprovider.AdminInitiateAuthOutput{}, nil
+}
+func (cognitoClientStubs) RespondToAuthChallenge(_ context.Context, _ *cognitoidentityprovider.RespondToAuthChallengeInput, _ ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.RespondToAuthChallengeOutput, error) {
+	return &cognitoidentityprovider.RespondToAuthChallengeOutput{}, nil
+}
+func (cognitoClientStubs) AdminRespondToAuthChallenge(_ context.Context, _ *cognitoidentityprovider.AdminRespondToAuthChallengeInput, _ ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.AdminRespondToAuthChallengeOutput, error) {
+	return &cognitoidentityprovider.AdminRespondToAuthChallengeOutput{}, nil
+}
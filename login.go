@@ -0,0 +1,350 @@
+package user
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider/types"
+)
+
+// AuthFlow is the Cognito flow Login uses to check a password.
+type AuthFlow string
+
+const (
+	AuthFlowUserPassword      AuthFlow = "USER_PASSWORD_AUTH"       // InitiateAuth; the password is sent to Cognito over TLS
+	AuthFlowAdminUserPassword AuthFlow = "ADMIN_USER_PASSWORD_AUTH" // AdminInitiateAuth; needs IAM credentials
+	AuthFlowUserSRP           AuthFlow = "USER_SRP_AUTH"            // InitiateAuth with SRP; the password never leaves this process
+)
+
+// ChallengeName is a Cognito sign-in challenge the caller must answer.
+type ChallengeName string
+
+const (
+	ChallengeNewPasswordRequired ChallengeName = "NEW_PASSWORD_REQUIRED" // First login with a temporary password; answer with the new password
+	ChallengeSoftwareTokenMFA    ChallengeName = "SOFTWARE_TOKEN_MFA"    // Answer with the authenticator code
+	ChallengeSMSMFA              ChallengeName = "SMS_MFA"               // Answer with the code sent by SMS
+)
+
+// LoginChallenge is a pending step of a Login. Pass it back unchanged to
+// RespondToLoginChallenge together with the user's answer.
+type LoginChallenge struct {
+	Name        ChallengeName `json:"name"`
+	Session     string        `json:"session"`               // Opaque Cognito session, valid for a few minutes
+	Username    string        `json:"username"`              // Cognito username the answer is given for
+	Destination string        `json:"destination,omitempty"` // Masked phone number an SMS code was sent to
+}
+
+// LoginResult is the outcome of Login and RespondToLoginChallenge: either
+// the authenticated user's claims and tokens, or the next challenge.
+type LoginResult struct {
+	Claims    *Claims         `json:"claims,omitempty"`
+	Tokens    *OAuthTokens    `json:"-"`
+	Challenge *LoginChallenge `json:"challenge,omitempty"`
+}
+
+// authStep is one InitiateAuth or RespondToAuthChallenge response.
+type authStep struct {
+	challenge types.ChallengeNameType
+	params    map[string]string
+	session   string
+	result    *types.AuthenticationResultType
+}
+
+// Login checks a username and password against the user pool without the
+// hosted UI, using OAuthConfig.LoginAuthFlow. A wrong username or password
+// returns ErrInvalidCredentials. When Cognito asks for a new password or an
+// MFA code, the result carries a Challenge for RespondToLoginChallenge;
+// otherwise it carries the same claims the OAuth callback produces.
+func (m *Manager) Login(ctx context.Context, username, password string) (*LoginResult, error) {
+	if username == "" || password == "" {
+		return nil, fmt.Errorf("username and password cannot be empty: %w", ErrInvalidInput)
+	}
+
+	client, err := m.clients().cognitoClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	config := m.Config()
+	flow := loginAuthFlow(config)
+	params := map[string]string{"USERNAME": username}
+	var srp *srpClient
+	switch flow {
+	case AuthFlowUserPassword, AuthFlowAdminUserPassword:
+		params["PASSWORD"] = password
+	case AuthFlowUserSRP:
+		if srp, err = newSRPClient(config.UserPoolID); err != nil {
+			return nil, err
+		}
+		params["SRP_A"] = srp.srpA()
+	default:
+		return nil, fmt.Errorf("unsupported login flow %q: %w", flow, ErrInvalidInput)
+	}
+	if config.ClientSecret != "" {
+		params["SECRET_HASH"] = secretHash(username, config.ClientID, config.ClientSecret)
+	}
+
+	step, err := initiateAuth(ctx, client, config, flow, params)
+	if err != nil {
+		return nil, err
+	}
+
+	if step.challenge == types.ChallengeNameTypePasswordVerifier {
+		if srp == nil {
+			return nil, fmt.Errorf("unexpected %s challenge for %s", step.challenge, flow)
+		}
+		userID := step.params["USER_ID_FOR_SRP"]
+		signature, timestamp, err := srp.passwordClaim(userID, password, step.params["SALT"], step.params["SRP_B"], step.params["SECRET_BLOCK"], time.Now())
+		if err != nil {
+			return nil, err
+		}
+		responses := map[string]string{
+			"USERNAME":                    userID,
+			"PASSWORD_CLAIM_SECRET_BLOCK": step.params["SECRET_BLOCK"],
+			"PASSWORD_CLAIM_SIGNATURE":    signature,
+			"TIMESTAMP":                   timestamp,
+		}
+		if config.ClientSecret != "" {
+			responses["SECRET_HASH"] = secretHash(userID, config.ClientID, config.ClientSecret)
+		}
+		if step, err = respondToAuthChallenge(ctx, client, config, flow, step.challenge, step.session, responses); err != nil {
+			return nil, err
+		}
+	}
+
+	return m.finishAuthStep(ctx, config, step, username)
+}
+
+// RespondToLoginChallenge answers a challenge returned by Login: the new
+// password for NEW_PASSWORD_REQUIRED, or the 6-digit code for the MFA
+// challenges. A wrong code or an expired session returns
+// ErrInvalidCredentials; a new password rejected by the pool's policy
+// returns ErrInvalidInput. The result may be a further challenge.
+func (m *Manager) RespondToLoginChallenge(ctx context.Context, challenge LoginChallenge, answer string) (*LoginResult, error) {
+	if challenge.Session == "" || challenge.Username == "" {
+		return nil, fmt.Errorf("challenge session and username cannot be empty: %w", ErrInvalidInput)
+	}
+
+	responses := map[string]string{"USERNAME": challenge.Username}
+	switch challenge.Name {
+	case ChallengeNewPasswordRequired:
+		if answer == "" {
+			return nil, fmt.Errorf("new password cannot be empty: %w", ErrInvalidInput)
+		}
+		responses["NEW_PASSWORD"] = answer
+	case ChallengeSoftwareTokenMFA:
+		if !totpCodePattern.MatchString(answer) {
+			return nil, fmt.Errorf("code must be 6 digits: %w", ErrInvalidInput)
+		}
+		responses["SOFTWARE_TOKEN_MFA_CODE"] = answer
+	case ChallengeSMSMFA:
+		if !totpCodePattern.MatchString(answer) {
+			return nil, fmt.Errorf("code must be 6 digits: %w", ErrInvalidInput)
+		}
+		responses["SMS_MFA_CODE"] = answer
+	default:
+		return nil, fmt.Errorf("unsupported challenge %q: %w", challenge.Name, ErrInvalidInput)
+	}
+
+	client, err := m.clients().cognitoClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	config := m.Config()
+	if config.ClientSecret != "" {
+		responses["SECRET_HASH"] = secretHash(challenge.Username, config.ClientID, config.ClientSecret)
+	}
+
+	step, err := respondToAuthChallenge(ctx, client, config, loginAuthFlow(config), types.ChallengeNameType(challenge.Name), challenge.Session, responses)
+	if err != nil {
+		return nil, err
+	}
+	return m.finishAuthStep(ctx, config, step, challenge.Username)
+}
+
+// finishAuthStep turns a Cognito response into a LoginResult, validating
+// the ID token like the OAuth callback does.
+func (m *Manager) finishAuthStep(ctx context.Context, config *OAuthConfig, step *authStep, username string) (*LoginResult, error) {
+	if step.result != nil {
+		idToken := aws.ToString(step.result.IdToken)
+		claims, err := m.oidc.validate(ctx, idToken, config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to validate ID token: %w", err)
+		}
+		log.Printf("✅ [Login] %s signed in", claims.Email)
+		return &LoginResult{
+			Claims: claims,
+			Tokens: &OAuthTokens{
+				IDToken:      idToken,
+				AccessToken:  aws.ToString(step.result.AccessToken),
+				RefreshToken: aws.ToString(step.result.RefreshToken),
+				Expiry:       time.Now().Add(time.Duration(step.result.ExpiresIn) * time.Second),
+			},
+		}, nil
+	}
+
+	switch name := ChallengeName(step.challenge); name {
+	case ChallengeNewPasswordRequired, ChallengeSoftwareTokenMFA, ChallengeSMSMFA:
+		if id := step.params["USER_ID_FOR_SRP"]; id != "" {
+			username = id
+		}
+		log.Printf("🔄 [Login] %s challenge for %s", name, username)
+		return &LoginResult{Challenge: &LoginChallenge{
+			Name:        name,
+			Session:     step.session,
+			Username:    username,
+			Destination: step.params["CODE_DELIVERY_DESTINATION"],
+		}}, nil
+	default:
+		return nil, fmt.Errorf("unsupported challenge %q", step.challenge)
+	}
+}
+
+// PasswordVerifier is an optional UserStore extension for backends that can
+// check a password themselves. Without it, ChangePassword signs in through
+// Login to check the current password.
+type PasswordVerifier interface {
+	// VerifyPassword returns an error wrapping ErrInvalidCredentials when
+	// password is not the user's current password or the user is unknown.
+	VerifyPassword(ctx context.Context, username, password string) error
+}
+
+// ChangePassword sets a new permanent password after checking the user's
+// current one, returning ErrInvalidCredentials when it is wrong. A sign-in
+// that ends in an MFA or new-password challenge still proves the password.
+func (m *Manager) ChangePassword(ctx context.Context, email, currentPassword, newPassword string) error {
+	if email == "" || currentPassword == "" {
+		return fmt.Errorf("email and current password cannot be empty: %w", ErrInvalidInput)
+	}
+
+	store, err := m.userStore()
+	if err != nil {
+		return err
+	}
+	if verifier, ok := store.(PasswordVerifier); ok {
+		err = verifier.VerifyPassword(ctx, email, currentPassword)
+	} else {
+		_, err = m.Login(ctx, email, currentPassword)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := m.SetUserPassword(ctx, email, newPassword, true); err != nil {
+		return err
+	}
+
+	log.Printf("✅ [Login] %s changed their password", email)
+	return nil
+}
+
+// setLoginCookies sets the cookies the OAuth callback sets: the jwt cookie
+// with the ID token, and the refresh token in the refresh token store.
+func (m *Manager) setLoginCookies(w http.ResponseWriter, r *http.Request, result *LoginResult) {
+	config := m.requiredConfig()
+	idToken := result.Tokens.IDToken
+	w.Header().Add("Set-Cookie", CreateJWTCookie(idToken, jwtCookieMaxAge(idToken), cookieDomainFromConfig(config)))
+
+	if err := m.refreshTokenStore().SaveRefreshToken(w, r, result.Claims, result.Tokens.RefreshToken); err != nil {
+		log.Printf("⚠️ [Login] Failed to save refresh token: %v", err)
+	}
+}
+
+// loginAuthFlow returns the configured flow, defaulting to USER_PASSWORD_AUTH.
+func loginAuthFlow(config *OAuthConfig) AuthFlow {
+	if config.LoginAuthFlow != "" {
+		return config.LoginAuthFlow
+	}
+	return AuthFlowUserPassword
+}
+
+// secretHash computes the SECRET_HASH Cognito requires from app clients
+// that have a client secret: Base64(HMAC-SHA256(secret, username + clientID)).
+func secretHash(username, clientID, clientSecret string) string {
+	mac := hmac.New(sha256.New, []byte(clientSecret))
+	mac.Write([]byte(username + clientID))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func initiateAuth(ctx context.Context, client CognitoClient, config *OAuthConfig, flow AuthFlow, params map[string]string) (*authStep, error) {
+	if flow == AuthFlowAdminUserPassword {
+		result, err := client.AdminInitiateAuth(ctx, &cognitoidentityprovider.AdminInitiateAuthInput{
+			AuthFlow:       types.AuthFlowType(flow),
+			ClientId:       aws.String(config.ClientID),
+			UserPoolId:     aws.String(config.UserPoolID),
+			AuthParameters: params,
+		})
+		if err != nil {
+			return nil, wrapCognitoLoginError(err, "AdminInitiateAuth")
+		}
+		return &authStep{result.ChallengeName, result.ChallengeParameters, aws.ToString(result.Session), result.AuthenticationResult}, nil
+	}
+
+	result, err := client.InitiateAuth(ctx, &cognitoidentityprovider.InitiateAuthInput{
+		AuthFlow:       types.AuthFlowType(flow),
+		ClientId:       aws.String(config.ClientID),
+		AuthParameters: params,
+	})
+	if err != nil {
+		return nil, wrapCognitoLoginError(err, "InitiateAuth")
+	}
+	return &authStep{result.ChallengeName, result.ChallengeParameters, aws.ToString(result.Session), result.AuthenticationResult}, nil
+}
+
+func respondToAuthChallenge(ctx context.Context, client CognitoClient, config *OAuthConfig, flow AuthFlow, challenge types.ChallengeNameType, session string, responses map[string]string) (*authStep, error) {
+	if flow == AuthFlowAdminUserPassword {
+		result, err := client.AdminRespondToAuthChallenge(ctx, &cognitoidentityprovider.AdminRespondToAuthChallengeInput{
+			ChallengeName:      challenge,
+			ClientId:           aws.String(config.ClientID),
+			UserPoolId:         aws.String(config.UserPoolID),
+			Session:            aws.String(session),
+			ChallengeResponses: responses,
+		})
+		if err != nil {
+			return nil, wrapCognitoLoginError(err, "AdminRespondToAuthChallenge")
+		}
+		return &authStep{result.ChallengeName, result.ChallengeParameters, aws.ToString(result.Session), result.AuthenticationResult}, nil
+	}
+
+	result, err := client.RespondToAuthChallenge(ctx, &cognitoidentityprovider.RespondToAuthChallengeInput{
+		ChallengeName:      challenge,
+		ClientId:           aws.String(config.ClientID),
+		Session:            aws.String(session),
+		ChallengeResponses: responses,
+	})
+	if err != nil {
+		return nil, wrapCognitoLoginError(err, "RespondToAuthChallenge")
+	}
+	return &authStep{result.ChallengeName, result.ChallengeParameters, aws.ToString(result.Session), result.AuthenticationResult}, nil
+}
+
+// wrapCognitoLoginError maps sign-in errors before falling back to
+// wrapCognitoError. Unknown users, wrong passwords, wrong codes and expired
+// sessions all become ErrInvalidCredentials so callers cannot tell them apart.
+func wrapCognitoLoginError(err error, operation string) error {
+	for _, exception := range []string{
+		"NotAuthorizedException",
+		"UserNotFoundException",
+		"CodeMismatchException",
+		"ExpiredCodeException",
+	} {
+		if strings.Contains(err.Error(), exception) {
+			log.Printf("❌ [Cognito] %s failed: %v", operation, err)
+			return fmt.Errorf("%s: %s: %w", operation, exception, ErrInvalidCredentials)
+		}
+	}
+	if strings.Contains(err.Error(), "PasswordResetRequiredException") {
+		log.Printf("❌ [Cognito] %s failed: %v", operation, err)
+		return fmt.Errorf("%s: %w", operation, ErrPasswordResetRequired)
+	}
+	return wrapCognitoError(err, operation)
+}
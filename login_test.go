@@ -0,0 +1,389 @@
+package user
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider/types"
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	testLoginPoolID   = "us-east-1_TestPool"
+	testLoginSecret   = "client-secret"
+	testLoginPassword = "Passw0rd!"
+)
+
+// mockLoginCognitoClient plays Cognito's sign-in flows: it checks the
+// password (or SRP claim), then asks for a new password and a TOTP code
+// when configured to, then issues tokens.
+type mockLoginCognitoClient struct {
+	mockUserMgmtCognitoClient
+
+	idToken     string
+	newPassword bool   // Ask for NEW_PASSWORD_REQUIRED first
+	totpCode    string // Ask for SOFTWARE_TOKEN_MFA with this code
+	srp         *testSRPServer
+
+	calls  []string            // Operation names, in order
+	params []map[string]string // AuthParameters or ChallengeResponses of each call
+}
+
+func (m *mockLoginCognitoClient) record(op string, params map[string]string) {
+	m.calls = append(m.calls, op)
+	m.params = append(m.params, params)
+}
+
+func (m *mockLoginCognitoClient) initiate(flow types.AuthFlowType, params map[string]string) (types.ChallengeNameType, map[string]string, *types.AuthenticationResultType, error) {
+	if flow == types.AuthFlowTypeUserSrpAuth {
+		return types.ChallengeNameTypePasswordVerifier, m.srp.start(params["SRP_A"]), nil, nil
+	}
+	if params["PASSWORD"] != testLoginPassword {
+		return "", nil, nil, errors.New("NotAuthorizedException: Incorrect username or password.")
+	}
+	name, result := m.next()
+	return name, nil, result, nil
+}
+
+func (m *mockLoginCognitoClient) respond(name types.ChallengeNameType, responses map[string]string) (types.ChallengeNameType, *types.AuthenticationResultType, error) {
+	switch name {
+	case types.ChallengeNameTypePasswordVerifier:
+		if !m.srp.verify(responses) {
+			return "", nil, errors.New("NotAuthorizedException: Incorrect username or password.")
+		}
+	case types.ChallengeNameTypeNewPasswordRequired:
+		m.newPassword = false
+	case types.ChallengeNameTypeSoftwareTokenMfa:
+		if responses["SOFTWARE_TOKEN_MFA_CODE"] != m.totpCode {
+			return "", nil, errors.New("CodeMismatchException: Invalid code received for user")
+		}
+		m.totpCode = ""
+	}
+	name, result := m.next()
+	return name, result, nil
+}
+
+func (m *mockLoginCognitoClient) next() (types.ChallengeNameType, *types.AuthenticationResultType) {
+	switch {
+	case m.newPassword:
+		return types.ChallengeNameTypeNewPasswordRequired, nil
+	case m.totpCode != "":
+		return types.ChallengeNameTypeSoftwareTokenMfa, nil
+	}
+	return "", &types.AuthenticationResultType{
+		IdToken:      aws.String(m.idToken),
+		AccessToken:  aws.String("access-token"),
+		RefreshToken: aws.String("refresh-token"),
+		ExpiresIn:    3600,
+	}
+}
+
+func (m *mockLoginCognitoClient) InitiateAuth(_ context.Context, params *cognitoidentityprovider.InitiateAuthInput, _ ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.InitiateAuthOutput, error) {
+	m.record("InitiateAuth", params.AuthParameters)
+	name, challengeParams, result, err := m.initiate(params.AuthFlow, params.AuthParameters)
+	if err != nil {
+		return nil, err
+	}
+	return &cognitoidentityprovider.InitiateAuthOutput{ChallengeName: name, ChallengeParameters: challengeParams, Session: aws.String("session-1"), AuthenticationResult: result}, nil
+}
+
+func (m *mockLoginCognitoClient) AdminInitiateAuth(_ context.Context, params *cognitoidentityprovider.AdminInitiateAuthInput, _ ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.AdminInitiateAuthOutput, error) {
+	m.record("AdminInitiateAuth:"+aws.ToString(params.UserPoolId), params.AuthParameters)
+	name, challengeParams, result, err := m.initiate(params.AuthFlow, params.AuthParameters)
+	if err != nil {
+		return nil, err
+	}
+	return &cognitoidentityprovider.AdminInitiateAuthOutput{ChallengeName: name, ChallengeParameters: challengeParams, Session: aws.String("session-1"), AuthenticationResult: result}, nil
+}
+
+func (m *mockLoginCognitoClient) RespondToAuthChallenge(_ context.Context, params *cognitoidentityprovider.RespondToAuthChallengeInput, _ ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.RespondToAuthChallengeOutput, error) {
+	m.record("RespondToAuthChallenge", params.ChallengeResponses)
+	name, result, err := m.respond(params.ChallengeName, params.ChallengeResponses)
+	if err != nil {
+		return nil, err
+	}
+	return &cognitoidentityprovider.RespondToAuthChallengeOutput{ChallengeName: name, Session: aws.String("session-2"), AuthenticationResult: result}, nil
+}
+
+func (m *mockLoginCognitoClient) AdminRespondToAuthChallenge(_ context.Context, params *cognitoidentityprovider.AdminRespondToAuthChallengeInput, _ ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.AdminRespondToAuthChallengeOutput, error) {
+	m.record("AdminRespondToAuthChallenge:"+aws.ToString(params.UserPoolId), params.ChallengeResponses)
+	name, result, err := m.respond(params.ChallengeName, params.ChallengeResponses)
+	if err != nil {
+		return nil, err
+	}
+	return &cognitoidentityprovider.AdminRespondToAuthChallengeOutput{ChallengeName: name, Session: aws.String("session-2"), AuthenticationResult: result}, nil
+}
+
+// testSRPServer is the server half of SRP-6a with Cognito's parameters.
+type testSRPServer struct {
+	userID   string
+	password string
+	salt     *big.Int
+	b, B, A  *big.Int
+	block    []byte
+}
+
+func (s *testSRPServer) verifier() *big.Int {
+	identity := sha256.Sum256([]byte("TestPool" + s.userID + ":" + s.password))
+	x := srpHashInt(srpPad(s.salt), identity[:])
+	return new(big.Int).Exp(srpG, x, srpN)
+}
+
+func (s *testSRPServer) start(aHex string) map[string]string {
+	s.A, _ = new(big.Int).SetString(aHex, 16)
+	s.salt = randomTestInt(16)
+	s.b = randomTestInt(128)
+	s.block = randomTestInt(64).Bytes()
+
+	// B = k*v + g^b mod N
+	s.B = new(big.Int).Mul(srpK, s.verifier())
+	s.B.Add(s.B, new(big.Int).Exp(srpG, s.b, srpN))
+	s.B.Mod(s.B, srpN)
+
+	return map[string]string{
+		"SALT":            s.salt.Text(16),
+		"SRP_B":           s.B.Text(16),
+		"SECRET_BLOCK":    base64.StdEncoding.EncodeToString(s.block),
+		"USER_ID_FOR_SRP": s.userID,
+	}
+}
+
+func (s *testSRPServer) verify(responses map[string]string) bool {
+	// S = (A * v^u)^b mod N
+	u := srpHashInt(srpPad(s.A), srpPad(s.B))
+	S := new(big.Int).Exp(s.verifier(), u, srpN)
+	S.Mul(S, s.A)
+	S.Exp(S, s.b, srpN)
+
+	mac := hmac.New(sha256.New, srpHKDF(srpPad(S), srpPad(u)))
+	mac.Write([]byte("TestPool" + s.userID))
+	mac.Write(s.block)
+	mac.Write([]byte(responses["TIMESTAMP"]))
+	want := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+	return responses["USERNAME"] == s.userID && responses["PASSWORD_CLAIM_SIGNATURE"] == want
+}
+
+func randomTestInt(size int) *big.Int {
+	b := make([]byte, size)
+	rand.Read(b)
+	return new(big.Int).SetBytes(b)
+}
+
+func newLoginTestManager(t *testing.T, flow AuthFlow) (*Manager, *mockLoginCognitoClient) {
+	t.Helper()
+	issuer, sign := newTestIssuer(t)
+	client := &mockLoginCognitoClient{
+		idToken: sign("alice-sub", time.Now()),
+		srp:     &testSRPServer{userID: "alice-uuid", password: testLoginPassword},
+	}
+	config := &OAuthConfig{
+		ClientID:      testIssuerClientID,
+		ClientSecret:  testLoginSecret,
+		UserPoolID:    testLoginPoolID,
+		Region:        "us-east-1",
+		IssuerURL:     issuer,
+		LoginAuthFlow: flow,
+	}
+	return newTestManager(t, config, nil, nil, WithCognitoClient(client)), client
+}
+
+func TestSecretHash(t *testing.T) {
+	if got := secretHash("alice@example.com", "app-client", "client-secret"); got != "hRePAx0T3Bi73KxVKIcgtXRMhnuzKW8ZdM8IIexPIMY=" {
+		t.Errorf("unexpected secret hash %q", got)
+	}
+}
+
+func TestLogin_UserPasswordAuth(t *testing.T) {
+	m, client := newLoginTestManager(t, "")
+	ctx := context.Background()
+
+	result, err := m.Login(ctx, "alice@example.com", testLoginPassword)
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	if result.Challenge != nil || result.Claims == nil || result.Claims.Email != "alice@example.com" {
+		t.Fatalf("expected claims for alice, got %+v", result)
+	}
+	if result.Tokens.RefreshToken != "refresh-token" || result.Tokens.AccessToken != "access-token" {
+		t.Errorf("unexpected tokens %+v", result.Tokens)
+	}
+
+	params := client.params[0]
+	if client.calls[0] != "InitiateAuth" || params["PASSWORD"] != testLoginPassword {
+		t.Errorf("expected InitiateAuth with the password, got %v %v", client.calls, params)
+	}
+	if params["SECRET_HASH"] != secretHash("alice@example.com", testIssuerClientID, testLoginSecret) {
+		t.Errorf("expected SECRET_HASH for the client secret, got %q", params["SECRET_HASH"])
+	}
+
+	if _, err := m.Login(ctx, "alice@example.com", "wrong"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("expected ErrInvalidCredentials, got %v", err)
+	}
+	if _, err := m.Login(ctx, "alice@example.com", ""); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("expected ErrInvalidInput for an empty password, got %v", err)
+	}
+}
+
+func TestChangePassword_VerifiesCurrentPasswordWithCognito(t *testing.T) {
+	m, client := newLoginTestManager(t, "")
+	ctx := context.Background()
+
+	if err := m.ChangePassword(ctx, "alice@example.com", "wrong", "N3w-Passw0rd!"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("expected ErrInvalidCredentials, got %v", err)
+	}
+	if client.setPasswordCalled {
+		t.Fatal("expected no password change after a wrong current password")
+	}
+
+	if err := m.ChangePassword(ctx, "alice@example.com", testLoginPassword, "N3w-Passw0rd!"); err != nil {
+		t.Fatalf("ChangePassword: %v", err)
+	}
+	if !client.setPasswordCalled || aws.ToString(client.setPasswordInput.Password) != "N3w-Passw0rd!" || !client.setPasswordInput.Permanent {
+		t.Errorf("expected a permanent AdminSetUserPassword, got %+v", client.setPasswordInput)
+	}
+}
+
+func TestLogin_ChallengeLoop(t *testing.T) {
+	m, client := newLoginTestManager(t, AuthFlowAdminUserPassword)
+	client.newPassword = true
+	client.totpCode = "123456"
+	ctx := context.Background()
+
+	result, err := m.Login(ctx, "alice@example.com", testLoginPassword)
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	if result.Challenge == nil || result.Challenge.Name != ChallengeNewPasswordRequired || result.Claims != nil {
+		t.Fatalf("expected NEW_PASSWORD_REQUIRED, got %+v", result)
+	}
+
+	result, err = m.RespondToLoginChallenge(ctx, *result.Challenge, "N3w-Passw0rd!")
+	if err != nil {
+		t.Fatalf("RespondToLoginChallenge(new password): %v", err)
+	}
+	if result.Challenge == nil || result.Challenge.Name != ChallengeSoftwareTokenMFA {
+		t.Fatalf("expected SOFTWARE_TOKEN_MFA, got %+v", result)
+	}
+	challenge := *result.Challenge
+
+	if _, err := m.RespondToLoginChallenge(ctx, challenge, "654321"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("expected ErrInvalidCredentials for a wrong code, got %v", err)
+	}
+	if _, err := m.RespondToLoginChallenge(ctx, challenge, "12345"); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("expected ErrInvalidInput for a malformed code, got %v", err)
+	}
+
+	result, err = m.RespondToLoginChallenge(ctx, challenge, "123456")
+	if err != nil {
+		t.Fatalf("RespondToLoginChallenge(code): %v", err)
+	}
+	if result.Claims == nil || result.Claims.Email != "alice@example.com" {
+		t.Fatalf("expected claims after the last challenge, got %+v", result)
+	}
+
+	wantCalls := []string{
+		"AdminInitiateAuth:" + testLoginPoolID,
+		"AdminRespondToAuthChallenge:" + testLoginPoolID,
+		"AdminRespondToAuthChallenge:" + testLoginPoolID,
+		"AdminRespondToAuthChallenge:" + testLoginPoolID,
+	}
+	if strings.Join(client.calls, ",") != strings.Join(wantCalls, ",") {
+		t.Errorf("expected calls %v, got %v", wantCalls, client.calls)
+	}
+	if got := client.params[1]; got["NEW_PASSWORD"] != "N3w-Passw0rd!" || got["USERNAME"] != "alice@example.com" || got["SECRET_HASH"] == "" {
+		t.Errorf("unexpected NEW_PASSWORD_REQUIRED responses %v", got)
+	}
+}
+
+func TestLogin_UserSRPAuth(t *testing.T) {
+	m, client := newLoginTestManager(t, AuthFlowUserSRP)
+	ctx := context.Background()
+
+	result, err := m.Login(ctx, "alice@example.com", testLoginPassword)
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	if result.Claims == nil || result.Claims.Email != "alice@example.com" {
+		t.Fatalf("expected claims for alice, got %+v", result)
+	}
+	for i, params := range client.params {
+		if _, ok := params["PASSWORD"]; ok {
+			t.Errorf("%s sent the password", client.calls[i])
+		}
+	}
+	if got := client.params[1]; got["SECRET_HASH"] != secretHash("alice-uuid", testIssuerClientID, testLoginSecret) {
+		t.Errorf("expected PASSWORD_VERIFIER SECRET_HASH for USER_ID_FOR_SRP, got %q", got["SECRET_HASH"])
+	}
+
+	if _, err := m.Login(ctx, "alice@example.com", "wrong"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("expected ErrInvalidCredentials, got %v", err)
+	}
+}
+
+func TestNativeLoginRoutes(t *testing.T) {
+	m, client := newLoginTestManager(t, "")
+	client.totpCode = "123456"
+	r := chi.NewRouter()
+	m.SetupLoginRoutes(r)
+
+	post := func(path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := post("/api/auth/native-login", `{"username":"alice@example.com","password":"wrong"}`); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 for a wrong password, got %d", w.Code)
+	}
+
+	w := post("/api/auth/native-login", `{"username":"alice@example.com","password":"`+testLoginPassword+`"}`)
+	if w.Code != http.StatusOK || len(w.Header().Values("Set-Cookie")) != 0 {
+		t.Fatalf("expected 200 without cookies for a challenge, got %d %v", w.Code, w.Header().Values("Set-Cookie"))
+	}
+	var pending LoginResult
+	json.NewDecoder(w.Body).Decode(&pending)
+	if pending.Challenge == nil || pending.Challenge.Name != ChallengeSoftwareTokenMFA {
+		t.Fatalf("expected a SOFTWARE_TOKEN_MFA challenge, got %+v", pending)
+	}
+
+	body, _ := json.Marshal(map[string]string{
+		"name":     string(pending.Challenge.Name),
+		"session":  pending.Challenge.Session,
+		"username": pending.Challenge.Username,
+		"answer":   "123456",
+	})
+	w = post("/api/auth/native-login/challenge", string(body))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body)
+	}
+	var done LoginResult
+	json.NewDecoder(w.Body).Decode(&done)
+	if done.Claims == nil || done.Claims.Email != "alice@example.com" {
+		t.Errorf("expected claims in the response, got %+v", done)
+	}
+
+	cookies := map[string]string{}
+	for _, c := range (&http.Response{Header: w.Header()}).Cookies() {
+		cookies[c.Name] = c.Value
+	}
+	if cookies["jwt"] != client.idToken {
+		t.Errorf("expected the jwt cookie to carry the ID token, got %v", cookies)
+	}
+	if cookies[refreshTokenCookieName] == "" {
+		t.Errorf("expected a refresh token cookie, got %v", cookies)
+	}
+}
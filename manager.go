@@ -0,0 +1,218 @@
+package user
+
+import (
+	"fmt"
+)
+
+// Manager owns everything the user management API needs for one Cognito
+// user pool: its OAuthConfig, user store, AWS clients, OIDC verifier and
+// token revocation list, pending password resets, OAuth state repository,
+// STS credential cache and opaque-token cache. Several Managers can coexist
+// in one process (e.g. one per user pool) without sharing state.
+//
+// The package-level functions (GetUser, RequireAuthMiddleware,
+// SetupAuthRoutes, ...) are thin wrappers over a default Manager configured
+// through SetOAuthConfig, SetUserStore and the Set*ClientFactory functions.
+type Manager struct {
+	config       *OAuthConfig
+	store        UserStore
+	cognito      CognitoClient
+	sts          STSClient
+	ses          SESClient
+	stateRepo    StateRepository
+	refreshStore RefreshTokenStore
+	sessions     SessionStore
+	resets       PasswordResetStore
+	policies     *passwordPolicyCache
+	oidc         *oidcProviderCache
+	stsCache     *stsCredentialCache
+	tokenCache   *tokenClaimsCache
+
+	// refresher overrides the OAuth2Service used for transparent refresh (tests).
+	refresher tokenRefresher
+
+	// isDefault marks the package-level Manager, whose config is whatever
+	// SetOAuthConfig last stored.
+	isDefault bool
+}
+
+// ManagerOption configures a Manager created by NewManager.
+type ManagerOption func(*Manager)
+
+// WithUserStore sets the backend used for user operations. By default the
+// Manager uses the Cognito user pool from its config.
+func WithUserStore(store UserStore) ManagerOption {
+	return func(m *Manager) {
+		m.store = store
+	}
+}
+
+// WithCognitoClient sets the Cognito client. By default a client is built per
+// call through the Cognito client factory, using STS credentials when the
+// context carries a JWT.
+func WithCognitoClient(client CognitoClient) ManagerOption {
+	return func(m *Manager) {
+		m.cognito = client
+	}
+}
+
+// WithSTSClient sets the STS client used for AssumeRoleWithWebIdentity.
+func WithSTSClient(client STSClient) ManagerOption {
+	return func(m *Manager) {
+		m.sts = client
+	}
+}
+
+// WithSESClient sets the SES client used for invitation emails.
+func WithSESClient(client SESClient) ManagerOption {
+	return func(m *Manager) {
+		m.ses = client
+	}
+}
+
+// WithStateRepository sets the OAuth state repository used by SetupAuthRoutes.
+// Defaults to NewEncryptedStateRepository.
+func WithStateRepository(repo StateRepository) ManagerOption {
+	return func(m *Manager) {
+		m.stateRepo = repo
+	}
+}
+
+// WithRefreshTokenStore sets where refresh tokens issued at login are kept.
+// Defaults to an encrypted cookie (NewCookieRefreshTokenStore).
+func WithRefreshTokenStore(store RefreshTokenStore) ManagerOption {
+	return func(m *Manager) {
+		m.refreshStore = store
+	}
+}
+
+// WithSessionStore enables server-side sessions: each login gets an opaque
+// session ID cookie mapped to a stored Session holding the refresh token, and
+// RequireAuthMiddleware rejects JWTs, in the cookie or a Bearer header, whose
+// session was revoked or that come without the session cookie.
+// Takes precedence over WithRefreshTokenStore.
+func WithSessionStore(store SessionStore) ManagerOption {
+	return func(m *Manager) {
+		m.sessions = store
+	}
+}
+
+// NewManager creates a Manager for config with its own OIDC verifier,
+// revocation list, password reset store, STS cache and token cache.
+func NewManager(config *OAuthConfig, opts ...ManagerOption) *Manager {
+	m := &Manager{
+		config:     config,
+		oidc:       &oidcProviderCache{revocations: NewMemoryRevocationList()},
+		resets:     NewMemoryPasswordResetStore(),
+		policies:   &passwordPolicyCache{},
+		stsCache:   newSTSCredentialCache(),
+		tokenCache: newTokenClaimsCache(),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+var defaultManager = &Manager{
+	oidc:       defaultOIDCProvider,
+	resets:     NewMemoryPasswordResetStore(),
+	policies:   &passwordPolicyCache{},
+	stsCache:   defaultSTSCache,
+	tokenCache: newTokenClaimsCache(),
+	isDefault:  true,
+}
+
+// Default returns the Manager behind the package-level functions.
+func Default() *Manager {
+	return defaultManager
+}
+
+// Config returns the Manager's OAuth configuration.
+func (m *Manager) Config() *OAuthConfig {
+	if m.isDefault {
+		return oauthConfig
+	}
+	return m.config
+}
+
+// requiredConfig returns the config or panics if it is not set.
+func (m *Manager) requiredConfig() *OAuthConfig {
+	config := m.Config()
+	if config == nil {
+		panic("OAuth configuration not set. Call user.SetOAuthConfig() before using authentication middleware or SetupAuthRoutes()")
+	}
+	return config
+}
+
+// userStore returns the configured store, falling back to Cognito when the
+// OAuth config is set.
+func (m *Manager) userStore() (UserStore, error) {
+	if m.store != nil {
+		return m.store, nil
+	}
+
+	config := m.Config()
+	if config == nil {
+		return nil, fmt.Errorf("oauth config is not set")
+	}
+
+	return &cognitoUserStore{clients: m.clients()}, nil
+}
+
+// clients resolves AWS clients for the Manager's current config.
+func (m *Manager) clients() *awsClients {
+	return &awsClients{
+		config:   m.Config(),
+		cognito:  m.cognito,
+		sts:      m.sts,
+		ses:      m.ses,
+		stsCache: m.stsCache,
+	}
+}
+
+// refreshTokenStore returns the session-backed store when sessions are
+// enabled, then the configured store, defaulting to an encrypted cookie.
+func (m *Manager) refreshTokenStore() RefreshTokenStore {
+	if m.sessions != nil {
+		return NewSessionRefreshTokenStore(m.sessions, refreshTokenMaxAge(m.Config()))
+	}
+	if m.refreshStore != nil {
+		return m.refreshStore
+	}
+	return NewCookieRefreshTokenStore(refreshTokenMaxAge(m.Config()))
+}
+
+// tokenRefresher returns the OAuth2 service used to renew sessions.
+func (m *Manager) tokenRefresher(config *OAuthConfig) (tokenRefresher, error) {
+	if m.refresher != nil {
+		return m.refresher, nil
+	}
+	service, err := NewOAuth2ServiceFromOAuthConfig(m.stateRepo, config)
+	if err != nil {
+		return nil, err
+	}
+	service.oidc = m.oidc
+	return service, nil
+}
+
+func (m *Manager) roleAttributeName() string {
+	return roleAttributeName(m.Config())
+}
+
+// awsClients resolves the AWS clients used for one OAuthConfig. Injected
+// clients take precedence; otherwise clients are built per call through the
+// package factories so STS credentials carried on the context are honored.
+type awsClients struct {
+	config   *OAuthConfig
+	cognito  CognitoClient
+	sts      STSClient
+	ses      SESClient
+	stsCache *stsCredentialCache
+}
+
+// defaultAWSClients resolves clients for config through the package factories
+// and the shared STS cache.
+func defaultAWSClients(config *OAuthConfig) *awsClients {
+	return &awsClients{config: config, stsCache: defaultSTSCache}
+}
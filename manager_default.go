@@ -0,0 +1,380 @@
+package user
+
+import (
+	"context"
+	"io"
+	"iter"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// The package-level functions below delegate to the default Manager, which
+// reads its configuration from SetOAuthConfig and SetUserStore.
+
+// GetUser calls Manager.GetUser on the default Manager.
+func GetUser(ctx context.Context, email string) (*User, error) {
+	return defaultManager.GetUser(ctx, email)
+}
+
+// CreateUser calls Manager.CreateUser on the default Manager.
+func CreateUser(ctx context.Context, req CreateUserRequest) (*User, error) {
+	return defaultManager.CreateUser(ctx, req)
+}
+
+// UpdateProfile calls Manager.UpdateProfile on the default Manager.
+func UpdateProfile(ctx context.Context, email string, update ProfileUpdate) (*User, error) {
+	return defaultManager.UpdateProfile(ctx, email, update)
+}
+
+// UpdateRole calls Manager.UpdateRole on the default Manager.
+func UpdateRole(ctx context.Context, email string, role string) (*User, error) {
+	return defaultManager.UpdateRole(ctx, email, role)
+}
+
+// GetCustomAttributes calls Manager.GetCustomAttributes on the default Manager.
+func GetCustomAttributes(ctx context.Context, email string) (map[string]string, error) {
+	return defaultManager.GetCustomAttributes(ctx, email)
+}
+
+// UpdateCustomAttributes calls Manager.UpdateCustomAttributes on the default Manager.
+func UpdateCustomAttributes(ctx context.Context, email string, attributes map[string]string) (*User, error) {
+	return defaultManager.UpdateCustomAttributes(ctx, email, attributes)
+}
+
+// DeleteCustomAttributes calls Manager.DeleteCustomAttributes on the default Manager.
+func DeleteCustomAttributes(ctx context.Context, email string, names ...string) (*User, error) {
+	return defaultManager.DeleteCustomAttributes(ctx, email, names...)
+}
+
+// CreateGroup calls Manager.CreateGroup on the default Manager.
+func CreateGroup(ctx context.Context, group Group) (*Group, error) {
+	return defaultManager.CreateGroup(ctx, group)
+}
+
+// DeleteGroup calls Manager.DeleteGroup on the default Manager.
+func DeleteGroup(ctx context.Context, name string) error {
+	return defaultManager.DeleteGroup(ctx, name)
+}
+
+// ListGroups calls Manager.ListGroups on the default Manager.
+func ListGroups(ctx context.Context) ([]*Group, error) {
+	return defaultManager.ListGroups(ctx)
+}
+
+// AddUserToGroup calls Manager.AddUserToGroup on the default Manager.
+func AddUserToGroup(ctx context.Context, email, group string) error {
+	return defaultManager.AddUserToGroup(ctx, email, group)
+}
+
+// RemoveUserFromGroup calls Manager.RemoveUserFromGroup on the default Manager.
+func RemoveUserFromGroup(ctx context.Context, email, group string) error {
+	return defaultManager.RemoveUserFromGroup(ctx, email, group)
+}
+
+// ListUsersInGroup calls Manager.ListUsersInGroup on the default Manager.
+func ListUsersInGroup(ctx context.Context, group string, limit, offset int) ([]*User, error) {
+	return defaultManager.ListUsersInGroup(ctx, group, limit, offset)
+}
+
+// ListGroupsForUser calls Manager.ListGroupsForUser on the default Manager.
+func ListGroupsForUser(ctx context.Context, email string) ([]*Group, error) {
+	return defaultManager.ListGroupsForUser(ctx, email)
+}
+
+// UpdateTenantID calls Manager.UpdateTenantID on the default Manager.
+func UpdateTenantID(ctx context.Context, email string, tenantID string) (*User, error) {
+	return defaultManager.UpdateTenantID(ctx, email, tenantID)
+}
+
+// DisableUser calls Manager.DisableUser on the default Manager.
+func DisableUser(ctx context.Context, email string) error {
+	return defaultManager.DisableUser(ctx, email)
+}
+
+// SignOutUser calls Manager.SignOutUser on the default Manager.
+func SignOutUser(ctx context.Context, email string) error {
+	return defaultManager.SignOutUser(ctx, email)
+}
+
+// SetUserMFAPreference calls Manager.SetUserMFAPreference on the default Manager.
+func SetUserMFAPreference(ctx context.Context, email string, methods []MFAMethod, preferred MFAMethod) error {
+	return defaultManager.SetUserMFAPreference(ctx, email, methods, preferred)
+}
+
+// SetRoleMFAPreference calls Manager.SetRoleMFAPreference on the default Manager.
+func SetRoleMFAPreference(ctx context.Context, role string, methods []MFAMethod, preferred MFAMethod) (*MFARoleReport, error) {
+	return defaultManager.SetRoleMFAPreference(ctx, role, methods, preferred)
+}
+
+// EnrollTOTP calls Manager.EnrollTOTP on the default Manager.
+func EnrollTOTP(ctx context.Context, email, accessToken string) (*TOTPEnrollment, error) {
+	return defaultManager.EnrollTOTP(ctx, email, accessToken)
+}
+
+// VerifyTOTP calls Manager.VerifyTOTP on the default Manager.
+func VerifyTOTP(ctx context.Context, email, accessToken, code, deviceName string) error {
+	return defaultManager.VerifyTOTP(ctx, email, accessToken, code, deviceName)
+}
+
+// Login calls Manager.Login on the default Manager.
+func Login(ctx context.Context, username, password string) (*LoginResult, error) {
+	return defaultManager.Login(ctx, username, password)
+}
+
+// ChangePassword calls Manager.ChangePassword on the default Manager.
+func ChangePassword(ctx context.Context, email, currentPassword, newPassword string) error {
+	return defaultManager.ChangePassword(ctx, email, currentPassword, newPassword)
+}
+
+// RespondToLoginChallenge calls Manager.RespondToLoginChallenge on the default Manager.
+func RespondToLoginChallenge(ctx context.Context, challenge LoginChallenge, answer string) (*LoginResult, error) {
+	return defaultManager.RespondToLoginChallenge(ctx, challenge, answer)
+}
+
+// EnableUser calls Manager.EnableUser on the default Manager.
+func EnableUser(ctx context.Context, email string) error {
+	return defaultManager.EnableUser(ctx, email)
+}
+
+// UpdateServiceProviderID calls Manager.UpdateServiceProviderID on the default Manager.
+func UpdateServiceProviderID(ctx context.Context, email string, serviceProviderID string) (*User, error) {
+	return defaultManager.UpdateServiceProviderID(ctx, email, serviceProviderID)
+}
+
+// SetUserPassword calls Manager.SetUserPassword on the default Manager.
+func SetUserPassword(ctx context.Context, email string, password string, permanent bool) error {
+	return defaultManager.SetUserPassword(ctx, email, password, permanent)
+}
+
+// GetPasswordPolicy calls Manager.GetPasswordPolicy on the default Manager.
+func GetPasswordPolicy(ctx context.Context) PasswordPolicy {
+	return defaultManager.GetPasswordPolicy(ctx)
+}
+
+// DeleteUser calls Manager.DeleteUser on the default Manager.
+func DeleteUser(ctx context.Context, email string) error {
+	return defaultManager.DeleteUser(ctx, email)
+}
+
+// ListUsers calls Manager.ListUsers on the default Manager.
+func ListUsers(ctx context.Context, limit, offset int) ([]*User, error) {
+	return defaultManager.ListUsers(ctx, limit, offset)
+}
+
+// ListUsersPage calls Manager.ListUsersPage on the default Manager.
+func ListUsersPage(ctx context.Context, opts ListUsersOptions) (*UserPage, error) {
+	return defaultManager.ListUsersPage(ctx, opts)
+}
+
+// AllUsers calls Manager.AllUsers on the default Manager.
+func AllUsers(ctx context.Context, opts AllUsersOptions) iter.Seq2[*User, error] {
+	return defaultManager.AllUsers(ctx, opts)
+}
+
+// BulkImportUsers calls Manager.BulkImportUsers on the default Manager.
+func BulkImportUsers(ctx context.Context, r io.Reader, opts BulkImportOptions) (*BulkImportReport, error) {
+	return defaultManager.BulkImportUsers(ctx, r, opts)
+}
+
+// ExportUsers calls Manager.ExportUsers on the default Manager.
+func ExportUsers(ctx context.Context, w io.Writer, opts ExportOptions) (int, error) {
+	return defaultManager.ExportUsers(ctx, w, opts)
+}
+
+// GenerateAPIKey calls Manager.GenerateAPIKey on the default Manager.
+func GenerateAPIKey(ctx context.Context, email string) (string, error) {
+	return defaultManager.GenerateAPIKey(ctx, email)
+}
+
+// GetAPIKey calls Manager.GetAPIKey on the default Manager.
+func GetAPIKey(ctx context.Context, email string) (string, error) {
+	return defaultManager.GetAPIKey(ctx, email)
+}
+
+// RotateAPIKey calls Manager.RotateAPIKey on the default Manager.
+func RotateAPIKey(ctx context.Context, email string) (string, error) {
+	return defaultManager.RotateAPIKey(ctx, email)
+}
+
+// UpdateAPIKey calls Manager.UpdateAPIKey on the default Manager.
+func UpdateAPIKey(ctx context.Context, email string, apiKey string) error {
+	return defaultManager.UpdateAPIKey(ctx, email, apiKey)
+}
+
+// ValidateAPIKey calls Manager.ValidateAPIKey on the default Manager.
+func ValidateAPIKey(ctx context.Context, apiKey string) (*User, error) {
+	return defaultManager.ValidateAPIKey(ctx, apiKey)
+}
+
+// FindUserByToken calls Manager.FindUserByToken on the default Manager.
+func FindUserByToken(ctx context.Context, token string) (*Claims, error) {
+	return defaultManager.FindUserByToken(ctx, token)
+}
+
+// CreateUserWithInvitation calls Manager.CreateUserWithInvitation on the default Manager.
+func CreateUserWithInvitation(ctx context.Context, req CreateUserRequest) (*User, string, error) {
+	return defaultManager.CreateUserWithInvitation(ctx, req)
+}
+
+// ForgotPassword calls Manager.ForgotPassword on the default Manager.
+func ForgotPassword(ctx context.Context, email string) error {
+	return defaultManager.ForgotPassword(ctx, email)
+}
+
+// ConfirmForgotPassword calls Manager.ConfirmForgotPassword on the default Manager.
+func ConfirmForgotPassword(ctx context.Context, email, code, newPassword string) error {
+	return defaultManager.ConfirmForgotPassword(ctx, email, code, newPassword)
+}
+
+// ResetTemporaryPassword calls Manager.ResetTemporaryPassword on the default Manager.
+func ResetTemporaryPassword(ctx context.Context, email string) (string, error) {
+	return defaultManager.ResetTemporaryPassword(ctx, email)
+}
+
+// CreateAPIKey calls Manager.CreateAPIKey on the default Manager.
+func CreateAPIKey(ctx context.Context, email string, req CreateAPIKeyRequest) (string, *APIKey, error) {
+	return defaultManager.CreateAPIKey(ctx, email, req)
+}
+
+// ListAPIKeys calls Manager.ListAPIKeys on the default Manager.
+func ListAPIKeys(ctx context.Context, email string) ([]*APIKey, error) {
+	return defaultManager.ListAPIKeys(ctx, email)
+}
+
+// RevokeAPIKey calls Manager.RevokeAPIKey on the default Manager.
+func RevokeAPIKey(ctx context.Context, email, keyID string) error {
+	return defaultManager.RevokeAPIKey(ctx, email, keyID)
+}
+
+// RotateAPIKeyByID calls Manager.RotateAPIKeyByID on the default Manager.
+func RotateAPIKeyByID(ctx context.Context, email, keyID string, grace time.Duration) (string, *APIKey, error) {
+	return defaultManager.RotateAPIKeyByID(ctx, email, keyID, grace)
+}
+
+// MigratePlaintextAPIKeys calls Manager.MigratePlaintextAPIKeys on the default Manager.
+func MigratePlaintextAPIKeys(ctx context.Context) (*APIKeyMigrationResult, error) {
+	return defaultManager.MigratePlaintextAPIKeys(ctx)
+}
+
+// GetTokenCacheStats calls Manager.TokenCacheStats on the default Manager.
+func GetTokenCacheStats() TokenCacheStats {
+	return defaultManager.TokenCacheStats()
+}
+
+// ClearTokenCache calls Manager.ClearTokenCache on the default Manager.
+func ClearTokenCache() {
+	defaultManager.ClearTokenCache()
+}
+
+// ListSessions calls Manager.ListSessions on the default Manager.
+func ListSessions(ctx context.Context, username string) ([]*Session, error) {
+	return defaultManager.ListSessions(ctx, username)
+}
+
+// RevokeSession calls Manager.RevokeSession on the default Manager.
+func RevokeSession(ctx context.Context, username, sessionID string) error {
+	return defaultManager.RevokeSession(ctx, username, sessionID)
+}
+
+// RevokeAllSessions calls Manager.RevokeAllSessions on the default Manager.
+func RevokeAllSessions(ctx context.Context, username string) (int, error) {
+	return defaultManager.RevokeAllSessions(ctx, username)
+}
+
+// SendInvitationEmail calls Manager.SendInvitationEmail on the default Manager.
+func SendInvitationEmail(ctx context.Context, req InvitationEmailRequest) error {
+	return defaultManager.SendInvitationEmail(ctx, req)
+}
+
+// SendPasswordResetEmail calls Manager.SendPasswordResetEmail on the default Manager.
+func SendPasswordResetEmail(ctx context.Context, req PasswordResetEmailRequest) error {
+	return defaultManager.SendPasswordResetEmail(ctx, req)
+}
+
+// GetUserPoolPreSignUpARN calls Manager.GetUserPoolPreSignUpARN on the default Manager.
+func GetUserPoolPreSignUpARN(ctx context.Context, userPoolID string) (string, error) {
+	return defaultManager.GetUserPoolPreSignUpARN(ctx, userPoolID)
+}
+
+// RequireAuthMiddleware calls Manager.RequireAuthMiddleware on the default Manager.
+func RequireAuthMiddleware(opts ...AuthMiddlewareOption) func(http.Handler) http.Handler {
+	return defaultManager.RequireAuthMiddleware(opts...)
+}
+
+// RequireRole calls Manager.RequireRole on the default Manager.
+func RequireRole(role string) func(http.Handler) http.Handler {
+	return defaultManager.RequireRole(role)
+}
+
+// RequireAnyRole calls Manager.RequireAnyRole on the default Manager.
+func RequireAnyRole(roles ...string) func(http.Handler) http.Handler {
+	return defaultManager.RequireAnyRole(roles...)
+}
+
+// RequirePermission calls Manager.RequirePermission on the default Manager.
+func RequirePermission(permissions ...string) func(http.Handler) http.Handler {
+	return defaultManager.RequirePermission(permissions...)
+}
+
+// RequireTenant calls Manager.RequireTenant on the default Manager.
+func RequireTenant(resolvers ...TenantResolver) func(http.Handler) http.Handler {
+	return defaultManager.RequireTenant(resolvers...)
+}
+
+// GetTenantUser calls Manager.GetTenantUser on the default Manager.
+func GetTenantUser(ctx context.Context, tenantID, email string) (*User, error) {
+	return defaultManager.GetTenantUser(ctx, tenantID, email)
+}
+
+// ListTenantUsers calls Manager.ListTenantUsers on the default Manager.
+func ListTenantUsers(ctx context.Context, tenantID string, limit, offset int) ([]*User, error) {
+	return defaultManager.ListTenantUsers(ctx, tenantID, limit, offset)
+}
+
+// HasRole calls Manager.HasRole on the default Manager.
+func HasRole(claims *Claims, role string) bool {
+	return defaultManager.HasRole(claims, role)
+}
+
+// HasPermission calls Manager.HasPermission on the default Manager.
+func HasPermission(claims *Claims, permission string) bool {
+	return defaultManager.HasPermission(claims, permission)
+}
+
+// SetupAuthRoutes calls Manager.SetupAuthRoutes on the default Manager.
+// Note: You must call SetOAuthConfig() first to configure OAuth settings
+func SetupAuthRoutes(r chi.Router) error {
+	return defaultManager.SetupAuthRoutes(r)
+}
+
+// SetupSTSRoutes calls Manager.SetupSTSRoutes on the default Manager.
+func SetupSTSRoutes(r chi.Router) {
+	defaultManager.SetupSTSRoutes(r)
+}
+
+// SetupPasswordResetRoutes calls Manager.SetupPasswordResetRoutes on the default Manager.
+func SetupPasswordResetRoutes(r chi.Router) {
+	defaultManager.SetupPasswordResetRoutes(r)
+}
+
+// SetupLoginRoutes calls Manager.SetupLoginRoutes on the default Manager.
+func SetupLoginRoutes(r chi.Router) {
+	defaultManager.SetupLoginRoutes(r)
+}
+
+// SetupSelfServiceRoutes calls Manager.SetupSelfServiceRoutes on the default Manager.
+func SetupSelfServiceRoutes(r chi.Router) {
+	defaultManager.SetupSelfServiceRoutes(r)
+}
+
+// SetupMFARoutes calls Manager.SetupMFARoutes on the default Manager.
+func SetupMFARoutes(r chi.Router) {
+	defaultManager.SetupMFARoutes(r)
+}
+
+// SetupAdminUserRoutes calls Manager.SetupAdminUserRoutes on the default Manager.
+func SetupAdminUserRoutes(r chi.Router) {
+	defaultManager.SetupAdminUserRoutes(r)
+}
@@ -0,0 +1,103 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestManager_IsolatedStores(t *testing.T) {
+	ctx := context.Background()
+
+	storeA := NewMemoryUserStore()
+	storeB := NewMemoryUserStore()
+	managerA := NewManager(&OAuthConfig{RoleAttributeName: "custom:userRole"}, WithUserStore(storeA))
+	managerB := NewManager(&OAuthConfig{}, WithUserStore(storeB))
+
+	created, err := managerA.CreateUser(ctx, CreateUserRequest{Email: "a@example.com", Role: "admin"})
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	if created.Role != "admin" {
+		t.Errorf("expected role admin, got %q", created.Role)
+	}
+
+	record, err := storeA.GetUser(ctx, "a@example.com")
+	if err != nil {
+		t.Fatalf("storeA.GetUser: %v", err)
+	}
+	if record.Attributes["custom:userRole"] != "admin" {
+		t.Errorf("expected role under managerA's role attribute, got %+v", record.Attributes)
+	}
+
+	if _, err := managerB.GetUser(ctx, "a@example.com"); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("expected ErrUserNotFound from managerB, got %v", err)
+	}
+}
+
+func TestManager_DoesNotUseDefaultStore(t *testing.T) {
+	setupMemoryStore(t)
+	ctx := context.Background()
+
+	if _, err := CreateUser(ctx, CreateUserRequest{Email: "default@example.com"}); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+
+	m := NewManager(&OAuthConfig{}, WithUserStore(NewMemoryUserStore()))
+	if _, err := m.GetUser(ctx, "default@example.com"); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("expected ErrUserNotFound, got %v", err)
+	}
+}
+
+func TestManager_NoConfigWithoutStore(t *testing.T) {
+	m := NewManager(nil)
+	if _, err := m.GetUser(context.Background(), "x@example.com"); err == nil {
+		t.Error("expected error when neither config nor store is set")
+	}
+}
+
+func TestManager_DefaultFollowsSetOAuthConfig(t *testing.T) {
+	originalConfig := oauthConfig
+	t.Cleanup(func() { oauthConfig = originalConfig })
+
+	config := &OAuthConfig{Region: "us-east-1"}
+	SetOAuthConfig(config)
+	if Default().Config() != config {
+		t.Error("expected default Manager to use the config passed to SetOAuthConfig")
+	}
+}
+
+func TestManager_STSCacheIsPerManager(t *testing.T) {
+	clearSTSCache()
+	t.Cleanup(clearSTSCache)
+
+	expiration := time.Now().Add(1 * time.Hour)
+	stsA := &mockSTSClient{expiration: expiration}
+	stsB := &mockSTSClient{expiration: expiration}
+	config := &OAuthConfig{Region: "us-east-1"}
+	managerA := NewManager(config, WithSTSClient(stsA))
+	managerB := NewManager(config, WithSTSClient(stsB))
+
+	token := createTestJWT("sts@example.com", "arn:aws:iam::123456789012:role/test")
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, err := managerA.GetSTSCredentials(ctx, token); err != nil {
+			t.Fatalf("managerA.GetSTSCredentials: %v", err)
+		}
+	}
+	if _, err := managerB.GetSTSCredentials(ctx, token); err != nil {
+		t.Fatalf("managerB.GetSTSCredentials: %v", err)
+	}
+
+	if stsA.callCount != 1 {
+		t.Errorf("expected managerA to call STS once, got %d", stsA.callCount)
+	}
+	if stsB.callCount != 1 {
+		t.Errorf("expected managerB to call STS once (separate cache), got %d", stsB.callCount)
+	}
+	if getCachedCredentials(hashToken(token)) != nil {
+		t.Error("expected package-level STS cache to be untouched")
+	}
+}
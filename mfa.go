@@ -0,0 +1,262 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"regexp"
+)
+
+// MFAMethod is a multi-factor method, named as Cognito reports it.
+type MFAMethod string
+
+const (
+	MFAMethodSMS  MFAMethod = "SMS_MFA"            // Code sent to the verified phone_number
+	MFAMethodTOTP MFAMethod = "SOFTWARE_TOKEN_MFA" // Code from an authenticator app
+)
+
+// TOTPEnrollment is a pending authenticator enrollment. The user adds the
+// secret to their authenticator, then confirms with VerifyTOTP.
+type TOTPEnrollment struct {
+	SecretCode string `json:"secretCode"` // Base32 secret, for manual entry
+	OTPAuthURI string `json:"otpauthUri"` // otpauth:// URI, for QR codes
+}
+
+// MFARoleReport is the outcome of SetRoleMFAPreference.
+type MFARoleReport struct {
+	Updated []string          `json:"updated"`          // Emails whose MFA preference was set
+	Failed  map[string]string `json:"failed,omitempty"` // Email -> reason, e.g. no TOTP authenticator enrolled yet
+}
+
+// MFAStore is an optional UserStore extension for backends with
+// multi-factor authentication. The Cognito store implements it.
+type MFAStore interface {
+	// SetMFAPreference enables exactly methods for the user and makes
+	// preferred ("" for none) the method used for sign-in challenges.
+	SetMFAPreference(ctx context.Context, username string, methods []MFAMethod, preferred MFAMethod) error
+	// AccessTokenUsername returns the username of the owner of accessToken,
+	// or an error wrapping ErrInvalidInput for a token that is not valid.
+	AccessTokenUsername(ctx context.Context, accessToken string) (string, error)
+	// AssociateSoftwareToken starts a TOTP enrollment for the owner of
+	// accessToken and returns the shared secret.
+	AssociateSoftwareToken(ctx context.Context, accessToken string) (string, error)
+	// VerifySoftwareToken completes the enrollment with a code from the
+	// authenticator, or returns an error wrapping ErrInvalidInput.
+	VerifySoftwareToken(ctx context.Context, accessToken, code, deviceName string) error
+}
+
+var totpCodePattern = regexp.MustCompile(`^[0-9]{6}$`)
+
+// mfaStore returns the user store's MFAStore implementation.
+func (m *Manager) mfaStore() (MFAStore, error) {
+	store, err := m.userStore()
+	if err != nil {
+		return nil, err
+	}
+	mfa, ok := store.(MFAStore)
+	if !ok {
+		return nil, fmt.Errorf("user store %T does not support MFA", store)
+	}
+	return mfa, nil
+}
+
+// SetUserMFAPreference enables exactly the given MFA methods for a user;
+// methods left out are disabled, so an empty list turns MFA off. preferred
+// may be "" when a single method is given. SMS needs a verified phone_number
+// and TOTP an enrolled authenticator, otherwise Cognito rejects the change.
+func (m *Manager) SetUserMFAPreference(ctx context.Context, email string, methods []MFAMethod, preferred MFAMethod) error {
+	if email == "" {
+		return fmt.Errorf("email cannot be empty: %w", ErrInvalidInput)
+	}
+	methods, preferred, err := normalizeMFAPreference(methods, preferred)
+	if err != nil {
+		return err
+	}
+
+	store, err := m.mfaStore()
+	if err != nil {
+		return err
+	}
+	if err := store.SetMFAPreference(ctx, email, methods, preferred); err != nil {
+		return err
+	}
+
+	log.Printf("✅ MFA methods for %s set to %v", email, methods)
+	return nil
+}
+
+// SetRoleMFAPreference applies SetUserMFAPreference to every user with the
+// given role. Users whose change is rejected are reported in Failed and do
+// not stop the others; only listing errors are returned.
+func (m *Manager) SetRoleMFAPreference(ctx context.Context, role string, methods []MFAMethod, preferred MFAMethod) (*MFARoleReport, error) {
+	if role == "" {
+		return nil, fmt.Errorf("role cannot be empty: %w", ErrInvalidInput)
+	}
+	methods, preferred, err := normalizeMFAPreference(methods, preferred)
+	if err != nil {
+		return nil, err
+	}
+
+	store, err := m.mfaStore()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &MFARoleReport{Updated: []string{}}
+	err = m.walkUsers(ctx, AllUsersOptions{Filter: UserFilter{Role: role}}, func(record *UserRecord, user *User) bool {
+		if err := store.SetMFAPreference(ctx, record.Username, methods, preferred); err != nil {
+			if report.Failed == nil {
+				report.Failed = make(map[string]string)
+			}
+			report.Failed[user.Email] = err.Error()
+			return true
+		}
+		report.Updated = append(report.Updated, user.Email)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("✅ MFA methods for role %s set to %v: %d updated, %d failed", role, methods, len(report.Updated), len(report.Failed))
+	return report, nil
+}
+
+// EnrollTOTP starts an authenticator enrollment for the owner of
+// accessToken (a Cognito access token, not the ID token). email labels the
+// entry in the authenticator app, under OAuthConfig.AppName.
+func (m *Manager) EnrollTOTP(ctx context.Context, email, accessToken string) (*TOTPEnrollment, error) {
+	if email == "" || accessToken == "" {
+		return nil, fmt.Errorf("email and access token cannot be empty: %w", ErrInvalidInput)
+	}
+
+	store, err := m.mfaStore()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := m.accessTokenUser(ctx, store, email, accessToken); err != nil {
+		return nil, err
+	}
+	secret, err := store.AssociateSoftwareToken(ctx, accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TOTPEnrollment{
+		SecretCode: secret,
+		OTPAuthURI: totpURI(m.totpIssuer(), email, secret),
+	}, nil
+}
+
+// VerifyTOTP completes an enrollment started by EnrollTOTP with a code from
+// the authenticator, then enables TOTP for the user as the preferred method,
+// keeping SMS if it was enabled. A wrong code returns ErrInvalidInput.
+func (m *Manager) VerifyTOTP(ctx context.Context, email, accessToken, code, deviceName string) error {
+	if email == "" || accessToken == "" {
+		return fmt.Errorf("email and access token cannot be empty: %w", ErrInvalidInput)
+	}
+	if !totpCodePattern.MatchString(code) {
+		return fmt.Errorf("code must be 6 digits: %w", ErrInvalidInput)
+	}
+
+	store, err := m.mfaStore()
+	if err != nil {
+		return err
+	}
+	user, err := m.accessTokenUser(ctx, store, email, accessToken)
+	if err != nil {
+		return err
+	}
+	if err := store.VerifySoftwareToken(ctx, accessToken, code, deviceName); err != nil {
+		return err
+	}
+
+	methods := []MFAMethod{MFAMethodTOTP}
+	for _, method := range user.MFAMethods {
+		if method != MFAMethodTOTP {
+			methods = append(methods, method)
+		}
+	}
+	if err := store.SetMFAPreference(ctx, email, methods, MFAMethodTOTP); err != nil {
+		return err
+	}
+
+	log.Printf("✅ TOTP enrolled for %s", email)
+	return nil
+}
+
+// accessTokenUser returns the user with the given email, or an error wrapping
+// ErrInvalidInput when accessToken was issued to someone else: the token
+// picks the account Cognito enrolls, the email the account whose preference
+// is changed, and the two must agree.
+func (m *Manager) accessTokenUser(ctx context.Context, store MFAStore, email, accessToken string) (*User, error) {
+	owner, err := store.AccessTokenUsername(ctx, accessToken)
+	if err != nil {
+		return nil, err
+	}
+	user, err := m.GetUser(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	if owner != user.Username {
+		log.Printf("❌ Access token of %s presented for %s", owner, email)
+		return nil, fmt.Errorf("access token does not belong to %s: %w", email, ErrInvalidInput)
+	}
+	return user, nil
+}
+
+// normalizeMFAPreference rejects unknown or duplicate methods and a preferred
+// method that is not enabled. A single method is preferred by default.
+func normalizeMFAPreference(methods []MFAMethod, preferred MFAMethod) ([]MFAMethod, MFAMethod, error) {
+	seen := make(map[MFAMethod]bool, len(methods))
+	for _, method := range methods {
+		if method != MFAMethodSMS && method != MFAMethodTOTP {
+			return nil, "", fmt.Errorf("unknown MFA method %q: %w", method, ErrInvalidInput)
+		}
+		if seen[method] {
+			return nil, "", fmt.Errorf("MFA method %q given twice: %w", method, ErrInvalidInput)
+		}
+		seen[method] = true
+	}
+
+	if preferred == "" && len(methods) == 1 {
+		preferred = methods[0]
+	}
+	if preferred != "" && !seen[preferred] {
+		return nil, "", fmt.Errorf("preferred MFA method %q is not enabled: %w", preferred, ErrInvalidInput)
+	}
+	return methods, preferred, nil
+}
+
+// hasMFAMethod reports whether methods contains method.
+func hasMFAMethod(methods []MFAMethod, method MFAMethod) bool {
+	for _, m := range methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// totpIssuer names the service in authenticator apps.
+func (m *Manager) totpIssuer() string {
+	if config := m.Config(); config != nil && config.AppName != "" {
+		return config.AppName
+	}
+	return "Cognito"
+}
+
+// totpURI builds the Key URI Format understood by authenticator apps
+// (otpauth://totp/Issuer:account?secret=...&issuer=Issuer), with Cognito's
+// fixed parameters: SHA1, 6 digits, 30 second period.
+func totpURI(issuer, account, secret string) string {
+	query := url.Values{
+		"secret":    {secret},
+		"issuer":    {issuer},
+		"algorithm": {"SHA1"},
+		"digits":    {"6"},
+		"period":    {"30"},
+	}
+	return "otpauth://totp/" + url.PathEscape(issuer+":"+account) + "?" + query.Encode()
+}
@@ -0,0 +1,325 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider/types"
+	"github.com/go-chi/chi/v5"
+)
+
+// mockMFACognitoClient keeps per-user MFA settings, accepts the access token
+// "access-ok" (issued to test@example.com; "access-other" belongs to someone
+// else) and the TOTP code "123456", and refuses to enable TOTP for users in
+// noAuthenticator.
+type mockMFACognitoClient struct {
+	mockPagingCognitoClient
+	methods         map[string][]string
+	preferred       map[string]string
+	noAuthenticator map[string]bool
+	verifyInput     *cognitoidentityprovider.VerifySoftwareTokenInput
+}
+
+func newMockMFACognitoClient() *mockMFACognitoClient {
+	return &mockMFACognitoClient{
+		methods:         make(map[string][]string),
+		preferred:       make(map[string]string),
+		noAuthenticator: make(map[string]bool),
+	}
+}
+
+func (m *mockMFACognitoClient) AdminGetUser(ctx context.Context, params *cognitoidentityprovider.AdminGetUserInput, optFns ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.AdminGetUserOutput, error) {
+	result, _ := m.mockUserMgmtCognitoClient.AdminGetUser(ctx, params, optFns...)
+	username := aws.ToString(params.Username)
+	result.UserMFASettingList = m.methods[username]
+	if preferred := m.preferred[username]; preferred != "" {
+		result.PreferredMfaSetting = aws.String(preferred)
+	}
+	return result, nil
+}
+
+func (m *mockMFACognitoClient) AdminSetUserMFAPreference(_ context.Context, params *cognitoidentityprovider.AdminSetUserMFAPreferenceInput, _ ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.AdminSetUserMFAPreferenceOutput, error) {
+	username := aws.ToString(params.Username)
+	if params.SoftwareTokenMfaSettings.Enabled && m.noAuthenticator[username] {
+		return nil, errors.New("InvalidParameterException: User has not set up software token mfa")
+	}
+
+	var methods []string
+	preferred := ""
+	if params.SMSMfaSettings.Enabled {
+		methods = append(methods, string(MFAMethodSMS))
+	}
+	if params.SMSMfaSettings.PreferredMfa {
+		preferred = string(MFAMethodSMS)
+	}
+	if params.SoftwareTokenMfaSettings.Enabled {
+		methods = append(methods, string(MFAMethodTOTP))
+	}
+	if params.SoftwareTokenMfaSettings.PreferredMfa {
+		preferred = string(MFAMethodTOTP)
+	}
+	m.methods[username] = methods
+	m.preferred[username] = preferred
+	return &cognitoidentityprovider.AdminSetUserMFAPreferenceOutput{}, nil
+}
+
+func (m *mockMFACognitoClient) GetUser(_ context.Context, params *cognitoidentityprovider.GetUserInput, _ ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.GetUserOutput, error) {
+	switch aws.ToString(params.AccessToken) {
+	case "access-ok":
+		return &cognitoidentityprovider.GetUserOutput{Username: aws.String("test@example.com")}, nil
+	case "access-other":
+		return &cognitoidentityprovider.GetUserOutput{Username: aws.String("other@example.com")}, nil
+	}
+	return nil, errors.New("NotAuthorizedException: Invalid Access Token")
+}
+
+func (m *mockMFACognitoClient) AssociateSoftwareToken(_ context.Context, params *cognitoidentityprovider.AssociateSoftwareTokenInput, _ ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.AssociateSoftwareTokenOutput, error) {
+	if aws.ToString(params.AccessToken) != "access-ok" {
+		return nil, errors.New("NotAuthorizedException: Invalid Access Token")
+	}
+	return &cognitoidentityprovider.AssociateSoftwareTokenOutput{SecretCode: aws.String("JBSWY3DPEHPK3PXP")}, nil
+}
+
+func (m *mockMFACognitoClient) VerifySoftwareToken(_ context.Context, params *cognitoidentityprovider.VerifySoftwareTokenInput, _ ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.VerifySoftwareTokenOutput, error) {
+	m.verifyInput = params
+	if aws.ToString(params.UserCode) != "123456" {
+		return nil, errors.New("CodeMismatchException: Invalid code received for user")
+	}
+	return &cognitoidentityprovider.VerifySoftwareTokenOutput{Status: types.VerifySoftwareTokenResponseTypeSuccess}, nil
+}
+
+func newMFATestManager(client *mockMFACognitoClient) *Manager {
+	return NewManager(&OAuthConfig{UserPoolID: "pool", Region: "us-east-1", AppName: "Acme Portal"}, WithCognitoClient(client))
+}
+
+func TestSetUserMFAPreference(t *testing.T) {
+	client := newMockMFACognitoClient()
+	m := newMFATestManager(client)
+	ctx := context.Background()
+
+	if err := m.SetUserMFAPreference(ctx, "test@example.com", []MFAMethod{MFAMethodSMS}, ""); err != nil {
+		t.Fatalf("SetUserMFAPreference: %v", err)
+	}
+	user, err := m.GetUser(ctx, "test@example.com")
+	if err != nil {
+		t.Fatalf("GetUser: %v", err)
+	}
+	if !reflect.DeepEqual(user.MFAMethods, []MFAMethod{MFAMethodSMS}) || user.PreferredMFA != MFAMethodSMS {
+		t.Errorf("expected SMS to be enabled and preferred, got %v %q", user.MFAMethods, user.PreferredMFA)
+	}
+
+	if err := m.SetUserMFAPreference(ctx, "test@example.com", nil, ""); err != nil {
+		t.Fatalf("SetUserMFAPreference: %v", err)
+	}
+	if user, _ := m.GetUser(ctx, "test@example.com"); len(user.MFAMethods) != 0 || user.PreferredMFA != "" {
+		t.Errorf("expected MFA to be off, got %v %q", user.MFAMethods, user.PreferredMFA)
+	}
+
+	for name, tc := range map[string]struct {
+		methods   []MFAMethod
+		preferred MFAMethod
+	}{
+		"unknown method":        {[]MFAMethod{"EMAIL_OTP"}, ""},
+		"duplicate method":      {[]MFAMethod{MFAMethodSMS, MFAMethodSMS}, ""},
+		"preferred not enabled": {[]MFAMethod{MFAMethodSMS}, MFAMethodTOTP},
+	} {
+		if err := m.SetUserMFAPreference(ctx, "test@example.com", tc.methods, tc.preferred); !errors.Is(err, ErrInvalidInput) {
+			t.Errorf("%s: expected ErrInvalidInput, got %v", name, err)
+		}
+	}
+
+	client.noAuthenticator["test@example.com"] = true
+	if err := m.SetUserMFAPreference(ctx, "test@example.com", []MFAMethod{MFAMethodTOTP}, ""); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("expected ErrInvalidInput without an authenticator, got %v", err)
+	}
+}
+
+func TestSetUserMFAPreference_UnsupportedStore(t *testing.T) {
+	m := NewManager(&OAuthConfig{}, WithUserStore(NewMemoryUserStore()))
+	err := m.SetUserMFAPreference(context.Background(), "test@example.com", []MFAMethod{MFAMethodSMS}, "")
+	if err == nil || !strings.Contains(err.Error(), "does not support MFA") {
+		t.Errorf("expected an unsupported store error, got %v", err)
+	}
+}
+
+func TestSetRoleMFAPreference(t *testing.T) {
+	client := newMockMFACognitoClient()
+	client.pages = map[string]*cognitoidentityprovider.ListUsersOutput{
+		"": {Users: []types.UserType{
+			cognitoTestUser("a@example.com", "admin"),
+			cognitoTestUser("b@example.com", "user"),
+			cognitoTestUser("c@example.com", "admin"),
+		}},
+	}
+	client.noAuthenticator["c@example.com"] = true
+	m := newMFATestManager(client)
+
+	report, err := m.SetRoleMFAPreference(context.Background(), "admin", []MFAMethod{MFAMethodTOTP}, "")
+	if err != nil {
+		t.Fatalf("SetRoleMFAPreference: %v", err)
+	}
+	if !reflect.DeepEqual(report.Updated, []string{"a@example.com"}) {
+		t.Errorf("expected only a@example.com to be updated, got %v", report.Updated)
+	}
+	if len(report.Failed) != 1 || report.Failed["c@example.com"] == "" {
+		t.Errorf("expected c@example.com to be reported as failed, got %v", report.Failed)
+	}
+	if _, ok := client.methods["b@example.com"]; ok {
+		t.Error("users with other roles must not be changed")
+	}
+}
+
+func TestEnrollAndVerifyTOTP(t *testing.T) {
+	client := newMockMFACognitoClient()
+	client.methods["test@example.com"] = []string{string(MFAMethodSMS)}
+	m := newMFATestManager(client)
+	ctx := context.Background()
+
+	enrollment, err := m.EnrollTOTP(ctx, "test@example.com", "access-ok")
+	if err != nil {
+		t.Fatalf("EnrollTOTP: %v", err)
+	}
+	uri, err := url.Parse(enrollment.OTPAuthURI)
+	if err != nil {
+		t.Fatalf("parse otpauth URI: %v", err)
+	}
+	if uri.Scheme != "otpauth" || uri.Host != "totp" || uri.Path != "/Acme Portal:test@example.com" {
+		t.Errorf("unexpected otpauth URI %q", enrollment.OTPAuthURI)
+	}
+	if q := uri.Query(); q.Get("secret") != "JBSWY3DPEHPK3PXP" || q.Get("issuer") != "Acme Portal" || q.Get("digits") != "6" {
+		t.Errorf("unexpected otpauth parameters %v", q)
+	}
+	if _, err := m.EnrollTOTP(ctx, "test@example.com", "stolen"); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("expected ErrInvalidInput for a bad access token, got %v", err)
+	}
+
+	if _, err := m.EnrollTOTP(ctx, "test@example.com", "access-other"); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("expected ErrInvalidInput for another user's access token, got %v", err)
+	}
+	if err := m.VerifyTOTP(ctx, "test@example.com", "access-other", "123456", "phone"); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("expected ErrInvalidInput for another user's access token, got %v", err)
+	}
+	if client.verifyInput != nil || len(client.methods["test@example.com"]) != 1 {
+		t.Fatalf("expected no verification with another user's access token, got %+v %v", client.verifyInput, client.methods)
+	}
+
+	if err := m.VerifyTOTP(ctx, "test@example.com", "access-ok", "654321", "phone"); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("expected ErrInvalidInput for a wrong code, got %v", err)
+	}
+	if err := m.VerifyTOTP(ctx, "test@example.com", "access-ok", "12345", "phone"); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("expected ErrInvalidInput for a malformed code, got %v", err)
+	}
+	if err := m.VerifyTOTP(ctx, "test@example.com", "access-ok", "123456", "phone"); err != nil {
+		t.Fatalf("VerifyTOTP: %v", err)
+	}
+	if aws.ToString(client.verifyInput.FriendlyDeviceName) != "phone" {
+		t.Errorf("expected the device name to be passed on, got %+v", client.verifyInput)
+	}
+
+	user, _ := m.GetUser(ctx, "test@example.com")
+	if !reflect.DeepEqual(user.MFAMethods, []MFAMethod{MFAMethodSMS, MFAMethodTOTP}) || user.PreferredMFA != MFAMethodTOTP {
+		t.Errorf("expected TOTP to be added as preferred, keeping SMS, got %v %q", user.MFAMethods, user.PreferredMFA)
+	}
+}
+
+// accessTokenRefresher returns a fixed access token on refresh.
+type accessTokenRefresher struct {
+	accessToken string
+}
+
+func (f *accessTokenRefresher) RefreshTokens(ctx context.Context, refreshToken string) (*Claims, *OAuthTokens, error) {
+	return &Claims{}, &OAuthTokens{AccessToken: f.accessToken, RefreshToken: refreshToken}, nil
+}
+
+// fixedRefreshTokenStore holds one refresh token for every request.
+type fixedRefreshTokenStore struct {
+	token string
+}
+
+func (s *fixedRefreshTokenStore) SaveRefreshToken(w http.ResponseWriter, r *http.Request, claims *Claims, refreshToken string) error {
+	s.token = refreshToken
+	return nil
+}
+
+func (s *fixedRefreshTokenStore) LoadRefreshToken(r *http.Request) (string, error) {
+	if s.token == "" {
+		return "", ErrSessionNotFound
+	}
+	return s.token, nil
+}
+
+func (s *fixedRefreshTokenStore) ClearRefreshToken(w http.ResponseWriter, r *http.Request) error {
+	s.token = ""
+	return nil
+}
+
+func TestMFARoutes(t *testing.T) {
+	issuer, sign := newTestIssuer(t)
+	client := newMockMFACognitoClient()
+	refreshStore := &fixedRefreshTokenStore{}
+	config := &OAuthConfig{ClientID: testIssuerClientID, IssuerURL: issuer, UserPoolID: "pool", Region: "us-east-1"}
+	m := NewManager(config, WithCognitoClient(client), WithRefreshTokenStore(refreshStore))
+	m.refresher = &accessTokenRefresher{accessToken: "access-ok"}
+
+	r := chi.NewRouter()
+	m.SetupMFARoutes(r)
+	jwtCookie := &http.Cookie{Name: "jwt", Value: sign("user-1", time.Now().Add(-time.Minute))}
+
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.AddCookie(jwtCookie)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	// No access token in the body and no refresh token to mint one from
+	if w := serve(http.MethodPost, "/api/auth/mfa/totp", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without an access token, got %d", w.Code)
+	}
+
+	w := serve(http.MethodPost, "/api/auth/mfa/totp", `{"accessToken":"access-ok"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var enrollment TOTPEnrollment
+	json.Unmarshal(w.Body.Bytes(), &enrollment)
+	if !strings.HasPrefix(enrollment.OTPAuthURI, "otpauth://totp/") {
+		t.Errorf("expected an otpauth URI, got %+v", enrollment)
+	}
+
+	if w := serve(http.MethodPost, "/api/auth/mfa/totp/verify", `{"accessToken":"access-other","code":"123456"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for another user's access token, got %d", w.Code)
+	}
+
+	refreshStore.token = "refresh-1"
+	if w := serve(http.MethodPost, "/api/auth/mfa/totp/verify", `{"code":"000000"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a wrong code, got %d", w.Code)
+	}
+	if w := serve(http.MethodPost, "/api/auth/mfa/totp/verify", `{"code":"123456","deviceName":"phone"}`); w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d: %s", w.Code, w.Body.String())
+	}
+	if aws.ToString(client.verifyInput.AccessToken) != "access-ok" {
+		t.Errorf("expected the access token to come from the refresh token, got %q", aws.ToString(client.verifyInput.AccessToken))
+	}
+
+	w = serve(http.MethodGet, "/api/auth/mfa", "")
+	var status struct {
+		Methods   []MFAMethod `json:"methods"`
+		Preferred MFAMethod   `json:"preferred"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &status)
+	if w.Code != http.StatusOK || status.Preferred != MFAMethodTOTP {
+		t.Errorf("expected TOTP to be preferred, got %d %+v", w.Code, status)
+	}
+}
@@ -2,6 +2,7 @@ package user
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"net/http"
 	"strings"
@@ -20,37 +21,63 @@ const UserKey = contextKey("user")
 
 // Claims represents authentication claims for both JWT and API key authentication
 type Claims struct {
-	Sub               string `json:"sub"`                      // User ID
-	Email             string `json:"email"`                    // User email
-	Name              string `json:"name"`                     // Full name (Cognito name field)
-	GivenName         string `json:"given_name"`               // User first name
-	FamilyName        string `json:"family_name"`              // User last name
-	Picture           string `json:"picture"`                  // Profile picture URL
-	Username          string `json:"username"`                 // Username (usually email)
-	APIKey            string `json:"api_key"`                  // API key if used for auth
-	Role              string `json:"role"`                     // User role
-	UserRole          string `json:"custom:userRole"`          // User role from Cognito custom attribute
-	TenantID          string `json:"custom:tenantId"`          // Tenant ID from Cognito custom attribute
-	ServiceProviderID string `json:"custom:serviceProviderId"` // Service Provider ID from Cognito custom attribute
-	Provider          string `json:"provider"`                 // Auth provider (jwt, api_key)
+	Sub               string   `json:"sub"`                      // User ID
+	Email             string   `json:"email"`                    // User email
+	Name              string   `json:"name"`                     // Full name (Cognito name field)
+	GivenName         string   `json:"given_name"`               // User first name
+	FamilyName        string   `json:"family_name"`              // User last name
+	Picture           string   `json:"picture"`                  // Profile picture URL
+	Username          string   `json:"username"`                 // Username (usually email)
+	APIKey            string   `json:"api_key"`                  // API key if used for auth
+	Role              string   `json:"role"`                     // User role
+	UserRole          string   `json:"custom:userRole"`          // User role from Cognito custom attribute
+	TenantID          string   `json:"custom:tenantId"`          // Tenant ID from Cognito custom attribute
+	ServiceProviderID string   `json:"custom:serviceProviderId"` // Service Provider ID from Cognito custom attribute
+	Provider          string   `json:"provider"`                 // Auth provider (jwt, api_key)
+	APIKeyID          string   `json:"api_key_id,omitempty"`     // ID of the API key used, if any
+	Scopes            []string `json:"scopes,omitempty"`         // Scopes granted to the API key used, if any
+	SessionID         string   `json:"session_id,omitempty"`     // Server-side session, when sessions are enabled
 }
 
-// getRequiredOIDCConfig returns the global OIDC config or panics if not set
-func getRequiredOIDCConfig() *OAuthConfig {
-	if oauthConfig == nil {
-		panic("OAuth configuration not set. Call user.SetOAuthConfig() before using authentication middleware or SetupAuthRoutes()")
+// HasScope reports whether the API key used to authenticate was granted scope.
+func (c *Claims) HasScope(scope string) bool {
+	for _, s := range c.Scopes {
+		if s == scope {
+			return true
+		}
 	}
-	return oauthConfig
+	return false
 }
 
 // AuthConfig, DefaultAuthConfig, validateAPIKey removed
 // These were used by OptionalAuthMiddleware and APIKeyOnlyMiddleware which required
 // SQLite database access. Use RequireAuthMiddleware() instead for JWT/Cognito auth.
 
+// AuthMiddlewareOption configures RequireAuthMiddleware
+type AuthMiddlewareOption func(*authMiddlewareOptions)
+
+type authMiddlewareOptions struct {
+	transparentRefresh bool
+}
+
+// WithTransparentRefresh renews the session from the stored refresh token
+// when the JWT cookie has expired (or the browser already dropped it),
+// instead of rejecting the request. The new JWT cookie is set on the response.
+func WithTransparentRefresh() AuthMiddlewareOption {
+	return func(o *authMiddlewareOptions) {
+		o.transparentRefresh = true
+	}
+}
+
 // RequireAuthMiddleware creates middleware that requires authentication
 // Supports both JWT tokens (from cookie) and opaque tokens (from Authorization header)
 // No database operations - validates JWT tokens or looks up users in Cognito by token
-func RequireAuthMiddleware() func(http.Handler) http.Handler {
+func (m *Manager) RequireAuthMiddleware(opts ...AuthMiddlewareOption) func(http.Handler) http.Handler {
+	var options authMiddlewareOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			log.Printf("🔍 [RequireAuthMiddleware] === Authentication Check Started ===")
@@ -60,13 +87,23 @@ func RequireAuthMiddleware() func(http.Handler) http.Handler {
 			var claims *Claims
 			var err error
 
+			// A missing or expired JWT cookie can be renewed from the refresh token
+			canRefresh := options.transparentRefresh
+
 			// First, try JWT cookie with OIDC validation
 			cookie, cookieErr := r.Cookie("jwt")
 			if cookieErr == nil {
 				log.Printf("🔍 [RequireAuthMiddleware] Found JWT cookie: jwt (length: %d)", len(cookie.Value))
-				oidcConfig := getRequiredOIDCConfig()
+				oidcConfig := m.requiredConfig()
 				log.Printf("🔄 [RequireAuthMiddleware] Validating JWT token...")
-				claims, err = ValidateOIDCTokenFromOAuthConfig(r.Context(), cookie.Value, oidcConfig)
+				claims, err = m.oidc.validate(r.Context(), cookie.Value, oidcConfig)
+				if err == nil && claims != nil {
+					err = m.bindSession(r, claims)
+					if err != nil {
+						log.Printf("❌ [RequireAuthMiddleware] Session rejected: %v", err)
+						claims = nil
+					}
+				}
 				if err == nil && claims != nil {
 					log.Printf("✅ [RequireAuthMiddleware] JWT token validated successfully")
 					log.Printf("🔍 [RequireAuthMiddleware] Claims - Email: %s, Username: %s, Sub: %s", claims.Email, claims.Username, claims.Sub)
@@ -79,6 +116,7 @@ func RequireAuthMiddleware() func(http.Handler) http.Handler {
 				} else {
 					log.Printf("❌ [RequireAuthMiddleware] JWT token validation failed: %v", err)
 					log.Printf("🔍 [RequireAuthMiddleware] Error type: %T", err)
+					canRefresh = canRefresh && isTokenExpiredError(err)
 				}
 			} else {
 				log.Printf("⚠️ [RequireAuthMiddleware] No JWT cookie found: %v", cookieErr)
@@ -95,8 +133,15 @@ func RequireAuthMiddleware() func(http.Handler) http.Handler {
 					// Check if it's a JWT (has 3 dot-separated parts) or opaque token
 					if isJWTToken(token) {
 						log.Printf("🔄 [RequireAuthMiddleware] Token appears to be JWT, validating...")
-						oidcConfig := getRequiredOIDCConfig()
-						claims, err = ValidateOIDCTokenFromOAuthConfig(r.Context(), token, oidcConfig)
+						oidcConfig := m.requiredConfig()
+						claims, err = m.oidc.validate(r.Context(), token, oidcConfig)
+						if err == nil && claims != nil {
+							err = m.bindSession(r, claims)
+							if err != nil {
+								log.Printf("❌ [RequireAuthMiddleware] Session rejected: %v", err)
+								claims = nil
+							}
+						}
 						if err == nil && claims != nil {
 							log.Printf("✅ [RequireAuthMiddleware] JWT token from Authorization header validated successfully")
 							ctx := context.WithValue(r.Context(), ClaimsKey, claims)
@@ -108,7 +153,7 @@ func RequireAuthMiddleware() func(http.Handler) http.Handler {
 					} else {
 						// Opaque token - look up in Cognito
 						log.Printf("🔄 [RequireAuthMiddleware] Token appears to be opaque, looking up in Cognito...")
-						claims, err = FindUserByToken(r.Context(), token)
+						claims, err = m.cachedClaimsForToken(r.Context(), token)
 						if err == nil && claims != nil {
 							log.Printf("✅ [RequireAuthMiddleware] Opaque token validated successfully")
 							log.Printf("🔍 [RequireAuthMiddleware] Claims - Email: %s, Username: %s", claims.Email, claims.Username)
@@ -122,6 +167,22 @@ func RequireAuthMiddleware() func(http.Handler) http.Handler {
 				}
 			}
 
+			// Third, renew the session from the refresh token
+			if canRefresh {
+				log.Printf("🔄 [RequireAuthMiddleware] Attempting transparent session refresh...")
+				claims, err = m.renewSession(w, r)
+				if err == nil && claims != nil {
+					err = m.bindSession(r, claims)
+				}
+				if err == nil && claims != nil {
+					log.Printf("✅ [RequireAuthMiddleware] Session refreshed for %s", claims.Email)
+					ctx := context.WithValue(r.Context(), ClaimsKey, claims)
+					next.ServeHTTP(w, r.WithContext(ctx))
+					return
+				}
+				log.Printf("❌ [RequireAuthMiddleware] Session refresh failed: %v", err)
+			}
+
 			// Authentication failed
 			log.Printf("❌ [RequireAuthMiddleware] === Authentication Failed ===")
 			log.Printf("❌ [RequireAuthMiddleware] No valid authentication token provided")
@@ -130,6 +191,42 @@ func RequireAuthMiddleware() func(http.Handler) http.Handler {
 	}
 }
 
+// renewSession renews the request's session from its stored refresh token.
+func (m *Manager) renewSession(w http.ResponseWriter, r *http.Request) (*Claims, error) {
+	config := m.requiredConfig()
+	refresher, err := m.tokenRefresher(config)
+	if err != nil {
+		return nil, err
+	}
+	return renewSession(w, r, refresher, m.refreshTokenStore(), CreateJWTCookie, cookieDomainFromConfig(config))
+}
+
+// bindSession checks that a JWT-authenticated request, whether the JWT came
+// in the cookie or the Authorization header, belongs to a live server-side
+// session of the same user and records its ID in claims. It is a no-op unless
+// a SessionStore is configured.
+func (m *Manager) bindSession(r *http.Request, claims *Claims) error {
+	if m.sessions == nil {
+		return nil
+	}
+
+	cookie, err := r.Cookie(sessionCookieName)
+	if err != nil || cookie.Value == "" {
+		return fmt.Errorf("no session cookie: %w", ErrSessionNotFound)
+	}
+
+	session, err := m.sessions.GetSession(r.Context(), cookie.Value)
+	if err != nil {
+		return err
+	}
+	if session.Username != claims.Username {
+		return fmt.Errorf("session belongs to another user: %w", ErrSessionNotFound)
+	}
+
+	claims.SessionID = session.ID
+	return nil
+}
+
 func isJWTToken(token string) bool {
 	parts := strings.Split(token, ".")
 	return len(parts) == 3
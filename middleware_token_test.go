@@ -58,6 +58,7 @@ func TestRequireAuthMiddleware_TokenAuth(t *testing.T) {
 					Users: []types.UserType{
 						{
 							Username: aws.String("testuser"),
+							Enabled:  true,
 							Attributes: []types.AttributeType{
 								{Name: aws.String("sub"), Value: aws.String("user-123")},
 								{Name: aws.String("email"), Value: aws.String("test@example.com")},
@@ -2,10 +2,12 @@ package user
 
 import (
 	"context"
+	"crypto/subtle"
 	"fmt"
 	"log"
 	"time"
 
+	"github.com/coreos/go-oidc/v3/oidc"
 	"golang.org/x/oauth2"
 )
 
@@ -15,6 +17,19 @@ type OAuth2Service struct {
 	stateRepo           StateRepository
 	oauthConfig         *OAuthConfig
 	oauth2ConfigFactory func() (*oauth2.Config, error)
+	oidc                *oidcProviderCache // nil uses the package-level provider
+
+	// verifyIDToken overrides OIDC validation of issued ID tokens (tests). It
+	// returns the claims and the token's nonce claim.
+	verifyIDToken func(ctx context.Context, rawIDToken string) (*Claims, string, error)
+}
+
+// OAuthTokens holds the tokens issued by the provider's token endpoint.
+type OAuthTokens struct {
+	IDToken      string
+	AccessToken  string
+	RefreshToken string    // Empty when the provider did not issue one
+	Expiry       time.Time // Access token expiry
 }
 
 // NewOAuth2ServiceFromOAuthConfig creates a new OAuth2 service with OAuthConfig
@@ -31,6 +46,14 @@ func NewOAuth2ServiceFromOAuthConfig(stateRepo StateRepository, oauthConfig *OAu
 	}, nil
 }
 
+// oidcProvider returns the OIDC provider cache used for discovery and ID token validation.
+func (s *OAuth2Service) oidcProvider() *oidcProviderCache {
+	if s.oidc != nil {
+		return s.oidc
+	}
+	return defaultOIDCProvider
+}
+
 // validateOAuthConfig validates that all required OAuthConfig fields are provided
 func validateOAuthConfig(config *OAuthConfig) error {
 	if config == nil {
@@ -42,9 +65,6 @@ func validateOAuthConfig(config *OAuthConfig) error {
 	if config.ClientID == "" {
 		missing = append(missing, "ClientID")
 	}
-	if config.ClientSecret == "" {
-		missing = append(missing, "ClientSecret")
-	}
 	if config.RedirectURI == "" {
 		missing = append(missing, "RedirectURI")
 	}
@@ -67,6 +87,16 @@ func validateOAuthConfig(config *OAuthConfig) error {
 		return fmt.Errorf("missing required OAuthConfig fields: %v", missing)
 	}
 
+	if config.Roles != nil {
+		if _, err := compileRoles(config.Roles); err != nil {
+			return fmt.Errorf("invalid OAuthConfig roles: %w", err)
+		}
+	}
+
+	if _, err := compileAttributeSchema(config.AttributeSchema); err != nil {
+		return fmt.Errorf("invalid OAuthConfig attribute schema: %w", err)
+	}
+
 	return nil
 }
 
@@ -100,19 +130,33 @@ func (s *OAuth2Service) GenerateAuthURL(redirectURL, loginHint string) (string,
 	}
 	log.Printf("✅ [OAuth2Service] State prepared successfully in %v", duration)
 
+	// OIDC nonce: echoed in the ID token and checked in HandleCallback
+	opts := []oauth2.AuthCodeOption{oauth2.AccessTypeOffline, oidc.Nonce(nonce)}
+
 	var oauthState string
 	if encryptedRepo, ok := s.stateRepo.(*EncryptedStateRepository); ok {
-		oauthState, err = encryptedRepo.GenerateEncryptedState(nonce, redirectURL)
+		// PKCE: the verifier travels inside the encrypted state and the
+		// provider only sees its S256 challenge
+		codeVerifier := oauth2.GenerateVerifier()
+		oauthState, err = encryptedRepo.encryptPayload(statePayload{
+			Timestamp:    time.Now().Unix(),
+			RedirectURL:  redirectURL,
+			Nonce:        nonce,
+			CodeVerifier: codeVerifier,
+		})
 		if err != nil {
 			return "", fmt.Errorf("failed to generate encrypted state: %w", err)
 		}
-		log.Printf("✅ [OAuth2Service] Generated encrypted state token (length: %d)", len(oauthState))
+		opts = append(opts, oauth2.S256ChallengeOption(codeVerifier))
+		log.Printf("✅ [OAuth2Service] Generated encrypted state token with PKCE challenge (length: %d)", len(oauthState))
 	} else {
 		oauthState = nonce
 		log.Printf("✅ [OAuth2Service] Using nonce as state (stored in database)")
+		if s.oauthConfig != nil && s.oauthConfig.ClientSecret == "" {
+			log.Printf("⚠️ [OAuth2Service] Public client without PKCE: use the encrypted state repository to enable PKCE")
+		}
 	}
 
-	opts := []oauth2.AuthCodeOption{oauth2.AccessTypeOffline}
 	if loginHint != "" {
 		opts = append(opts, oauth2.SetAuthURLParam("login_hint", loginHint))
 	}
@@ -132,6 +176,16 @@ func (s *OAuth2Service) buildOAuth2Config() (*oauth2.Config, error) {
 
 // HandleCallback processes an OAuth2 callback and returns user claims, raw ID token, and redirect URL
 func (s *OAuth2Service) HandleCallback(code, state string) (*Claims, string, string, error) {
+	claims, tokens, redirectURL, err := s.HandleCallbackTokens(code, state)
+	if err != nil {
+		return nil, "", "", err
+	}
+	return claims, tokens.IDToken, redirectURL, nil
+}
+
+// HandleCallbackTokens processes an OAuth2 callback like HandleCallback but
+// returns every issued token, including the refresh token.
+func (s *OAuth2Service) HandleCallbackTokens(code, state string) (*Claims, *OAuthTokens, string, error) {
 	log.Printf("🔍 [HandleCallback] === Starting OAuth Callback Processing ===")
 	log.Printf("🔍 [HandleCallback] Code length: %d", len(code))
 	log.Printf("🔍 [HandleCallback] State length: %d", len(state))
@@ -144,17 +198,17 @@ func (s *OAuth2Service) HandleCallback(code, state string) (*Claims, string, str
 
 	if code == "" {
 		log.Printf("❌ [HandleCallback] Missing authorization code")
-		return nil, "", "", fmt.Errorf("missing authorization code")
+		return nil, nil, "", fmt.Errorf("missing authorization code")
 	}
 
 	if state == "" {
 		log.Printf("❌ [HandleCallback] Missing state parameter")
-		return nil, "", "", fmt.Errorf("missing state parameter")
+		return nil, nil, "", fmt.Errorf("missing state parameter")
 	}
 
 	// Validate state parameter and get redirect URL
 	log.Printf("🔄 [HandleCallback] Validating state parameter...")
-	redirectURL, isValid := s.stateRepo.ValidateAndRemoveState(state)
+	payload, isValid := s.consumeState(state)
 	if !isValid {
 		log.Printf("❌ [HandleCallback] State validation failed - invalid or expired")
 		statePreview := state
@@ -162,24 +216,30 @@ func (s *OAuth2Service) HandleCallback(code, state string) (*Claims, string, str
 			statePreview = state[:20] + "..."
 		}
 		log.Printf("🔍 [HandleCallback] State that failed: %s", statePreview)
-		return nil, "", "", fmt.Errorf("invalid or expired state parameter")
+		return nil, nil, "", fmt.Errorf("invalid or expired state parameter")
 	}
+	redirectURL := payload.RedirectURL
 	log.Printf("✅ [HandleCallback] State validated successfully, redirect URL: %s", redirectURL)
 
 	// Initialize OAuth2 config
-	oauth2Config, err := s.createOAuth2Config()
+	oauth2Config, err := s.buildOAuth2Config()
 	if err != nil {
-		return nil, "", "", fmt.Errorf("failed to initialize OAuth2 config: %w", err)
+		return nil, nil, "", fmt.Errorf("failed to initialize OAuth2 config: %w", err)
 	}
 
 	// Exchange authorization code for tokens
 	log.Printf("🔄 [HandleCallback] Exchanging authorization code for tokens...")
 	log.Printf("🔍 [HandleCallback] OAuth2 Config - ClientID: %s, RedirectURI: %s", oauth2Config.ClientID, oauth2Config.RedirectURL)
-	token, err := oauth2Config.Exchange(context.Background(), code)
+	var exchangeOpts []oauth2.AuthCodeOption
+	if payload.CodeVerifier != "" {
+		log.Printf("🔍 [HandleCallback] Sending PKCE code verifier")
+		exchangeOpts = append(exchangeOpts, oauth2.VerifierOption(payload.CodeVerifier))
+	}
+	token, err := oauth2Config.Exchange(context.Background(), code, exchangeOpts...)
 	if err != nil {
 		log.Printf("❌ [HandleCallback] Token exchange failed: %v", err)
 		log.Printf("🔍 [HandleCallback] Error type: %T", err)
-		return nil, "", "", fmt.Errorf("failed to exchange code for token: %w", err)
+		return nil, nil, "", fmt.Errorf("failed to exchange code for token: %w", err)
 	}
 	log.Printf("✅ [HandleCallback] Token exchange successful")
 	log.Printf("🔍 [HandleCallback] Token type: %s", token.TokenType)
@@ -189,17 +249,21 @@ func (s *OAuth2Service) HandleCallback(code, state string) (*Claims, string, str
 	rawIDToken, ok := token.Extra("id_token").(string)
 	if !ok {
 		log.Printf("❌ [HandleCallback] No ID token in response")
-		return nil, "", "", fmt.Errorf("no ID token in response")
+		return nil, nil, "", fmt.Errorf("no ID token in response")
 	}
 	log.Printf("✅ [HandleCallback] ID token extracted, length: %d", len(rawIDToken))
 
 	// Validate ID token
 	log.Printf("🔄 [HandleCallback] Validating ID token...")
-	claims, err := ValidateOIDCTokenFromOAuthConfig(context.Background(), rawIDToken, s.oauthConfig)
+	claims, tokenNonce, err := s.validateToken(context.Background(), rawIDToken)
 	if err != nil {
 		log.Printf("❌ [HandleCallback] ID token validation failed: %v", err)
 		log.Printf("🔍 [HandleCallback] Error type: %T", err)
-		return nil, "", "", fmt.Errorf("failed to validate ID token: %w", err)
+		return nil, nil, "", fmt.Errorf("failed to validate ID token: %w", err)
+	}
+	if err := verifyNonce(payload.Nonce, tokenNonce); err != nil {
+		log.Printf("❌ [HandleCallback] ID token nonce mismatch")
+		return nil, nil, "", fmt.Errorf("failed to validate ID token: %w", err)
 	}
 	log.Printf("✅ [HandleCallback] ID token validated successfully")
 	log.Printf("🔍 [HandleCallback] Claims - Email: %s, Username: %s, Sub: %s, GivenName: %s, FamilyName: %s",
@@ -207,7 +271,93 @@ func (s *OAuth2Service) HandleCallback(code, state string) (*Claims, string, str
 
 	// JWT validation only - no database operations
 	log.Printf("✅ [HandleCallback] === OAuth Callback Processing Completed Successfully ===")
-	return claims, rawIDToken, redirectURL, nil
+	tokens := &OAuthTokens{
+		IDToken:      rawIDToken,
+		AccessToken:  token.AccessToken,
+		RefreshToken: token.RefreshToken,
+		Expiry:       token.Expiry,
+	}
+	log.Printf("🔍 [HandleCallback] Refresh token issued: %v", tokens.RefreshToken != "")
+	return claims, tokens, redirectURL, nil
+}
+
+// consumeState validates and removes state, returning its payload. Only the
+// encrypted state repository carries more than the redirect URL.
+func (s *OAuth2Service) consumeState(state string) (*statePayload, bool) {
+	if encryptedRepo, ok := s.stateRepo.(*EncryptedStateRepository); ok {
+		return encryptedRepo.validatePayload(state)
+	}
+
+	redirectURL, ok := s.stateRepo.ValidateAndRemoveState(state)
+	if !ok {
+		return nil, false
+	}
+	// Repositories that store the state server-side use it as the nonce
+	return &statePayload{RedirectURL: redirectURL, Nonce: state}, true
+}
+
+// RefreshTokens exchanges a refresh token for a new ID token and returns its
+// validated claims. Providers that do not rotate refresh tokens (Cognito)
+// return the original refresh token in OAuthTokens.
+func (s *OAuth2Service) RefreshTokens(ctx context.Context, refreshToken string) (*Claims, *OAuthTokens, error) {
+	if refreshToken == "" {
+		return nil, nil, fmt.Errorf("refresh token cannot be empty")
+	}
+
+	oauth2Config, err := s.buildOAuth2Config()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to initialize OAuth2 config: %w", err)
+	}
+
+	log.Printf("🔄 [RefreshTokens] Exchanging refresh token...")
+	token, err := oauth2Config.TokenSource(ctx, &oauth2.Token{RefreshToken: refreshToken}).Token()
+	if err != nil {
+		log.Printf("❌ [RefreshTokens] Refresh token exchange failed: %v", err)
+		return nil, nil, fmt.Errorf("failed to refresh token: %w", err)
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		log.Printf("❌ [RefreshTokens] No ID token in response")
+		return nil, nil, fmt.Errorf("no ID token in response")
+	}
+
+	// Refreshed ID tokens carry no nonce: there is no authorize request to bind to
+	claims, _, err := s.validateToken(ctx, rawIDToken)
+	if err != nil {
+		log.Printf("❌ [RefreshTokens] ID token validation failed: %v", err)
+		return nil, nil, fmt.Errorf("failed to validate ID token: %w", err)
+	}
+	log.Printf("✅ [RefreshTokens] Tokens refreshed for %s", claims.Email)
+
+	tokens := &OAuthTokens{
+		IDToken:      rawIDToken,
+		AccessToken:  token.AccessToken,
+		RefreshToken: token.RefreshToken,
+		Expiry:       token.Expiry,
+	}
+	if tokens.RefreshToken == "" {
+		tokens.RefreshToken = refreshToken
+	}
+	return claims, tokens, nil
+}
+
+// validateToken validates an ID token issued to this client and returns its
+// claims and nonce claim.
+func (s *OAuth2Service) validateToken(ctx context.Context, rawIDToken string) (*Claims, string, error) {
+	if s.verifyIDToken != nil {
+		return s.verifyIDToken(ctx, rawIDToken)
+	}
+	return s.oidcProvider().verify(ctx, rawIDToken, s.oauthConfig)
+}
+
+// verifyNonce checks the ID token's nonce claim against the nonce sent on the
+// authorize request, so a token issued for another login cannot be injected.
+func verifyNonce(expected, actual string) error {
+	if expected == "" || subtle.ConstantTimeCompare([]byte(expected), []byte(actual)) != 1 {
+		return fmt.Errorf("ID token nonce does not match the authorization request")
+	}
+	return nil
 }
 
 // createOAuth2Config creates an OAuth2 configuration
@@ -217,16 +367,22 @@ func (s *OAuth2Service) createOAuth2Config() (*oauth2.Config, error) {
 	}
 
 	// Initialize provider if not already done
-	provider, err := initOIDCProviderFromOAuthConfig(s.oauthConfig)
+	provider, err := s.oidcProvider().init(s.oauthConfig)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize OIDC provider: %w", err)
 	}
 
+	endpoint := provider.Endpoint()
+	if s.oauthConfig.ClientSecret == "" {
+		// Public client: send client_id in the body instead of Basic auth
+		endpoint.AuthStyle = oauth2.AuthStyleInParams
+	}
+
 	config := &oauth2.Config{
 		ClientID:     s.oauthConfig.ClientID,
 		ClientSecret: s.oauthConfig.ClientSecret,
 		RedirectURL:  s.oauthConfig.RedirectURI,
-		Endpoint:     provider.Endpoint(),
+		Endpoint:     endpoint,
 		Scopes:       s.oauthConfig.Scopes,
 	}
 
@@ -1,7 +1,14 @@
 package user
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
 	"time"
 
@@ -79,3 +86,195 @@ func TestGenerateAuthURL_WithoutLoginHint_OmitsParam(t *testing.T) {
 		t.Errorf("expected no login_hint param, got %q", got)
 	}
 }
+
+func TestGenerateAuthURL_PKCE_ChallengeMatchesVerifierInState(t *testing.T) {
+	repo := &EncryptedStateRepository{key: getOrGenerateStateKey()}
+	svc := &OAuth2Service{
+		stateRepo:           repo,
+		oauthConfig:         &OAuthConfig{ClientID: "test-client"},
+		oauth2ConfigFactory: fakeOAuth2ConfigFactory,
+	}
+
+	rawURL, err := svc.GenerateAuthURL("https://app.example.com/dashboard", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	query, _ := url.ParseQuery(rawURL[strings.Index(rawURL, "?")+1:])
+
+	if got := query.Get("code_challenge_method"); got != "S256" {
+		t.Errorf("expected code_challenge_method=S256, got %q", got)
+	}
+
+	payload, ok := repo.validatePayload(query.Get("state"))
+	if !ok {
+		t.Fatal("expected state to decrypt")
+	}
+	if payload.CodeVerifier == "" {
+		t.Fatal("expected code verifier in state payload")
+	}
+	sum := sha256.Sum256([]byte(payload.CodeVerifier))
+	if want := base64.RawURLEncoding.EncodeToString(sum[:]); query.Get("code_challenge") != want {
+		t.Errorf("expected code_challenge %q, got %q", want, query.Get("code_challenge"))
+	}
+	if strings.Contains(rawURL, payload.CodeVerifier) {
+		t.Error("authorization URL leaks the code verifier")
+	}
+}
+
+func TestGenerateAuthURL_NoPKCEWithoutEncryptedState(t *testing.T) {
+	svc := newTestOAuth2Service()
+
+	rawURL, err := svc.GenerateAuthURL("https://app.example.com/dashboard", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(rawURL, "code_challenge") {
+		t.Errorf("expected no code_challenge when the state cannot carry a verifier, got %s", rawURL)
+	}
+}
+
+// newTestTokenServer returns a token endpoint that issues idToken and
+// records the last token request form.
+func newTestTokenServer(t *testing.T, idToken string, form *url.Values) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		*form = r.PostForm
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token":"access","id_token":%q,"token_type":"Bearer","expires_in":3600}`, idToken)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// newTestCallbackService returns a public-client OAuth2Service using the
+// encrypted state repository. Its ID tokens carry *tokenNonce as the nonce claim.
+func newTestCallbackService(serverURL string, tokenNonce *string) (*OAuth2Service, *EncryptedStateRepository) {
+	repo := &EncryptedStateRepository{key: getOrGenerateStateKey()}
+	svc := &OAuth2Service{
+		stateRepo:   repo,
+		oauthConfig: &OAuthConfig{ClientID: "public-client"},
+		oauth2ConfigFactory: func() (*oauth2.Config, error) {
+			return &oauth2.Config{
+				ClientID: "public-client",
+				Endpoint: oauth2.Endpoint{
+					AuthURL:   serverURL + "/authorize",
+					TokenURL:  serverURL + "/token",
+					AuthStyle: oauth2.AuthStyleInParams,
+				},
+			}, nil
+		},
+		verifyIDToken: func(ctx context.Context, rawIDToken string) (*Claims, string, error) {
+			return &Claims{Email: "alice@example.com"}, *tokenNonce, nil
+		},
+	}
+	return svc, repo
+}
+
+// startTestLogin generates an authorization URL and returns its query.
+func startTestLogin(t *testing.T, svc *OAuth2Service) url.Values {
+	t.Helper()
+	rawURL, err := svc.GenerateAuthURL("https://app.example.com/dashboard", "")
+	if err != nil {
+		t.Fatalf("GenerateAuthURL: %v", err)
+	}
+	query, _ := url.ParseQuery(rawURL[strings.Index(rawURL, "?")+1:])
+	return query
+}
+
+func TestHandleCallback_PKCE_SendsVerifier(t *testing.T) {
+	var form url.Values
+	server := newTestTokenServer(t, "id-token", &form)
+
+	var tokenNonce string
+	svc, repo := newTestCallbackService(server.URL, &tokenNonce)
+	query := startTestLogin(t, svc)
+	payload, _ := repo.validatePayload(query.Get("state"))
+	tokenNonce = payload.Nonce
+
+	_, _, redirectURL, err := svc.HandleCallbackTokens("auth-code", query.Get("state"))
+	if err != nil {
+		t.Fatalf("HandleCallbackTokens: %v", err)
+	}
+	if redirectURL != "https://app.example.com/dashboard" {
+		t.Errorf("unexpected redirect URL %q", redirectURL)
+	}
+	if got := form.Get("code_verifier"); got == "" || got != payload.CodeVerifier {
+		t.Errorf("expected code_verifier %q, got %q", payload.CodeVerifier, got)
+	}
+	if form.Get("client_id") != "public-client" || form.Get("client_secret") != "" {
+		t.Errorf("expected public client credentials in body, got %v", form)
+	}
+}
+
+func TestGenerateAuthURL_SendsNonceFromState(t *testing.T) {
+	var tokenNonce string
+	svc, repo := newTestCallbackService("https://fake.example.com", &tokenNonce)
+	query := startTestLogin(t, svc)
+
+	payload, ok := repo.validatePayload(query.Get("state"))
+	if !ok {
+		t.Fatal("expected state to decrypt")
+	}
+	if got := query.Get("nonce"); got == "" || got != payload.Nonce {
+		t.Errorf("expected nonce %q on authorize URL, got %q", payload.Nonce, got)
+	}
+}
+
+func TestHandleCallback_RejectsNonceMismatch(t *testing.T) {
+	var form url.Values
+	server := newTestTokenServer(t, "id-token", &form)
+
+	for name, nonce := range map[string]string{
+		"nonce from another login": "some-other-nonce",
+		"missing nonce":            "",
+	} {
+		t.Run(name, func(t *testing.T) {
+			tokenNonce := nonce
+			svc, _ := newTestCallbackService(server.URL, &tokenNonce)
+			query := startTestLogin(t, svc)
+
+			if _, _, _, err := svc.HandleCallbackTokens("auth-code", query.Get("state")); err == nil || !strings.Contains(err.Error(), "nonce") {
+				t.Errorf("expected nonce mismatch error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateOAuthConfig_AllowsPublicClient(t *testing.T) {
+	err := validateOAuthConfig(&OAuthConfig{
+		ClientID:    "public-client",
+		UserPoolID:  "us-east-1_test",
+		Region:      "us-east-1",
+		RedirectURI: "https://localhost:3000/oauth2/idpresponse",
+		FrontEndURL: "https://localhost:8000",
+		Scopes:      []string{"openid"},
+	})
+	if err != nil {
+		t.Errorf("expected config without ClientSecret to be valid, got %v", err)
+	}
+}
+
+func TestCreateOAuth2Config_PublicClientSendsClientIDInBody(t *testing.T) {
+	issuer := fakeIssuerWithDiscovery(t, "")
+
+	for _, tt := range []struct {
+		secret string
+		want   oauth2.AuthStyle
+	}{
+		{secret: "", want: oauth2.AuthStyleInParams},
+		{secret: "shh", want: oauth2.AuthStyleAutoDetect},
+	} {
+		svc := &OAuth2Service{
+			oauthConfig: &OAuthConfig{ClientID: "client", ClientSecret: tt.secret, IssuerURL: issuer},
+			oidc:        &oidcProviderCache{},
+		}
+		config, err := svc.createOAuth2Config()
+		if err != nil {
+			t.Fatalf("createOAuth2Config: %v", err)
+		}
+		if config.Endpoint.AuthStyle != tt.want {
+			t.Errorf("secret %q: expected AuthStyle %v, got %v", tt.secret, tt.want, config.Endpoint.AuthStyle)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package user
 import (
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -20,8 +21,9 @@ const (
 
 // oauth2Servicer is the subset of OAuth2Service used by the HTTP handlers.
 type oauth2Servicer interface {
+	tokenRefresher
 	GenerateAuthURL(redirectURL, loginHint string) (string, error)
-	HandleCallback(code, state string) (*Claims, string, string, error)
+	HandleCallbackTokens(code, state string) (*Claims, *OAuthTokens, string, error)
 }
 
 // OAuth2Handlers provides HTTP handlers for OAuth2 flow
@@ -31,6 +33,7 @@ type OAuth2Handlers struct {
 	getFrontEndURL  func() string
 	getCookieDomain func() string
 	createJWTCookie func(token string, maxAge int, domain string) string
+	refreshTokens   RefreshTokenStore // nil disables refresh token storage
 }
 
 // NewOAuth2Handlers creates a new OAuth2 handlers instance
@@ -102,7 +105,7 @@ func (h *OAuth2Handlers) CallbackHandler(w http.ResponseWriter, r *http.Request)
 
 	log.Printf("🔄 [CallbackHandler] Calling HandleCallback with code and state...")
 	// Handle OAuth2 callback
-	claims, rawIDToken, redirectURL, err := h.oauth2Service.HandleCallback(code, state)
+	claims, tokens, redirectURL, err := h.oauth2Service.HandleCallbackTokens(code, state)
 	if err != nil {
 		log.Printf("❌ [CallbackHandler] OAuth2 callback failed: %v", err)
 		log.Printf("🔍 [CallbackHandler] Error type: %T", err)
@@ -143,6 +146,7 @@ func (h *OAuth2Handlers) CallbackHandler(w http.ResponseWriter, r *http.Request)
 
 	log.Printf("✅ [CallbackHandler] OAuth2 callback succeeded")
 	log.Printf("🔍 [CallbackHandler] Claims received - Email: %s, Username: %s, Sub: %s", claims.Email, claims.Username, claims.Sub)
+	rawIDToken := tokens.IDToken
 	log.Printf("🔍 [CallbackHandler] Raw ID Token length: %d", len(rawIDToken))
 	log.Printf("🔍 [CallbackHandler] Redirect URL from state: %s", redirectURL)
 
@@ -165,6 +169,15 @@ func (h *OAuth2Handlers) CallbackHandler(w http.ResponseWriter, r *http.Request)
 
 	// Set cookie and redirect
 	w.Header().Set("Set-Cookie", cookie)
+
+	// Keep the refresh token so the session outlives the ID token
+	if h.refreshTokens != nil {
+		if err := h.refreshTokens.SaveRefreshToken(w, r, claims, tokens.RefreshToken); err != nil {
+			log.Printf("⚠️ [CallbackHandler] Failed to save refresh token: %v", err)
+		} else {
+			log.Printf("✅ [CallbackHandler] Refresh token saved (issued: %v)", tokens.RefreshToken != "")
+		}
+	}
 	log.Printf("🔍 [CallbackHandler] Response headers before redirect: %+v", w.Header())
 
 	// Use the redirect URL from state, or default to dashboard
@@ -202,6 +215,11 @@ func (h *OAuth2Handlers) LogoutHandler(w http.ResponseWriter, r *http.Request) {
 
 	// Set the clear cookie header
 	w.Header().Set("Set-Cookie", clearCookie)
+	if h.refreshTokens != nil {
+		if err := h.refreshTokens.ClearRefreshToken(w, r); err != nil {
+			log.Printf("⚠️ Failed to clear refresh token: %v", err)
+		}
+	}
 
 	// If Cognito domain is configured, use Cognito logout URL
 	if h.oauthConfig.Domain != "" && h.oauthConfig.ClientID != "" {
@@ -223,6 +241,31 @@ func (h *OAuth2Handlers) LogoutHandler(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusFound)
 }
 
+// RefreshHandler renews the JWT cookie from the stored refresh token
+func (h *OAuth2Handlers) RefreshHandler(w http.ResponseWriter, r *http.Request) {
+	if h.refreshTokens == nil {
+		h.writeJSONError(w, http.StatusUnauthorized, "No refresh token")
+		return
+	}
+
+	claims, err := renewSession(w, r, h.oauth2Service, h.refreshTokens, h.createJWTCookie, h.getCookieDomain())
+	if err != nil {
+		log.Printf("❌ [RefreshHandler] Session refresh failed: %v", err)
+		if errors.Is(err, ErrSessionNotFound) {
+			h.writeJSONError(w, http.StatusUnauthorized, "No refresh token")
+			return
+		}
+		h.writeJSONError(w, http.StatusUnauthorized, "Failed to refresh session")
+		return
+	}
+
+	log.Printf("✅ [RefreshHandler] Session refreshed for %s", claims.Email)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"email":    claims.Email,
+		"username": claims.Username,
+	})
+}
+
 // Helper methods
 
 // writeJSONError writes a JSON error response
@@ -254,6 +297,12 @@ func (h *OAuth2Handlers) hasExceededRetryLimit(attempts int) bool {
 
 // extractJWTExpiration extracts the exp claim from a JWT token to set cookie maxAge
 func (h *OAuth2Handlers) extractJWTExpiration(tokenString string) int {
+	return jwtCookieMaxAge(tokenString)
+}
+
+// jwtCookieMaxAge returns the seconds until tokenString expires, or one hour
+// when the exp claim cannot be read
+func jwtCookieMaxAge(tokenString string) int {
 	// Simple JWT parsing to extract exp claim without full validation
 	parts := strings.Split(tokenString, ".")
 	if len(parts) != 3 {
@@ -1,6 +1,7 @@
 package user
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -26,6 +27,14 @@ func (m *mockOAuth2Servicer) HandleCallback(code, state string) (*Claims, string
 	return nil, "", "", nil
 }
 
+func (m *mockOAuth2Servicer) HandleCallbackTokens(code, state string) (*Claims, *OAuthTokens, string, error) {
+	return nil, nil, "", nil
+}
+
+func (m *mockOAuth2Servicer) RefreshTokens(ctx context.Context, refreshToken string) (*Claims, *OAuthTokens, error) {
+	return nil, nil, nil
+}
+
 func TestLoginHandler_ForwardsLoginHintFromQuery(t *testing.T) {
 	tests := []struct {
 		name          string
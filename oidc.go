@@ -10,13 +10,25 @@ import (
 	"github.com/coreos/go-oidc/v3/oidc"
 )
 
-var (
-	oidcProvider  *oidc.Provider
-	oidcVerifier  *oidc.IDTokenVerifier
-	providerMutex sync.Mutex
-)
+// oidcProviderCache holds the OIDC provider and ID token verifier discovered
+// for one OAuthConfig, plus the revocation list consulted after verification.
+// Each Manager owns its own cache; the package-level functions share
+// defaultOIDCProvider.
+type oidcProviderCache struct {
+	mu          sync.Mutex
+	provider    *oidc.Provider
+	verifier    *oidc.IDTokenVerifier
+	revocations RevocationList
+}
 
-// initOIDCProviderFromOAuthConfig initializes the OIDC provider with OAuthConfig.
+var defaultOIDCProvider = &oidcProviderCache{revocations: NewMemoryRevocationList()}
+
+// initOIDCProviderFromOAuthConfig initializes the default OIDC provider with OAuthConfig.
+func initOIDCProviderFromOAuthConfig(config *OAuthConfig) (*oidc.Provider, error) {
+	return defaultOIDCProvider.init(config)
+}
+
+// init initializes the OIDC provider with OAuthConfig.
 //
 // Issuer-URL resolution order:
 //  1. config.IssuerURL — used verbatim when set. This is the generic OIDC
@@ -30,12 +42,16 @@ var (
 // into config.LogoutURL so LogoutHandler can use it without operators
 // having to plumb a separate OIDC_LOGOUT_URL env var. An explicitly set
 // config.LogoutURL is left untouched so it remains a hard override.
-func initOIDCProviderFromOAuthConfig(config *OAuthConfig) (*oidc.Provider, error) {
-	providerMutex.Lock()
-	defer providerMutex.Unlock()
+func (c *oidcProviderCache) init(config *OAuthConfig) (*oidc.Provider, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	if oidcProvider != nil {
-		return oidcProvider, nil
+	if c.provider != nil {
+		return c.provider, nil
+	}
+
+	if config == nil {
+		return nil, fmt.Errorf("oauth config is not set")
 	}
 
 	if config.ClientID == "" {
@@ -56,13 +72,13 @@ func initOIDCProviderFromOAuthConfig(config *OAuthConfig) (*oidc.Provider, error
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	var err error
-	oidcProvider, err = oidc.NewProvider(ctx, issuerURL)
+	provider, err := oidc.NewProvider(ctx, issuerURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create OIDC provider: %w", err)
 	}
 
-	oidcVerifier = oidcProvider.Verifier(&oidc.Config{
+	c.provider = provider
+	c.verifier = provider.Verifier(&oidc.Config{
 		ClientID: config.ClientID,
 	})
 
@@ -70,59 +86,103 @@ func initOIDCProviderFromOAuthConfig(config *OAuthConfig) (*oidc.Provider, error
 		var meta struct {
 			EndSessionEndpoint string `json:"end_session_endpoint"`
 		}
-		if claimErr := oidcProvider.Claims(&meta); claimErr == nil && meta.EndSessionEndpoint != "" {
+		if claimErr := provider.Claims(&meta); claimErr == nil && meta.EndSessionEndpoint != "" {
 			config.LogoutURL = meta.EndSessionEndpoint
 			log.Printf("✅ OIDC RP-initiated logout endpoint auto-discovered: %s", meta.EndSessionEndpoint)
 		}
 	}
 
 	log.Printf("✅ OIDC provider initialized for issuer: %s", issuerURL)
-	return oidcProvider, nil
+	return provider, nil
+}
+
+// idTokenVerifier returns the verifier, initializing the provider on first use.
+func (c *oidcProviderCache) idTokenVerifier(config *OAuthConfig) (*oidc.IDTokenVerifier, error) {
+	if _, err := c.init(config); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.verifier, nil
+}
+
+func (c *oidcProviderCache) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.provider = nil
+	c.verifier = nil
 }
 
 // resetOIDCProviderForTesting clears the cached provider/verifier so tests
 // that initialize the provider against an httptest server can run without
 // leaking state across subtests. Not part of the public API.
 func resetOIDCProviderForTesting() {
-	providerMutex.Lock()
-	defer providerMutex.Unlock()
-	oidcProvider = nil
-	oidcVerifier = nil
+	defaultOIDCProvider.reset()
 }
 
 // ValidateOIDCTokenFromOAuthConfig validates an OIDC ID token using OAuthConfig and returns claims
 func ValidateOIDCTokenFromOAuthConfig(ctx context.Context, tokenString string, config *OAuthConfig) (*Claims, error) {
+	return defaultOIDCProvider.validate(ctx, tokenString, config)
+}
+
+// ValidateIDToken validates an OIDC ID token against the Manager's user pool
+// and returns the authenticated user's claims.
+func (m *Manager) ValidateIDToken(ctx context.Context, tokenString string) (*Claims, error) {
+	return m.oidc.validate(ctx, tokenString, m.Config())
+}
+
+// validate verifies an ID token against the cached provider and maps its claims.
+func (c *oidcProviderCache) validate(ctx context.Context, tokenString string, config *OAuthConfig) (*Claims, error) {
+	claims, _, err := c.verify(ctx, tokenString, config)
+	return claims, err
+}
+
+// verify is validate that also returns the token's nonce claim.
+func (c *oidcProviderCache) verify(ctx context.Context, tokenString string, config *OAuthConfig) (*Claims, string, error) {
 	if tokenString == "" {
-		return nil, fmt.Errorf("empty token")
+		return nil, "", fmt.Errorf("empty token")
 	}
 
-	// Initialize verifier if not already done
-	if oidcVerifier == nil {
-		_, err := initOIDCProviderFromOAuthConfig(config)
-		if err != nil {
-			return nil, fmt.Errorf("failed to initialize OIDC provider: %w", err)
-		}
+	verifier, err := c.idTokenVerifier(config)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to initialize OIDC provider: %w", err)
 	}
 
 	// Verify the ID token
-	idToken, err := oidcVerifier.Verify(ctx, tokenString)
+	idToken, err := verifier.Verify(ctx, tokenString)
 	if err != nil {
-		return nil, fmt.Errorf("failed to verify ID token: %w", err)
+		return nil, "", fmt.Errorf("failed to verify ID token: %w", err)
 	}
 
 	// Extract claims into temporary OIDC structure for JWT parsing
 	var oidcClaims OIDCClaims
 	if err := idToken.Claims(&oidcClaims); err != nil {
-		return nil, fmt.Errorf("failed to extract claims: %w", err)
+		return nil, "", fmt.Errorf("failed to extract claims: %w", err)
+	}
+
+	if err := c.checkRevoked(ctx, oidcClaims.Sub, idToken.IssuedAt); err != nil {
+		return nil, "", err
 	}
 
-	// Calculate role using the provided function, or default to "user"
-	defaultRole := "user"
+	// A configured CalculateDefaultRole decides the role. Without one, the role
+	// attribute is used; its name is configurable, so it is read from the raw
+	// claims.
+	var role string
 	if config.CalculateDefaultRole != nil {
-		defaultRole = config.CalculateDefaultRole(&oidcClaims)
+		role = config.CalculateDefaultRole(&oidcClaims)
+	} else {
+		var rawClaims map[string]any
+		if err := idToken.Claims(&rawClaims); err != nil {
+			return nil, "", fmt.Errorf("failed to extract claims: %w", err)
+		}
+		role, _ = rawClaims[roleAttributeName(config)].(string)
+	}
+	if role == "" {
+		role = "user"
 	}
 
-	return oidcClaimsToClaims(&oidcClaims, defaultRole), nil
+	return oidcClaimsToClaims(&oidcClaims, role), idToken.Nonce, nil
 }
 
 func oidcClaimsToClaims(oidcClaims *OIDCClaims, role string) *Claims {
@@ -4,6 +4,8 @@ import (
 	"crypto/rand"
 	"fmt"
 	"math/big"
+	"strings"
+	"unicode/utf8"
 )
 
 const (
@@ -16,49 +18,83 @@ const (
 	maxPasswordRetries = 5
 )
 
-func validateCognitoPassword(pw string) error {
-	if len(pw) < minPasswordLen {
-		return fmt.Errorf("password must be at least %d characters", minPasswordLen)
+// cognitoSymbolChars are the characters Cognito counts as symbols.
+const cognitoSymbolChars = "^$*.[]{}()?\"!@#%&/\\,><':;|_~`=+- "
+
+// PasswordPolicy is the set of rules passwords must meet, mirroring a Cognito
+// user pool's password policy.
+type PasswordPolicy struct {
+	MinimumLength    int  `json:"minimumLength"`
+	RequireLowercase bool `json:"requireLowercase"`
+	RequireUppercase bool `json:"requireUppercase"`
+	RequireNumbers   bool `json:"requireNumbers"`
+	RequireSymbols   bool `json:"requireSymbols"`
+}
+
+// DefaultPasswordPolicy is used when neither OAuthConfig nor the user pool
+// provides a policy: 12 characters with all four character classes.
+func DefaultPasswordPolicy() PasswordPolicy {
+	return PasswordPolicy{
+		MinimumLength:    minPasswordLen,
+		RequireLowercase: true,
+		RequireUppercase: true,
+		RequireNumbers:   true,
+		RequireSymbols:   true,
+	}
+}
+
+// Validate returns a *ValidationError listing every rule password breaks,
+// or nil when it meets the policy.
+func (p PasswordPolicy) Validate(password string) error {
+	var fields []FieldError
+	fail := func(message string) {
+		fields = append(fields, FieldError{Field: "password", Message: message})
+	}
+
+	if n := utf8.RuneCountInString(password); n < p.MinimumLength {
+		fail(fmt.Sprintf("must be at least %d characters", p.MinimumLength))
 	}
-	if !passwordContainsFromSet(pw, lowercaseChars) {
-		return fmt.Errorf("password must contain a lowercase letter")
+	if p.RequireLowercase && !strings.ContainsAny(password, lowercaseChars) {
+		fail("must contain a lowercase letter")
 	}
-	if !passwordContainsFromSet(pw, uppercaseChars) {
-		return fmt.Errorf("password must contain an uppercase letter")
+	if p.RequireUppercase && !strings.ContainsAny(password, uppercaseChars) {
+		fail("must contain an uppercase letter")
 	}
-	if !passwordContainsFromSet(pw, digitChars) {
-		return fmt.Errorf("password must contain a digit")
+	if p.RequireNumbers && !strings.ContainsAny(password, digitChars) {
+		fail("must contain a number")
 	}
-	if !passwordContainsFromSet(pw, specialChars) {
-		return fmt.Errorf("password must contain a special character")
+	if p.RequireSymbols && !strings.ContainsAny(password, cognitoSymbolChars) {
+		fail("must contain a special character")
+	}
+
+	if len(fields) > 0 {
+		return &ValidationError{Fields: fields}
 	}
 	return nil
 }
 
-func passwordContainsFromSet(pw, set string) bool {
-	for i := 0; i < len(pw); i++ {
-		if containsChar(set, pw[i]) {
-			return true
-		}
+// generateTemporaryPassword returns a random password meeting policy. It
+// always has every character class and at least minPasswordLen characters.
+func generateTemporaryPassword(policy PasswordPolicy) (string, error) {
+	length := minPasswordLen
+	if policy.MinimumLength > length {
+		length = policy.MinimumLength
 	}
-	return false
-}
 
-func generateSecureTemporaryPassword() (string, error) {
 	for attempt := 0; attempt < maxPasswordRetries; attempt++ {
-		pw, err := buildSecureTemporaryPassword()
+		pw, err := buildSecureTemporaryPassword(length)
 		if err != nil {
 			return "", err
 		}
-		if err := validateCognitoPassword(pw); err == nil {
+		if err := policy.Validate(pw); err == nil {
 			return pw, nil
 		}
 	}
 	return "", fmt.Errorf("failed to generate valid temporary password after %d attempts", maxPasswordRetries)
 }
 
-func buildSecureTemporaryPassword() (string, error) {
-	slots := make([]byte, minPasswordLen)
+func buildSecureTemporaryPassword(length int) (string, error) {
+	slots := make([]byte, length)
 
 	lower, err := randomCharFrom(lowercaseChars)
 	if err != nil {
@@ -84,7 +120,7 @@ func buildSecureTemporaryPassword() (string, error) {
 	}
 	slots[3] = special
 
-	for i := 4; i < minPasswordLen; i++ {
+	for i := 4; i < length; i++ {
 		ch, err := randomCharFrom(allChars)
 		if err != nil {
 			return "", fmt.Errorf("failed to generate random character: %w", err)
@@ -107,15 +143,6 @@ func randomCharFrom(chars string) (byte, error) {
 	return chars[index.Int64()], nil
 }
 
-func containsChar(chars string, char byte) bool {
-	for i := 0; i < len(chars); i++ {
-		if chars[i] == char {
-			return true
-		}
-	}
-	return false
-}
-
 func shufflePassword(password []byte) error {
 	for i := len(password) - 1; i > 0; i-- {
 		j, err := rand.Int(rand.Reader, big.NewInt(int64(i+1)))
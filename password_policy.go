@@ -0,0 +1,95 @@
+package user
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// passwordPolicyCacheTTL is how long a policy read from the user store is
+// reused before it is read again; passwordPolicyRetryInterval is how long a
+// failed read is remembered before the store is asked again.
+const (
+	passwordPolicyCacheTTL      = time.Hour
+	passwordPolicyRetryInterval = time.Minute
+)
+
+// PasswordPolicyStore is an optional UserStore extension for backends that
+// enforce a password policy of their own. The Cognito store implements it
+// with DescribeUserPool.
+type PasswordPolicyStore interface {
+	// PasswordPolicy returns the backend's policy, or nil when it has none.
+	PasswordPolicy(ctx context.Context) (*PasswordPolicy, error)
+}
+
+// passwordPolicyCache holds the last policy read for one user pool.
+type passwordPolicyCache struct {
+	mu        sync.Mutex
+	poolID    string
+	policy    PasswordPolicy
+	loaded    bool // policy was read from the store rather than defaulted
+	expiresAt time.Time
+}
+
+// GetPasswordPolicy returns the policy new passwords must meet:
+// OAuthConfig.PasswordPolicy when set, otherwise the user store's (for
+// Cognito, the pool's policy, read once an hour), otherwise
+// DefaultPasswordPolicy. When the store fails to report its policy, the
+// error is logged, the last policy read (or the default) is used and the
+// store is asked again after a minute.
+func (m *Manager) GetPasswordPolicy(ctx context.Context) PasswordPolicy {
+	var poolID string
+	if config := m.Config(); config != nil {
+		if config.PasswordPolicy != nil {
+			return *config.PasswordPolicy
+		}
+		poolID = config.UserPoolID
+	}
+
+	store, err := m.userStore()
+	if err != nil {
+		return DefaultPasswordPolicy()
+	}
+	policyStore, ok := store.(PasswordPolicyStore)
+	if !ok {
+		return DefaultPasswordPolicy()
+	}
+	return m.policies.get(ctx, poolID, policyStore)
+}
+
+// get returns the cached policy for poolID, reading it from store when it
+// is missing or stale. The lock is not held during the read, so a slow user
+// pool does not block callers that have a cached policy; concurrent misses
+// may each read it.
+func (c *passwordPolicyCache) get(ctx context.Context, poolID string, store PasswordPolicyStore) PasswordPolicy {
+	c.mu.Lock()
+	if c.poolID == poolID && time.Now().Before(c.expiresAt) {
+		policy := c.policy
+		c.mu.Unlock()
+		return policy
+	}
+	c.mu.Unlock()
+
+	loaded, err := store.PasswordPolicy(ctx)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err != nil {
+		// Keep a policy read earlier over the default, and retry soon
+		if c.poolID != poolID || !c.loaded {
+			c.poolID, c.policy, c.loaded = poolID, DefaultPasswordPolicy(), false
+		}
+		c.expiresAt = time.Now().Add(passwordPolicyRetryInterval)
+		log.Printf("⚠️ Failed to read the password policy of %s, retrying in %v: %v", poolID, passwordPolicyRetryInterval, err)
+		return c.policy
+	}
+
+	policy := DefaultPasswordPolicy()
+	if loaded != nil {
+		policy = *loaded
+	}
+	c.poolID, c.policy, c.loaded, c.expiresAt = poolID, policy, true, time.Now().Add(passwordPolicyCacheTTL)
+	return policy
+}
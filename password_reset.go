@@ -0,0 +1,219 @@
+package user
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultPasswordResetCodeTTL = 15 * time.Minute
+	maxPasswordResetAttempts    = 5 // Wrong codes before a pending reset is dropped
+)
+
+// PasswordResetCode is a pending self-service password reset.
+type PasswordResetCode struct {
+	CodeHash  string    `json:"codeHash"` // Hex SHA-256 of the emailed code
+	ExpiresAt time.Time `json:"expiresAt"`
+	Attempts  int       `json:"attempts"` // Codes tried so far
+}
+
+// PasswordResetStore keeps pending password resets by email address.
+type PasswordResetStore interface {
+	// SaveResetCode stores code for email, replacing any pending one.
+	SaveResetCode(ctx context.Context, email string, code PasswordResetCode) error
+	// GetResetCode returns the pending code, or an error wrapping
+	// ErrInvalidResetCode when there is none.
+	GetResetCode(ctx context.Context, email string) (*PasswordResetCode, error)
+	// DeleteResetCode forgets the pending code, if any.
+	DeleteResetCode(ctx context.Context, email string) error
+	// IncrementAttempts counts a try at the pending code and returns the
+	// updated code, checking and incrementing in one atomic step so
+	// concurrent tries cannot exceed maxAttempts. When there is no pending
+	// code, it has expired or maxAttempts tries were already made, the code
+	// is forgotten and an error wrapping ErrInvalidResetCode is returned.
+	IncrementAttempts(ctx context.Context, email string, maxAttempts int) (*PasswordResetCode, error)
+}
+
+// MemoryPasswordResetStore is an in-process PasswordResetStore. Codes are
+// lost on restart and are not shared between instances; deployments with
+// several instances should provide a shared implementation.
+type MemoryPasswordResetStore struct {
+	mu    sync.Mutex
+	codes map[string]PasswordResetCode
+}
+
+// NewMemoryPasswordResetStore creates an empty in-memory reset store.
+func NewMemoryPasswordResetStore() *MemoryPasswordResetStore {
+	return &MemoryPasswordResetStore{codes: make(map[string]PasswordResetCode)}
+}
+
+func (s *MemoryPasswordResetStore) SaveResetCode(ctx context.Context, email string, code PasswordResetCode) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.codes[email] = code
+	return nil
+}
+
+func (s *MemoryPasswordResetStore) GetResetCode(ctx context.Context, email string) (*PasswordResetCode, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	code, ok := s.codes[email]
+	if !ok {
+		return nil, fmt.Errorf("no pending reset for %s: %w", email, ErrInvalidResetCode)
+	}
+	return &code, nil
+}
+
+func (s *MemoryPasswordResetStore) DeleteResetCode(ctx context.Context, email string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.codes, email)
+	return nil
+}
+
+func (s *MemoryPasswordResetStore) IncrementAttempts(ctx context.Context, email string, maxAttempts int) (*PasswordResetCode, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	code, ok := s.codes[email]
+	if !ok {
+		return nil, fmt.Errorf("no pending reset for %s: %w", email, ErrInvalidResetCode)
+	}
+	if time.Now().After(code.ExpiresAt) || code.Attempts >= maxAttempts {
+		delete(s.codes, email)
+		return nil, fmt.Errorf("reset code for %s expired: %w", email, ErrInvalidResetCode)
+	}
+	code.Attempts++
+	s.codes[email] = code
+	return &code, nil
+}
+
+// WithPasswordResetStore sets where pending password resets are kept.
+// Defaults to a MemoryPasswordResetStore.
+func WithPasswordResetStore(store PasswordResetStore) ManagerOption {
+	return func(m *Manager) {
+		m.resets = store
+	}
+}
+
+// SetPasswordResetStore sets the reset store of the default Manager. See
+// WithPasswordResetStore.
+func SetPasswordResetStore(store PasswordResetStore) {
+	defaultManager.resets = store
+}
+
+// ForgotPassword starts a self-service password reset: it emails the user a
+// 6-digit code with SendPasswordResetEmail, to be passed to
+// ConfirmForgotPassword. Unknown and disabled users get no email but no
+// error either, so callers cannot learn which addresses have accounts.
+func (m *Manager) ForgotPassword(ctx context.Context, email string) error {
+	if email == "" {
+		return fmt.Errorf("email cannot be empty: %w", ErrInvalidInput)
+	}
+
+	user, err := m.GetUser(ctx, email)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			log.Printf("🔍 Password reset requested for unknown user %s", email)
+			return nil
+		}
+		return err
+	}
+	if !user.Enabled {
+		log.Printf("🔍 Password reset requested for disabled user %s", email)
+		return nil
+	}
+
+	code, err := generatePasswordResetCode()
+	if err != nil {
+		return fmt.Errorf("failed to generate reset code: %w", err)
+	}
+	ttl := passwordResetCodeTTL(m.Config())
+	err = m.resets.SaveResetCode(ctx, resetKey(email), PasswordResetCode{
+		CodeHash:  hashResetCode(code),
+		ExpiresAt: time.Now().Add(ttl),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save reset code: %w", err)
+	}
+
+	if err := m.SendPasswordResetEmail(ctx, PasswordResetEmailRequest{Email: user.Email, Code: code, ExpiresIn: ttl}); err != nil {
+		return err
+	}
+
+	log.Printf("✅ Password reset code sent to %s", email)
+	return nil
+}
+
+// ConfirmForgotPassword completes a reset started by ForgotPassword. The new
+// password is checked against the password policy first, returning a
+// ValidationError, so a weak password does not use up an attempt. A wrong,
+// expired or unknown code returns ErrInvalidResetCode; after 5 tries the
+// reset must be started again.
+func (m *Manager) ConfirmForgotPassword(ctx context.Context, email, code, newPassword string) error {
+	if email == "" || code == "" {
+		return fmt.Errorf("email and code cannot be empty: %w", ErrInvalidInput)
+	}
+	if err := m.GetPasswordPolicy(ctx).Validate(newPassword); err != nil {
+		return err
+	}
+
+	// The attempt is counted before the code is compared, so concurrent
+	// guesses cannot outrun the limit
+	key := resetKey(email)
+	pending, err := m.resets.IncrementAttempts(ctx, key, maxPasswordResetAttempts)
+	if err != nil {
+		return err
+	}
+	if subtle.ConstantTimeCompare([]byte(hashResetCode(code)), []byte(pending.CodeHash)) != 1 {
+		if pending.Attempts >= maxPasswordResetAttempts {
+			m.resets.DeleteResetCode(ctx, key)
+		}
+		return fmt.Errorf("wrong reset code for %s: %w", email, ErrInvalidResetCode)
+	}
+
+	if err := m.SetUserPassword(ctx, email, newPassword, true); err != nil {
+		return err
+	}
+	if err := m.resets.DeleteResetCode(ctx, key); err != nil {
+		log.Printf("⚠️ Failed to delete used reset code for %s: %v", email, err)
+	}
+
+	log.Printf("✅ Password reset for %s", email)
+	return nil
+}
+
+// passwordResetCodeTTL returns the configured reset code lifetime.
+func passwordResetCodeTTL(config *OAuthConfig) time.Duration {
+	if config != nil && config.PasswordResetCodeTTLSeconds > 0 {
+		return time.Duration(config.PasswordResetCodeTTLSeconds) * time.Second
+	}
+	return defaultPasswordResetCodeTTL
+}
+
+// resetKey is the store key for email; Cognito usernames are case-insensitive.
+func resetKey(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+func generatePasswordResetCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+func hashResetCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,223 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+var resetCodePattern = regexp.MustCompile(`\b[0-9]{6}\b`)
+
+func newPasswordResetTestManager(t *testing.T, config *OAuthConfig) (*Manager, *MemoryUserStore, *mockSESClient) {
+	t.Helper()
+	store := NewMemoryUserStore()
+	ses := &mockSESClient{}
+	config.FromEmail = "noreply@example.com"
+	config.AppName = "Acme"
+	m := newTestManager(t, config, store, []CreateUserRequest{{Email: "alice@example.com"}}, WithSESClient(ses))
+	return m, store, ses
+}
+
+// lastResetCode returns the code in the most recent email.
+func lastResetCode(t *testing.T, ses *mockSESClient) string {
+	t.Helper()
+	if len(ses.bodies) == 0 {
+		t.Fatal("expected a password reset email")
+	}
+	code := resetCodePattern.FindString(ses.bodies[len(ses.bodies)-1])
+	if code == "" {
+		t.Fatalf("no code in email body %q", ses.bodies[len(ses.bodies)-1])
+	}
+	return code
+}
+
+func TestForgotPassword_ResetsWithEmailedCode(t *testing.T) {
+	m, store, ses := newPasswordResetTestManager(t, &OAuthConfig{PasswordResetURL: "https://app.example.com/reset"})
+	ctx := context.Background()
+
+	if err := m.ForgotPassword(ctx, "alice@example.com"); err != nil {
+		t.Fatalf("ForgotPassword: %v", err)
+	}
+	if len(ses.sent) != 1 || ses.sent[0] != "alice@example.com" {
+		t.Fatalf("expected one email to alice, got %v", ses.sent)
+	}
+	body := ses.bodies[0]
+	if !strings.Contains(body, "https://app.example.com/reset") || !strings.Contains(body, "15 minutes") {
+		t.Errorf("expected the reset URL and expiry in the email, got %q", body)
+	}
+	code := lastResetCode(t, ses)
+
+	var validationErr *ValidationError
+	if err := m.ConfirmForgotPassword(ctx, "alice@example.com", code, "weak"); !errors.As(err, &validationErr) || validationErr.Fields[0].Field != "password" {
+		t.Errorf("expected a password ValidationError, got %v", err)
+	}
+	wrong := "000000"
+	if code == wrong {
+		wrong = "111111"
+	}
+	if err := m.ConfirmForgotPassword(ctx, "alice@example.com", wrong, "N3w-Passw0rd!"); !errors.Is(err, ErrInvalidResetCode) {
+		t.Errorf("expected ErrInvalidResetCode for a wrong code, got %v", err)
+	}
+
+	if err := m.ConfirmForgotPassword(ctx, "alice@example.com", code, "N3w-Passw0rd!"); err != nil {
+		t.Fatalf("ConfirmForgotPassword: %v", err)
+	}
+	if pw, _ := store.Password("alice@example.com"); pw != "N3w-Passw0rd!" {
+		t.Errorf("expected the new password to be set, got %q", pw)
+	}
+	if record, _ := store.GetUser(ctx, "alice@example.com"); record.Status != "CONFIRMED" {
+		t.Errorf("expected a permanent password, got status %s", record.Status)
+	}
+
+	if err := m.ConfirmForgotPassword(ctx, "alice@example.com", code, "An0ther-Passw0rd!"); !errors.Is(err, ErrInvalidResetCode) {
+		t.Errorf("expected a used code to be rejected, got %v", err)
+	}
+}
+
+func TestForgotPassword_NoEmailForUnknownOrDisabledUsers(t *testing.T) {
+	m, _, ses := newPasswordResetTestManager(t, &OAuthConfig{})
+	ctx := context.Background()
+
+	if err := m.ForgotPassword(ctx, "nobody@example.com"); err != nil {
+		t.Errorf("expected no error for an unknown user, got %v", err)
+	}
+	if err := m.DisableUser(ctx, "alice@example.com"); err != nil {
+		t.Fatalf("DisableUser: %v", err)
+	}
+	if err := m.ForgotPassword(ctx, "alice@example.com"); err != nil {
+		t.Errorf("expected no error for a disabled user, got %v", err)
+	}
+	if len(ses.sent) != 0 {
+		t.Errorf("expected no emails, got %v", ses.sent)
+	}
+}
+
+func TestConfirmForgotPassword_AttemptLimitAndExpiry(t *testing.T) {
+	m, _, ses := newPasswordResetTestManager(t, &OAuthConfig{})
+	ctx := context.Background()
+
+	if err := m.ForgotPassword(ctx, "alice@example.com"); err != nil {
+		t.Fatalf("ForgotPassword: %v", err)
+	}
+	code := lastResetCode(t, ses)
+	wrong := "000000"
+	if code == wrong {
+		wrong = "111111"
+	}
+	for i := 0; i < maxPasswordResetAttempts; i++ {
+		m.ConfirmForgotPassword(ctx, "alice@example.com", wrong, "N3w-Passw0rd!")
+	}
+	if err := m.ConfirmForgotPassword(ctx, "alice@example.com", code, "N3w-Passw0rd!"); !errors.Is(err, ErrInvalidResetCode) {
+		t.Errorf("expected the code to be dropped after %d wrong attempts, got %v", maxPasswordResetAttempts, err)
+	}
+
+	m.resets.SaveResetCode(ctx, "alice@example.com", PasswordResetCode{
+		CodeHash:  hashResetCode("123456"),
+		ExpiresAt: time.Now().Add(-time.Second),
+	})
+	if err := m.ConfirmForgotPassword(ctx, "alice@example.com", "123456", "N3w-Passw0rd!"); !errors.Is(err, ErrInvalidResetCode) {
+		t.Errorf("expected an expired code to be rejected, got %v", err)
+	}
+}
+
+func TestPasswordResetRoutes(t *testing.T) {
+	m, store, ses := newPasswordResetTestManager(t, &OAuthConfig{PasswordResetRateLimit: 2})
+	r := chi.NewRouter()
+	m.SetupPasswordResetRoutes(r)
+
+	remoteAddr := "192.0.2.1:1234"
+	post := func(path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.RemoteAddr = remoteAddr
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := post("/api/auth/forgot-password", `{"email":"nobody@example.com"}`); w.Code != http.StatusAccepted {
+		t.Errorf("expected 202 for an unknown user, got %d", w.Code)
+	}
+	if w := post("/api/auth/forgot-password", `{"email":"alice@example.com"}`); w.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d", w.Code)
+	}
+	w := post("/api/auth/forgot-password", `{"email":"alice@example.com"}`)
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+		t.Errorf("expected 429 with Retry-After over the limit, got %d %v", w.Code, w.Header())
+	}
+
+	code := lastResetCode(t, ses)
+	if w := post("/api/auth/forgot-password/confirm", `{"email":"alice@example.com","code":"12345x","newPassword":"N3w-Passw0rd!"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a wrong code, got %d", w.Code)
+	}
+	if w := post("/api/auth/forgot-password/confirm", `{"email":"alice@example.com","code":"`+code+`","newPassword":"N3w-Passw0rd!"}`); w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d: %s", w.Code, w.Body)
+	}
+	if pw, _ := store.Password("alice@example.com"); pw != "N3w-Passw0rd!" {
+		t.Errorf("expected the new password to be set, got %q", pw)
+	}
+
+	// Guesses for one email are limited across client IPs too
+	remoteAddr = "192.0.2.2:1234"
+	if w := post("/api/auth/forgot-password/confirm", `{"email":"alice@example.com","code":"000000","newPassword":"N3w-Passw0rd!"}`); w.Code != http.StatusTooManyRequests {
+		t.Errorf("expected 429 over the per-email limit, got %d", w.Code)
+	}
+}
+
+func TestMemoryPasswordResetStore_IncrementAttemptsIsAtomic(t *testing.T) {
+	store := NewMemoryPasswordResetStore()
+	ctx := context.Background()
+	store.SaveResetCode(ctx, "alice@example.com", PasswordResetCode{CodeHash: hashResetCode("123456"), ExpiresAt: time.Now().Add(time.Minute)})
+
+	var wg sync.WaitGroup
+	var allowed atomic.Int32
+	for i := 0; i < 4*maxPasswordResetAttempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := store.IncrementAttempts(ctx, "alice@example.com", maxPasswordResetAttempts); err == nil {
+				allowed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := allowed.Load(); got != maxPasswordResetAttempts {
+		t.Errorf("expected %d tries to be allowed, got %d", maxPasswordResetAttempts, got)
+	}
+	if _, err := store.GetResetCode(ctx, "alice@example.com"); !errors.Is(err, ErrInvalidResetCode) {
+		t.Errorf("expected the code to be dropped once the tries ran out, got %v", err)
+	}
+}
+
+func TestRateLimiter_WindowResets(t *testing.T) {
+	now := time.Now()
+	limiter := newRateLimiter(2, time.Minute)
+	limiter.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		if ok, _ := limiter.allow("k"); !ok {
+			t.Fatalf("expected event %d to be allowed", i+1)
+		}
+	}
+	ok, retryAfter := limiter.allow("k")
+	if ok || retryAfter != time.Minute {
+		t.Errorf("expected the third event to wait a minute, got %v %v", ok, retryAfter)
+	}
+	if ok, _ := limiter.allow("other"); !ok {
+		t.Error("expected other keys to have their own limit")
+	}
+
+	now = now.Add(time.Minute)
+	if ok, _ := limiter.allow("k"); !ok {
+		t.Error("expected the limit to reset with the window")
+	}
+}
@@ -1,27 +1,35 @@
 package user
 
 import (
+	"context"
+	"errors"
 	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider/types"
 )
 
 func TestGenerateSecureTemporaryPassword_PolicyCompliance(t *testing.T) {
 	const iterations = 10_000
 	for i := 0; i < iterations; i++ {
-		pw, err := generateSecureTemporaryPassword()
+		pw, err := generateTemporaryPassword(DefaultPasswordPolicy())
 		if err != nil {
-			t.Fatalf("iteration %d: generateSecureTemporaryPassword: %v", i, err)
+			t.Fatalf("iteration %d: generateTemporaryPassword: %v", i, err)
 		}
-		if err := validateCognitoPassword(pw); err != nil {
+		if err := DefaultPasswordPolicy().Validate(pw); err != nil {
 			t.Fatalf("iteration %d: password %q: %v", i, pw, err)
 		}
 	}
 }
 
 func TestGenerateSecureTemporaryPassword_MinLength(t *testing.T) {
-	pw, err := generateSecureTemporaryPassword()
+	pw, err := generateTemporaryPassword(DefaultPasswordPolicy())
 	if err != nil {
-		t.Fatalf("generateSecureTemporaryPassword: %v", err)
+		t.Fatalf("generateTemporaryPassword: %v", err)
 	}
 	if len(pw) < minPasswordLen {
 		t.Fatalf("password length %d < min %d", len(pw), minPasswordLen)
@@ -35,14 +43,164 @@ func TestValidateCognitoPassword_DetectsMissingDigit(t *testing.T) {
 	if strings.ContainsAny(pw, digitChars) {
 		t.Fatal("test fixture must not contain a digit")
 	}
-	if err := validateCognitoPassword(pw); err == nil {
+	if err := DefaultPasswordPolicy().Validate(pw); err == nil {
 		t.Fatalf("expected policy error for password missing digit, got nil for %q", pw)
 	}
 }
 
 func TestValidateCognitoPassword_AcceptsCompliantPassword(t *testing.T) {
 	pw := "aB3!xxxxxxxx"
-	if err := validateCognitoPassword(pw); err != nil {
+	if err := DefaultPasswordPolicy().Validate(pw); err != nil {
 		t.Fatalf("expected nil for compliant password, got %v", err)
 	}
 }
+
+func TestPasswordPolicy_ValidateListsEveryViolation(t *testing.T) {
+	err := DefaultPasswordPolicy().Validate("abc")
+
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected a ValidationError, got %v", err)
+	}
+	want := []string{
+		"must be at least 12 characters",
+		"must contain an uppercase letter",
+		"must contain a number",
+		"must contain a special character",
+	}
+	if len(validationErr.Fields) != len(want) {
+		t.Fatalf("expected %d violations, got %v", len(want), validationErr.Fields)
+	}
+	for i, f := range validationErr.Fields {
+		if f.Field != "password" || f.Message != want[i] {
+			t.Errorf("violation %d: got %s %q, want password %q", i, f.Field, f.Message, want[i])
+		}
+	}
+
+	lax := PasswordPolicy{MinimumLength: 6}
+	if err := lax.Validate("abcdef"); err != nil {
+		t.Errorf("expected a lax policy to accept %q, got %v", "abcdef", err)
+	}
+}
+
+func TestGenerateTemporaryPassword_HonorsPolicy(t *testing.T) {
+	policy := PasswordPolicy{MinimumLength: 24, RequireLowercase: true, RequireUppercase: true, RequireNumbers: true, RequireSymbols: true}
+	for i := 0; i < 1000; i++ {
+		pw, err := generateTemporaryPassword(policy)
+		if err != nil {
+			t.Fatalf("iteration %d: generateTemporaryPassword: %v", i, err)
+		}
+		if err := policy.Validate(pw); err != nil {
+			t.Fatalf("iteration %d: password %q: %v", i, pw, err)
+		}
+	}
+}
+
+type mockPasswordPolicyCognitoClient struct {
+	mockUserMgmtCognitoClient
+	policy        *types.PasswordPolicyType
+	describeCalls int
+}
+
+func (m *mockPasswordPolicyCognitoClient) DescribeUserPool(_ context.Context, _ *cognitoidentityprovider.DescribeUserPoolInput, _ ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.DescribeUserPoolOutput, error) {
+	m.describeCalls++
+	return &cognitoidentityprovider.DescribeUserPoolOutput{
+		UserPool: &types.UserPoolType{Policies: &types.UserPoolPolicyType{PasswordPolicy: m.policy}},
+	}, nil
+}
+
+func TestGetPasswordPolicy_ReadsUserPool(t *testing.T) {
+	client := &mockPasswordPolicyCognitoClient{policy: &types.PasswordPolicyType{
+		MinimumLength:  aws.Int32(16),
+		RequireNumbers: true,
+	}}
+	config := &OAuthConfig{UserPoolID: "us-east-1_test", Region: "us-east-1"}
+	m := NewManager(config, WithCognitoClient(client))
+	ctx := context.Background()
+
+	want := PasswordPolicy{MinimumLength: 16, RequireNumbers: true}
+	for i := 0; i < 3; i++ {
+		if got := m.GetPasswordPolicy(ctx); got != want {
+			t.Fatalf("expected %+v, got %+v", want, got)
+		}
+	}
+	if client.describeCalls != 1 {
+		t.Errorf("expected the policy to be cached, got %d DescribeUserPool calls", client.describeCalls)
+	}
+
+	err := m.SetUserPassword(ctx, "test@example.com", "Short1!", true)
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || validationErr.Fields[0].Message != "must be at least 16 characters" {
+		t.Errorf("expected the pool's minimum length to be enforced, got %v", err)
+	}
+	if client.setPasswordCalled {
+		t.Error("expected AdminSetUserPassword not to be called for an invalid password")
+	}
+	if err := m.SetUserPassword(ctx, "test@example.com", "longenoughpassword1", true); err != nil {
+		t.Errorf("expected a password meeting the pool's policy to be set, got %v", err)
+	}
+
+	config.PasswordPolicy = &PasswordPolicy{MinimumLength: 8}
+	if got := m.GetPasswordPolicy(ctx); got != *config.PasswordPolicy {
+		t.Errorf("expected the configured policy to win, got %+v", got)
+	}
+}
+
+// blockingPolicyStore fails its first read, blocks its second until release
+// is closed, and answers later reads with policy.
+type blockingPolicyStore struct {
+	mu      sync.Mutex
+	calls   int
+	release chan struct{}
+	policy  PasswordPolicy
+}
+
+func (s *blockingPolicyStore) PasswordPolicy(ctx context.Context) (*PasswordPolicy, error) {
+	s.mu.Lock()
+	s.calls++
+	call := s.calls
+	s.mu.Unlock()
+
+	switch call {
+	case 1:
+		return nil, errors.New("ThrottlingException: Rate exceeded")
+	case 2:
+		<-s.release
+	}
+	return &s.policy, nil
+}
+
+func TestPasswordPolicyCache_RetriesFailuresWithoutBlocking(t *testing.T) {
+	store := &blockingPolicyStore{release: make(chan struct{}), policy: PasswordPolicy{MinimumLength: 16}}
+	cache := &passwordPolicyCache{}
+	ctx := context.Background()
+
+	if got := cache.get(ctx, "pool", store); got != DefaultPasswordPolicy() {
+		t.Fatalf("expected the default policy after a failed read, got %+v", got)
+	}
+	if cache.get(ctx, "pool", store); store.calls != 1 {
+		t.Fatalf("expected the failure to be remembered briefly, got %d reads", store.calls)
+	}
+
+	// Once the retry interval is over, a slow read does not hold up others
+	cache.expiresAt = time.Now().Add(-time.Second)
+	done := make(chan PasswordPolicy)
+	go func() { done <- cache.get(ctx, "pool", store) }()
+	for {
+		store.mu.Lock()
+		calls := store.calls
+		store.mu.Unlock()
+		if calls == 2 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if got := cache.get(ctx, "pool", store); got != store.policy {
+		t.Errorf("expected the pool's policy while another read is pending, got %+v", got)
+	}
+	close(store.release)
+	if got := <-done; got != store.policy {
+		t.Errorf("expected the pool's policy from the slow read, got %+v", got)
+	}
+}
@@ -0,0 +1,80 @@
+package user
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// rateLimiter allows up to limit events per key in each fixed window. State
+// is per process.
+type rateLimiter struct {
+	mu      sync.Mutex
+	limit   int
+	window  time.Duration
+	windows map[string]*rateWindow
+	now     func() time.Time
+}
+
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+// rateLimiterSweepSize is the number of tracked keys above which expired
+// windows are dropped.
+const rateLimiterSweepSize = 10000
+
+func newRateLimiter(limit int, window time.Duration) *rateLimiter {
+	return &rateLimiter{
+		limit:   limit,
+		window:  window,
+		windows: make(map[string]*rateWindow),
+		now:     time.Now,
+	}
+}
+
+// allow records an event for key. When the key is over its limit it returns
+// false and how long until the window resets.
+func (l *rateLimiter) allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if len(l.windows) > rateLimiterSweepSize {
+		for k, w := range l.windows {
+			if now.Sub(w.start) >= l.window {
+				delete(l.windows, k)
+			}
+		}
+	}
+
+	w, ok := l.windows[key]
+	if !ok || now.Sub(w.start) >= l.window {
+		w = &rateWindow{start: now}
+		l.windows[key] = w
+	}
+	if w.count >= l.limit {
+		return false, w.start.Add(l.window).Sub(now)
+	}
+	w.count++
+	return true, 0
+}
+
+// writeRateLimited writes a 429 with a Retry-After header.
+func writeRateLimited(w http.ResponseWriter, retryAfter time.Duration) {
+	seconds := int((retryAfter + time.Second - 1) / time.Second)
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	writeError(w, http.StatusTooManyRequests, "Too many requests", nil)
+}
+
+// clientIP returns the host part of r.RemoteAddr. Behind a proxy, install a
+// middleware such as chi's middleware.RealIP so this is the client's address.
+func clientIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
@@ -0,0 +1,232 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+const (
+	refreshTokenCookieName = "refresh_token"
+	sessionCookieName      = "session_id"
+
+	defaultRefreshTokenMaxAge = 30 * 24 * time.Hour
+)
+
+// refreshTokenCookieAAD binds encrypted refresh token cookies to their purpose
+// so an encrypted OAuth state value cannot be replayed as one.
+var refreshTokenCookieAAD = []byte("refresh_token")
+
+// refreshTokenCookieKey is derived once from OAUTH_STATE_ENCRYPTION_KEY.
+var refreshTokenCookieKey = sync.OnceValue(getOrGenerateStateKey)
+
+// RefreshTokenStore keeps the refresh token issued at login so the session
+// can be renewed after the ID token expires.
+type RefreshTokenStore interface {
+	// SaveRefreshToken stores refreshToken for the user in claims, setting
+	// whatever cookie the store needs on w. It is called on every login;
+	// refreshToken is empty when the provider issued none.
+	SaveRefreshToken(w http.ResponseWriter, r *http.Request, claims *Claims, refreshToken string) error
+	// LoadRefreshToken returns the refresh token for the request, or an error
+	// wrapping ErrSessionNotFound when there is none.
+	LoadRefreshToken(r *http.Request) (string, error)
+	// ClearRefreshToken forgets the request's refresh token and clears its cookie.
+	ClearRefreshToken(w http.ResponseWriter, r *http.Request) error
+}
+
+// tokenRefresher is the subset of OAuth2Service used to renew sessions.
+type tokenRefresher interface {
+	RefreshTokens(ctx context.Context, refreshToken string) (*Claims, *OAuthTokens, error)
+}
+
+// refreshTokenMaxAge returns the configured refresh token lifetime.
+func refreshTokenMaxAge(config *OAuthConfig) time.Duration {
+	if config != nil && config.RefreshTokenMaxAgeSeconds > 0 {
+		return time.Duration(config.RefreshTokenMaxAgeSeconds) * time.Second
+	}
+	return defaultRefreshTokenMaxAge
+}
+
+// createSessionCookie creates a cookie string with the same security settings
+// as CreateJWTCookie. A maxAge of zero clears the cookie.
+func createSessionCookie(name, value string, maxAge int) string {
+	if maxAge > 0 {
+		return fmt.Sprintf("%s=%s; HttpOnly; Secure; SameSite=None; Path=/; Max-Age=%d", name, value, maxAge)
+	}
+	return fmt.Sprintf("%s=; HttpOnly; Secure; SameSite=None; Path=/; Max-Age=0;", name)
+}
+
+// cookieRefreshTokenStore keeps the refresh token in an AES-GCM encrypted
+// HttpOnly cookie, so no server-side state is needed.
+type cookieRefreshTokenStore struct {
+	key    []byte
+	maxAge time.Duration
+}
+
+// NewCookieRefreshTokenStore creates a RefreshTokenStore that keeps the
+// refresh token in an encrypted HttpOnly cookie. The cookie is encrypted with
+// OAUTH_STATE_ENCRYPTION_KEY; a zero maxAge uses the 30 day default.
+func NewCookieRefreshTokenStore(maxAge time.Duration) RefreshTokenStore {
+	if maxAge <= 0 {
+		maxAge = defaultRefreshTokenMaxAge
+	}
+	return &cookieRefreshTokenStore{key: refreshTokenCookieKey(), maxAge: maxAge}
+}
+
+func (s *cookieRefreshTokenStore) SaveRefreshToken(w http.ResponseWriter, r *http.Request, claims *Claims, refreshToken string) error {
+	if refreshToken == "" {
+		return nil
+	}
+	sealed, err := sealAESGCM(s.key, []byte(refreshToken), refreshTokenCookieAAD)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt refresh token: %w", err)
+	}
+	w.Header().Add("Set-Cookie", createSessionCookie(refreshTokenCookieName, sealed, int(s.maxAge/time.Second)))
+	return nil
+}
+
+func (s *cookieRefreshTokenStore) LoadRefreshToken(r *http.Request) (string, error) {
+	cookie, err := r.Cookie(refreshTokenCookieName)
+	if err != nil || cookie.Value == "" {
+		return "", fmt.Errorf("no refresh token cookie: %w", ErrSessionNotFound)
+	}
+
+	refreshToken, err := openAESGCM(s.key, cookie.Value, refreshTokenCookieAAD)
+	if err != nil {
+		return "", fmt.Errorf("invalid refresh token cookie: %w", ErrSessionNotFound)
+	}
+	return string(refreshToken), nil
+}
+
+func (s *cookieRefreshTokenStore) ClearRefreshToken(w http.ResponseWriter, r *http.Request) error {
+	w.Header().Add("Set-Cookie", createSessionCookie(refreshTokenCookieName, "", 0))
+	return nil
+}
+
+// sessionRefreshTokenStore keeps the refresh token in a SessionStore and
+// only an opaque session ID in the browser.
+type sessionRefreshTokenStore struct {
+	sessions SessionStore
+	maxAge   time.Duration
+}
+
+// NewSessionRefreshTokenStore creates a RefreshTokenStore backed by a
+// server-side SessionStore. The browser only receives a random session ID.
+// A zero maxAge uses the 30 day default.
+func NewSessionRefreshTokenStore(sessions SessionStore, maxAge time.Duration) RefreshTokenStore {
+	if maxAge <= 0 {
+		maxAge = defaultRefreshTokenMaxAge
+	}
+	return &sessionRefreshTokenStore{sessions: sessions, maxAge: maxAge}
+}
+
+func (s *sessionRefreshTokenStore) SaveRefreshToken(w http.ResponseWriter, r *http.Request, claims *Claims, refreshToken string) error {
+	ctx := r.Context()
+
+	// A rotated refresh token (or a new login by the same user) replaces the
+	// one in the current session.
+	if session, err := s.currentSession(r); err == nil && claims != nil && session.Username == claims.Username {
+		session.RefreshToken = refreshToken
+		return s.sessions.SaveSession(ctx, session)
+	}
+
+	id, err := GenerateSecureState()
+	if err != nil {
+		return fmt.Errorf("failed to generate session ID: %w", err)
+	}
+
+	now := time.Now()
+	session := &Session{
+		ID:           id,
+		RefreshToken: refreshToken,
+		CreatedAt:    now,
+		ExpiresAt:    now.Add(s.maxAge),
+	}
+	if claims != nil {
+		session.Username = claims.Username
+		session.Email = claims.Email
+	}
+	session.UserAgent = r.UserAgent()
+	session.IPAddress = clientIP(r)
+	if err := s.sessions.SaveSession(ctx, session); err != nil {
+		return fmt.Errorf("failed to save session: %w", err)
+	}
+
+	w.Header().Add("Set-Cookie", createSessionCookie(sessionCookieName, id, int(s.maxAge/time.Second)))
+	return nil
+}
+
+func (s *sessionRefreshTokenStore) LoadRefreshToken(r *http.Request) (string, error) {
+	session, err := s.currentSession(r)
+	if err != nil {
+		return "", err
+	}
+	return session.RefreshToken, nil
+}
+
+func (s *sessionRefreshTokenStore) ClearRefreshToken(w http.ResponseWriter, r *http.Request) error {
+	w.Header().Add("Set-Cookie", createSessionCookie(sessionCookieName, "", 0))
+
+	cookie, err := r.Cookie(sessionCookieName)
+	if err != nil || cookie.Value == "" {
+		return nil
+	}
+	return s.sessions.DeleteSession(r.Context(), cookie.Value)
+}
+
+func (s *sessionRefreshTokenStore) currentSession(r *http.Request) (*Session, error) {
+	cookie, err := r.Cookie(sessionCookieName)
+	if err != nil || cookie.Value == "" {
+		return nil, fmt.Errorf("no session cookie: %w", ErrSessionNotFound)
+	}
+	return s.sessions.GetSession(r.Context(), cookie.Value)
+}
+
+// renewSession exchanges the request's refresh token for a new ID token and
+// sets it as the JWT cookie. The refresh token is saved again only when the
+// provider rotated it; a token the provider rejects is cleared.
+func renewSession(w http.ResponseWriter, r *http.Request, refresher tokenRefresher, store RefreshTokenStore, createJWTCookie func(string, int, string) string, cookieDomain string) (*Claims, error) {
+	refreshToken, err := store.LoadRefreshToken(r)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, tokens, err := refresher.RefreshTokens(r.Context(), refreshToken)
+	if err != nil {
+		var retrieveErr *oauth2.RetrieveError
+		if errors.As(err, &retrieveErr) && retrieveErr.ErrorCode == "invalid_grant" {
+			log.Printf("⚠️ [renewSession] Refresh token rejected, clearing it")
+			store.ClearRefreshToken(w, r)
+		}
+		return nil, err
+	}
+
+	if tokens.RefreshToken != refreshToken {
+		if err := store.SaveRefreshToken(w, r, claims, tokens.RefreshToken); err != nil {
+			log.Printf("⚠️ [renewSession] Failed to save rotated refresh token: %v", err)
+		}
+	}
+
+	w.Header().Add("Set-Cookie", createJWTCookie(tokens.IDToken, jwtCookieMaxAge(tokens.IDToken), cookieDomain))
+	return claims, nil
+}
+
+// isTokenExpiredError reports whether err is an expired ID token.
+func isTokenExpiredError(err error) bool {
+	var expiredErr *oidc.TokenExpiredError
+	return errors.As(err, &expiredErr)
+}
+
+// SetRefreshTokenStore sets where the default Manager keeps refresh tokens.
+// Defaults to an encrypted cookie (NewCookieRefreshTokenStore), or to the
+// session store when one is set.
+func SetRefreshTokenStore(store RefreshTokenStore) {
+	defaultManager.refreshStore = store
+}
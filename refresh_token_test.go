@@ -0,0 +1,325 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golang.org/x/oauth2"
+)
+
+// fakeTokenService issues fixed tokens for the callback and refresh flows.
+type fakeTokenService struct {
+	mockOAuth2Servicer
+	idToken          string
+	refreshToken     string
+	rotatedToken     string // returned by RefreshTokens when set
+	refreshErr       error
+	refreshedWith    string
+	refreshCallCount int
+}
+
+func (f *fakeTokenService) HandleCallbackTokens(code, state string) (*Claims, *OAuthTokens, string, error) {
+	claims := &Claims{Email: "alice@example.com", Username: "alice"}
+	return claims, &OAuthTokens{IDToken: f.idToken, RefreshToken: f.refreshToken}, "https://app.example.com/home", nil
+}
+
+func (f *fakeTokenService) RefreshTokens(ctx context.Context, refreshToken string) (*Claims, *OAuthTokens, error) {
+	f.refreshCallCount++
+	f.refreshedWith = refreshToken
+	if f.refreshErr != nil {
+		return nil, nil, f.refreshErr
+	}
+	next := refreshToken
+	if f.rotatedToken != "" {
+		next = f.rotatedToken
+	}
+	return &Claims{Email: "alice@example.com", Username: "alice"}, &OAuthTokens{IDToken: f.idToken, RefreshToken: next}, nil
+}
+
+func newTestRefreshHandlers(service oauth2Servicer, store RefreshTokenStore) *OAuth2Handlers {
+	return &OAuth2Handlers{
+		oauth2Service:   service,
+		oauthConfig:     &OAuthConfig{},
+		getFrontEndURL:  func() string { return "https://app.example.com" },
+		getCookieDomain: func() string { return "app.example.com" },
+		createJWTCookie: CreateJWTCookie,
+		refreshTokens:   store,
+	}
+}
+
+// responseCookies returns the cookies set on a recorded response.
+func responseCookies(w *httptest.ResponseRecorder) map[string]*http.Cookie {
+	cookies := make(map[string]*http.Cookie)
+	for _, c := range w.Result().Cookies() {
+		cookies[c.Name] = c
+	}
+	return cookies
+}
+
+func TestCallbackHandler_SavesRefreshToken(t *testing.T) {
+	service := &fakeTokenService{idToken: createTestJWT("alice@example.com", ""), refreshToken: "refresh-1"}
+	store := NewCookieRefreshTokenStore(0)
+	h := newTestRefreshHandlers(service, store)
+
+	w := httptest.NewRecorder()
+	h.CallbackHandler(w, httptest.NewRequest(http.MethodGet, "/oauth2/idpresponse?code=abc&state=xyz", nil))
+
+	if w.Code != http.StatusFound {
+		t.Fatalf("expected 302, got %d", w.Code)
+	}
+	cookies := responseCookies(w)
+	if cookies["jwt"] == nil || cookies["jwt"].Value != service.idToken {
+		t.Errorf("expected jwt cookie with ID token, got %+v", cookies["jwt"])
+	}
+	refreshCookie := cookies[refreshTokenCookieName]
+	if refreshCookie == nil || !refreshCookie.HttpOnly || !refreshCookie.Secure {
+		t.Fatalf("expected HttpOnly Secure refresh cookie, got %+v", refreshCookie)
+	}
+	if strings.Contains(refreshCookie.Value, "refresh-1") {
+		t.Error("refresh cookie contains the plaintext refresh token")
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/auth/refresh", nil)
+	req.AddCookie(refreshCookie)
+	if got, err := store.LoadRefreshToken(req); err != nil || got != "refresh-1" {
+		t.Errorf("expected refresh-1 from cookie, got %q (%v)", got, err)
+	}
+}
+
+func TestCookieRefreshTokenStore_RejectsForeignCiphertext(t *testing.T) {
+	store := NewCookieRefreshTokenStore(0)
+
+	// Encrypted OAuth state uses the same key but must not decrypt as a refresh token.
+	stateRepo := &EncryptedStateRepository{key: refreshTokenCookieKey()}
+	state, err := stateRepo.GenerateEncryptedState("nonce", "https://app.example.com")
+	if err != nil {
+		t.Fatalf("GenerateEncryptedState: %v", err)
+	}
+
+	for _, value := range []string{state, "not-encrypted"} {
+		req := httptest.NewRequest(http.MethodPost, "/", nil)
+		req.AddCookie(&http.Cookie{Name: refreshTokenCookieName, Value: value})
+		if _, err := store.LoadRefreshToken(req); !errors.Is(err, ErrSessionNotFound) {
+			t.Errorf("expected ErrSessionNotFound for %q, got %v", value, err)
+		}
+	}
+}
+
+func TestSessionRefreshTokenStore_KeepsTokenServerSide(t *testing.T) {
+	sessions := NewMemorySessionStore()
+	store := NewSessionRefreshTokenStore(sessions, 0)
+	claims := &Claims{Email: "alice@example.com", Username: "alice"}
+
+	w := httptest.NewRecorder()
+	if err := store.SaveRefreshToken(w, httptest.NewRequest(http.MethodGet, "/", nil), claims, "refresh-1"); err != nil {
+		t.Fatalf("SaveRefreshToken: %v", err)
+	}
+	sessionCookie := responseCookies(w)[sessionCookieName]
+	if sessionCookie == nil || strings.Contains(sessionCookie.Value, "refresh-1") {
+		t.Fatalf("expected opaque session cookie, got %+v", sessionCookie)
+	}
+
+	session, err := sessions.GetSession(context.Background(), sessionCookie.Value)
+	if err != nil {
+		t.Fatalf("GetSession: %v", err)
+	}
+	if session.Username != "alice" || session.RefreshToken != "refresh-1" {
+		t.Errorf("unexpected session: %+v", session)
+	}
+
+	// A rotated token updates the same session.
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.AddCookie(sessionCookie)
+	if err := store.SaveRefreshToken(httptest.NewRecorder(), req, claims, "refresh-2"); err != nil {
+		t.Fatalf("SaveRefreshToken: %v", err)
+	}
+	if got, err := store.LoadRefreshToken(req); err != nil || got != "refresh-2" {
+		t.Errorf("expected refresh-2, got %q (%v)", got, err)
+	}
+
+	if err := store.ClearRefreshToken(httptest.NewRecorder(), req); err != nil {
+		t.Fatalf("ClearRefreshToken: %v", err)
+	}
+	if _, err := sessions.GetSession(context.Background(), sessionCookie.Value); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("expected session to be deleted, got %v", err)
+	}
+}
+
+func TestRefreshHandler(t *testing.T) {
+	store := NewCookieRefreshTokenStore(0)
+	sealed := httptest.NewRecorder()
+	if err := store.SaveRefreshToken(sealed, httptest.NewRequest(http.MethodGet, "/", nil), nil, "refresh-1"); err != nil {
+		t.Fatalf("SaveRefreshToken: %v", err)
+	}
+	refreshCookie := responseCookies(sealed)[refreshTokenCookieName]
+
+	t.Run("renews the JWT cookie", func(t *testing.T) {
+		service := &fakeTokenService{idToken: createTestJWT("alice@example.com", "")}
+		h := newTestRefreshHandlers(service, store)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/auth/refresh", nil)
+		req.AddCookie(refreshCookie)
+		w := httptest.NewRecorder()
+		h.RefreshHandler(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		if service.refreshedWith != "refresh-1" {
+			t.Errorf("expected refresh with refresh-1, got %q", service.refreshedWith)
+		}
+		cookies := responseCookies(w)
+		if cookies["jwt"] == nil || cookies["jwt"].Value != service.idToken {
+			t.Errorf("expected renewed jwt cookie, got %+v", cookies["jwt"])
+		}
+		if cookies[refreshTokenCookieName] != nil {
+			t.Error("expected unchanged refresh token not to be rewritten")
+		}
+	})
+
+	t.Run("saves a rotated refresh token", func(t *testing.T) {
+		service := &fakeTokenService{idToken: createTestJWT("alice@example.com", ""), rotatedToken: "refresh-2"}
+		h := newTestRefreshHandlers(service, store)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/auth/refresh", nil)
+		req.AddCookie(refreshCookie)
+		w := httptest.NewRecorder()
+		h.RefreshHandler(w, req)
+
+		rotated := responseCookies(w)[refreshTokenCookieName]
+		if rotated == nil {
+			t.Fatal("expected rotated refresh cookie")
+		}
+		next := httptest.NewRequest(http.MethodPost, "/", nil)
+		next.AddCookie(rotated)
+		if got, _ := store.LoadRefreshToken(next); got != "refresh-2" {
+			t.Errorf("expected refresh-2, got %q", got)
+		}
+	})
+
+	t.Run("returns 401 without a refresh token", func(t *testing.T) {
+		service := &fakeTokenService{}
+		h := newTestRefreshHandlers(service, store)
+
+		w := httptest.NewRecorder()
+		h.RefreshHandler(w, httptest.NewRequest(http.MethodPost, "/api/auth/refresh", nil))
+
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("expected 401, got %d", w.Code)
+		}
+		if service.refreshCallCount != 0 {
+			t.Error("expected no token endpoint call")
+		}
+	})
+
+	t.Run("clears a rejected refresh token", func(t *testing.T) {
+		service := &fakeTokenService{refreshErr: fmt.Errorf("failed to refresh token: %w", &oauth2.RetrieveError{ErrorCode: "invalid_grant"})}
+		h := newTestRefreshHandlers(service, store)
+
+		req := httptest.NewRequest(http.MethodPost, "/api/auth/refresh", nil)
+		req.AddCookie(refreshCookie)
+		w := httptest.NewRecorder()
+		h.RefreshHandler(w, req)
+
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("expected 401, got %d", w.Code)
+		}
+		if c := responseCookies(w)[refreshTokenCookieName]; c == nil || c.MaxAge >= 0 {
+			t.Errorf("expected refresh cookie to be cleared, got %+v", c)
+		}
+	})
+}
+
+func TestRequireAuthMiddleware_TransparentRefresh(t *testing.T) {
+	store := NewCookieRefreshTokenStore(0)
+	sealed := httptest.NewRecorder()
+	if err := store.SaveRefreshToken(sealed, httptest.NewRequest(http.MethodGet, "/", nil), nil, "refresh-1"); err != nil {
+		t.Fatalf("SaveRefreshToken: %v", err)
+	}
+	refreshCookie := responseCookies(sealed)[refreshTokenCookieName]
+
+	service := &fakeTokenService{idToken: createTestJWT("alice@example.com", "")}
+	m := NewManager(&OAuthConfig{FrontEndURL: "https://app.example.com"}, WithRefreshTokenStore(store))
+	m.refresher = service
+
+	handler := func(opts ...AuthMiddlewareOption) http.Handler {
+		return m.RequireAuthMiddleware(opts...)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := GetClaimsFromContext(r)
+			if !ok || claims.Email != "alice@example.com" {
+				t.Errorf("expected refreshed claims, got %+v", claims)
+			}
+			w.WriteHeader(http.StatusOK)
+		}))
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/things", nil)
+	req.AddCookie(refreshCookie)
+
+	w := httptest.NewRecorder()
+	handler().ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without the option, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	handler(WithTransparentRefresh()).ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 with transparent refresh, got %d", w.Code)
+	}
+	if c := responseCookies(w)["jwt"]; c == nil || c.Value != service.idToken {
+		t.Errorf("expected renewed jwt cookie, got %+v", c)
+	}
+}
+
+func TestOAuth2Service_RefreshTokens(t *testing.T) {
+	idToken := createTestJWT("alice@example.com", "")
+	var gotGrant, gotRefresh string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		gotGrant, gotRefresh = r.Form.Get("grant_type"), r.Form.Get("refresh_token")
+		w.Header().Set("Content-Type", "application/json")
+		// Cognito does not return a new refresh token.
+		fmt.Fprintf(w, `{"access_token":"access","id_token":%q,"token_type":"Bearer","expires_in":3600}`, idToken)
+	}))
+	defer server.Close()
+
+	svc := &OAuth2Service{
+		oauthConfig: &OAuthConfig{ClientID: "test-client"},
+		oauth2ConfigFactory: func() (*oauth2.Config, error) {
+			return &oauth2.Config{
+				ClientID:     "test-client",
+				ClientSecret: "test-secret",
+				Endpoint:     oauth2.Endpoint{TokenURL: server.URL},
+			}, nil
+		},
+		verifyIDToken: func(ctx context.Context, rawIDToken string) (*Claims, string, error) {
+			if rawIDToken != idToken {
+				return nil, "", fmt.Errorf("unexpected ID token")
+			}
+			return &Claims{Email: "alice@example.com"}, "", nil
+		},
+	}
+
+	claims, tokens, err := svc.RefreshTokens(context.Background(), "refresh-1")
+	if err != nil {
+		t.Fatalf("RefreshTokens: %v", err)
+	}
+	if gotGrant != "refresh_token" || gotRefresh != "refresh-1" {
+		t.Errorf("unexpected token request: grant_type=%q refresh_token=%q", gotGrant, gotRefresh)
+	}
+	if claims.Email != "alice@example.com" || tokens.IDToken != idToken {
+		t.Errorf("unexpected result: %+v %+v", claims, tokens)
+	}
+	if tokens.RefreshToken != "refresh-1" {
+		t.Errorf("expected original refresh token to be kept, got %q", tokens.RefreshToken)
+	}
+
+	if _, _, err := svc.RefreshTokens(context.Background(), ""); err == nil {
+		t.Error("expected error for empty refresh token")
+	}
+}
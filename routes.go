@@ -38,14 +38,14 @@ func writeError(w http.ResponseWriter, status int, message string, details map[s
 // RegisterUserRoutes removed
 // This function and all user management routes required SQLite database access
 // which is no longer supported. User management is now handled via Cognito.
-// Use RequireAuthMiddleware() for authentication and SetupAuthRoutes() for OAuth flows.
+// Use RequireAuthMiddleware() for authentication, SetupAuthRoutes() for OAuth flows
+// and SetupAdminUserRoutes() for the admin user management API.
 
-// SetupAuthRoutes sets up all authentication routes using the global OAuth configuration
+// SetupAuthRoutes sets up all authentication routes using the Manager's OAuth configuration
 // This is the main entry point that replaces the complex manual setup
-// Note: You must call SetOAuthConfig() first to configure OAuth settings
-func SetupAuthRoutes(r chi.Router) error {
-	// Use global configuration (required)
-	config := getRequiredOIDCConfig()
+func (m *Manager) SetupAuthRoutes(r chi.Router) error {
+	// Use the Manager's configuration (required)
+	config := m.requiredConfig()
 
 	// Validate configuration
 	if err := validateOAuthConfig(config); err != nil {
@@ -53,29 +53,39 @@ func SetupAuthRoutes(r chi.Router) error {
 	}
 
 	// Create state repository for OAuth state management
-	// Always use encrypted state (stateless) - works in Lambda/serverless environments
-	stateRepo := NewEncryptedStateRepository()
-	log.Printf("✅ Using encrypted state repository (stateless)")
+	// Defaults to encrypted state (stateless) - works in Lambda/serverless environments
+	stateRepo := m.stateRepo
+	if stateRepo == nil {
+		stateRepo = NewEncryptedStateRepository()
+		log.Printf("✅ Using encrypted state repository (stateless)")
+	}
 
 	// Create OAuth2 service using the consolidated config (JWT validation only, no database)
 	oauth2Service, err := createOAuth2ServiceFromOAuthConfig(stateRepo, config)
 	if err != nil {
 		return fmt.Errorf("failed to create OAuth2 service: %w", err)
 	}
+	oauth2Service.oidc = m.oidc
 
 	// Create OAuth2 handlers with internal helper functions
 	oauth2Handlers := createOAuth2HandlersFromOAuthConfig(oauth2Service, config)
+	oauth2Handlers.refreshTokens = m.refreshTokenStore()
 
 	// Setup authentication routes
 	r.Get("/oauth2/idpresponse", oauth2Handlers.CallbackHandler)
 	r.Get("/api/auth/login", oauth2Handlers.LoginHandler)
 	r.Get("/api/auth/logout", oauth2Handlers.LogoutHandler)
+	r.Post("/api/auth/refresh", oauth2Handlers.RefreshHandler)
 
 	// Setup protected auth routes (requires authentication)
 	r.Route("/api/auth", func(r chi.Router) {
 		// Authentication middleware - JWT validation only
-		r.Use(RequireAuthMiddleware())
+		r.Use(m.RequireAuthMiddleware())
 		r.Get("/profile", createJWTProfileHandler())
+
+		if m.sessions != nil {
+			m.setupSessionRoutes(r)
+		}
 	})
 
 	log.Printf("✅ Authentication routes setup completed")
@@ -91,9 +101,7 @@ func createOAuth2ServiceFromOAuthConfig(stateRepo StateRepository, config *OAuth
 
 // createOAuth2HandlersFromOAuthConfig creates OAuth2Handlers with internal helper functions
 func createOAuth2HandlersFromOAuthConfig(oauth2Service *OAuth2Service, config *OAuthConfig) *OAuth2Handlers {
-	// Extract cookie domain from FrontEndURL
-	frontEndURL, _ := url.Parse(config.FrontEndURL)
-	cookieDomain := frontEndURL.Hostname()
+	cookieDomain := cookieDomainFromConfig(config)
 
 	// Create handlers with internal helper functions
 	return &OAuth2Handlers{
@@ -105,6 +113,15 @@ func createOAuth2HandlersFromOAuthConfig(oauth2Service *OAuth2Service, config *O
 	}
 }
 
+// cookieDomainFromConfig extracts the cookie domain from FrontEndURL
+func cookieDomainFromConfig(config *OAuthConfig) string {
+	frontEndURL, err := url.Parse(config.FrontEndURL)
+	if err != nil {
+		return ""
+	}
+	return frontEndURL.Hostname()
+}
+
 // createJWTProfileHandler creates the profile endpoint handler using JWT claims only
 func createJWTProfileHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,284 @@
+package user
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// SetupAdminUserRoutes registers the user management API under
+// /api/admin/users. Every route requires authentication and the admin role
+// (or a role inheriting it).
+//
+//	GET    /api/admin/users?limit=&offset=        - List users
+//	POST   /api/admin/users                       - Create and invite a user
+//	GET    /api/admin/users/{email}               - Get a user
+//	PATCH  /api/admin/users/{email}               - Update profile fields
+//	DELETE /api/admin/users/{email}               - Delete a user
+//	PUT    /api/admin/users/{email}/role          - Change the role
+//	PUT    /api/admin/users/{email}/tenant        - Change the tenant
+//	POST   /api/admin/users/{email}/disable       - Disable sign-in
+//	POST   /api/admin/users/{email}/enable        - Re-enable sign-in
+//	POST   /api/admin/users/{email}/sign-out      - Sign out everywhere
+//	POST   /api/admin/users/{email}/reset-password - Issue a new temporary password
+//	PUT    /api/admin/users/{email}/password      - Set a password
+//	PUT    /api/admin/users/{email}/mfa           - Set the enabled MFA methods
+func (m *Manager) SetupAdminUserRoutes(r chi.Router) {
+	r.Route("/api/admin/users", func(r chi.Router) {
+		r.Use(m.RequireAuthMiddleware())
+		r.Use(m.RequireRole("admin"))
+
+		r.Get("/", m.handleAdminListUsers)
+		r.Post("/", m.handleAdminCreateUser)
+		r.Route("/{email}", func(r chi.Router) {
+			r.Get("/", m.handleAdminGetUser)
+			r.Patch("/", m.handleAdminUpdateProfile)
+			r.Delete("/", m.handleAdminDeleteUser)
+			r.Put("/role", m.handleAdminUpdateRole)
+			r.Put("/tenant", m.handleAdminUpdateTenant)
+			r.Post("/disable", m.handleAdminDisableUser)
+			r.Post("/enable", m.handleAdminEnableUser)
+			r.Post("/sign-out", m.handleAdminSignOutUser)
+			r.Post("/reset-password", m.handleAdminResetPassword)
+			r.Put("/password", m.handleAdminSetPassword)
+			r.Put("/mfa", m.handleAdminSetMFA)
+		})
+	})
+}
+
+// writeUserError maps user management errors onto HTTP statuses:
+// ErrUserNotFound and ErrAPIKeyNotFound -> 404, ErrUserAlreadyExists -> 409,
+// ErrInvalidInput -> 400, with one detail per field for a ValidationError
+// (several messages for a field are joined with "; ").
+// Anything else is logged and reported as a 500 without details.
+func writeUserError(w http.ResponseWriter, err error) {
+	var validationErr *ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		details := make(map[string]string, len(validationErr.Fields))
+		for _, f := range validationErr.Fields {
+			if prev, ok := details[f.Field]; ok {
+				details[f.Field] = prev + "; " + f.Message
+			} else {
+				details[f.Field] = f.Message
+			}
+		}
+		writeError(w, http.StatusBadRequest, "Validation failed", details)
+	case errors.Is(err, ErrUserNotFound):
+		writeError(w, http.StatusNotFound, "User not found", nil)
+	case errors.Is(err, ErrAPIKeyNotFound):
+		writeError(w, http.StatusNotFound, "API key not found", nil)
+	case errors.Is(err, ErrUserAlreadyExists):
+		writeError(w, http.StatusConflict, "User already exists", nil)
+	case errors.Is(err, ErrInvalidInput):
+		writeError(w, http.StatusBadRequest, err.Error(), nil)
+	default:
+		log.Printf("❌ User management request failed: %v", err)
+		writeError(w, http.StatusInternalServerError, "Internal server error", nil)
+	}
+}
+
+// decodeJSONBody decodes the request body into v, writing a 400 on failure.
+func decodeJSONBody(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid JSON body", nil)
+		return false
+	}
+	return true
+}
+
+// adminEmailParam returns the unescaped {email} route parameter.
+func adminEmailParam(r *http.Request) string {
+	email := GetEmailFromURL(r)
+	if unescaped, err := url.PathUnescape(email); err == nil {
+		return unescaped
+	}
+	return email
+}
+
+// userResponse strips the legacy plaintext API key before a user is
+// returned over the HTTP API.
+func userResponse(user *User) *User {
+	response := *user
+	response.APIKey = ""
+	return &response
+}
+
+func (m *Manager) handleAdminListUsers(w http.ResponseWriter, r *http.Request) {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+
+	users, err := m.ListUsers(r.Context(), limit, offset)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+
+	response := make([]*User, 0, len(users))
+	for _, user := range users {
+		response = append(response, userResponse(user))
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"users": response})
+}
+
+func (m *Manager) handleAdminCreateUser(w http.ResponseWriter, r *http.Request) {
+	var req CreateUserRequest
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	user, tempPassword, err := m.CreateUserWithInvitation(r.Context(), req)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, map[string]interface{}{
+		"user":              userResponse(user),
+		"temporaryPassword": tempPassword,
+	})
+}
+
+func (m *Manager) handleAdminGetUser(w http.ResponseWriter, r *http.Request) {
+	user, err := m.GetUser(r.Context(), adminEmailParam(r))
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, userResponse(user))
+}
+
+func (m *Manager) handleAdminUpdateProfile(w http.ResponseWriter, r *http.Request) {
+	var update ProfileUpdate
+	if !decodeJSONBody(w, r, &update) {
+		return
+	}
+
+	user, err := m.UpdateProfile(r.Context(), adminEmailParam(r), update)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, userResponse(user))
+}
+
+func (m *Manager) handleAdminDeleteUser(w http.ResponseWriter, r *http.Request) {
+	if err := m.DeleteUser(r.Context(), adminEmailParam(r)); err != nil {
+		writeUserError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (m *Manager) handleAdminUpdateRole(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Role string `json:"role"`
+	}
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	user, err := m.UpdateRole(r.Context(), adminEmailParam(r), req.Role)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, userResponse(user))
+}
+
+func (m *Manager) handleAdminUpdateTenant(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		TenantID string `json:"tenantId"`
+	}
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	user, err := m.UpdateTenantID(r.Context(), adminEmailParam(r), req.TenantID)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, userResponse(user))
+}
+
+func (m *Manager) handleAdminDisableUser(w http.ResponseWriter, r *http.Request) {
+	if err := m.DisableUser(r.Context(), adminEmailParam(r)); err != nil {
+		writeUserError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (m *Manager) handleAdminEnableUser(w http.ResponseWriter, r *http.Request) {
+	if err := m.EnableUser(r.Context(), adminEmailParam(r)); err != nil {
+		writeUserError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (m *Manager) handleAdminSignOutUser(w http.ResponseWriter, r *http.Request) {
+	if err := m.SignOutUser(r.Context(), adminEmailParam(r)); err != nil {
+		writeUserError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (m *Manager) handleAdminResetPassword(w http.ResponseWriter, r *http.Request) {
+	tempPassword, err := m.ResetTemporaryPassword(r.Context(), adminEmailParam(r))
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"temporaryPassword": tempPassword})
+}
+
+func (m *Manager) handleAdminSetPassword(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Password  string `json:"password"`
+		Permanent bool   `json:"permanent"`
+	}
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+	if req.Password == "" {
+		writeError(w, http.StatusBadRequest, "password is required", nil)
+		return
+	}
+
+	if err := m.SetUserPassword(r.Context(), adminEmailParam(r), req.Password, req.Permanent); err != nil {
+		writeUserError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (m *Manager) handleAdminSetMFA(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Methods   []MFAMethod `json:"methods"`
+		Preferred MFAMethod   `json:"preferred"`
+	}
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	email := adminEmailParam(r)
+	if err := m.SetUserMFAPreference(r.Context(), email, req.Methods, req.Preferred); err != nil {
+		writeUserError(w, err)
+		return
+	}
+
+	user, err := m.GetUser(r.Context(), email)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, userResponse(user))
+}
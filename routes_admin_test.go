@@ -0,0 +1,177 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// newAdminRoutesTestServer serves the admin routes over a memory store and
+// returns API keys for an admin and a regular user.
+func newAdminRoutesTestServer(t *testing.T) (http.Handler, *MemoryUserStore, string, string) {
+	t.Helper()
+	store := NewMemoryUserStore()
+	m := NewManager(&OAuthConfig{}, WithUserStore(store))
+	ctx := context.Background()
+
+	keys := make(map[string]string)
+	for _, u := range []struct{ email, role string }{
+		{"admin@example.com", "admin"},
+		{"plain@example.com", "user"},
+	} {
+		if _, err := m.CreateUser(ctx, CreateUserRequest{Email: u.email, Role: u.role}); err != nil {
+			t.Fatalf("CreateUser: %v", err)
+		}
+		key, err := m.GenerateAPIKey(ctx, u.email)
+		if err != nil {
+			t.Fatalf("GenerateAPIKey: %v", err)
+		}
+		keys[u.role] = key
+	}
+
+	r := chi.NewRouter()
+	m.SetupAdminUserRoutes(r)
+	return r, store, keys["admin"], keys["user"]
+}
+
+func adminRequest(t *testing.T, h http.Handler, apiKey, method, path, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func TestAdminUserRoutes_RequireAdmin(t *testing.T) {
+	h, _, _, userKey := newAdminRoutesTestServer(t)
+
+	if w := adminRequest(t, h, userKey, http.MethodGet, "/api/admin/users", ""); w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for non-admin, got %d", w.Code)
+	}
+	if w := adminRequest(t, h, "bogus", http.MethodGet, "/api/admin/users", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 for unknown key, got %d", w.Code)
+	}
+}
+
+func TestAdminUserRoutes_Lifecycle(t *testing.T) {
+	h, store, adminKey, _ := newAdminRoutesTestServer(t)
+
+	w := adminRequest(t, h, adminKey, http.MethodPost, "/api/admin/users", `{"email":"new@example.com","givenName":"New","role":"manager"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var created struct {
+		User              User   `json:"user"`
+		TemporaryPassword string `json:"temporaryPassword"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &created)
+	if created.User.Role != "manager" || created.TemporaryPassword == "" {
+		t.Errorf("unexpected create response: %s", w.Body.String())
+	}
+
+	steps := []struct {
+		method, path, body string
+		want               int
+	}{
+		{http.MethodGet, "/api/admin/users/new@example.com", "", http.StatusOK},
+		{http.MethodPatch, "/api/admin/users/new@example.com", `{"familyName":"Person"}`, http.StatusOK},
+		{http.MethodPut, "/api/admin/users/new@example.com/role", `{"role":"admin"}`, http.StatusOK},
+		{http.MethodPut, "/api/admin/users/new@example.com/tenant", `{"tenantId":"acme"}`, http.StatusOK},
+		{http.MethodPost, "/api/admin/users/new@example.com/disable", "", http.StatusNoContent},
+		{http.MethodPost, "/api/admin/users/new@example.com/enable", "", http.StatusNoContent},
+		{http.MethodPost, "/api/admin/users/new@example.com/reset-password", "", http.StatusOK},
+		{http.MethodPut, "/api/admin/users/new@example.com/password", `{"password":"N3w-Passw0rd!","permanent":true}`, http.StatusNoContent},
+	}
+	for _, step := range steps {
+		if w := adminRequest(t, h, adminKey, step.method, step.path, step.body); w.Code != step.want {
+			t.Fatalf("%s %s: expected %d, got %d: %s", step.method, step.path, step.want, w.Code, w.Body.String())
+		}
+	}
+
+	record, err := store.GetUser(context.Background(), "new@example.com")
+	if err != nil {
+		t.Fatalf("GetUser: %v", err)
+	}
+	if record.Attributes["family_name"] != "Person" || record.Attributes["custom:role"] != "admin" || record.Attributes["custom:tenantId"] != "acme" {
+		t.Errorf("unexpected attributes: %v", record.Attributes)
+	}
+	if pw, _ := store.Password("new@example.com"); pw != "N3w-Passw0rd!" || record.Status != "CONFIRMED" {
+		t.Errorf("expected permanent password to be set, got %q (%s)", pw, record.Status)
+	}
+
+	w = adminRequest(t, h, adminKey, http.MethodGet, "/api/admin/users?limit=10", "")
+	var listed struct {
+		Users []User `json:"users"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &listed)
+	if w.Code != http.StatusOK || len(listed.Users) != 3 {
+		t.Errorf("list: expected 3 users, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if w := adminRequest(t, h, adminKey, http.MethodDelete, "/api/admin/users/new@example.com", ""); w.Code != http.StatusNoContent {
+		t.Fatalf("delete: expected 204, got %d", w.Code)
+	}
+	if _, err := store.GetUser(context.Background(), "new@example.com"); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("expected user to be deleted, got %v", err)
+	}
+}
+
+func TestAdminUserRoutes_ErrorMapping(t *testing.T) {
+	h, _, adminKey, _ := newAdminRoutesTestServer(t)
+
+	tests := []struct {
+		name, method, path, body string
+		want                     int
+	}{
+		{"not found", http.MethodGet, "/api/admin/users/missing@example.com", "", http.StatusNotFound},
+		{"already exists", http.MethodPost, "/api/admin/users", `{"email":"plain@example.com"}`, http.StatusConflict},
+		{"invalid input", http.MethodPost, "/api/admin/users", `{"givenName":"No Email"}`, http.StatusBadRequest},
+		{"empty role", http.MethodPut, "/api/admin/users/plain@example.com/role", `{"role":""}`, http.StatusBadRequest},
+		{"malformed JSON", http.MethodPatch, "/api/admin/users/plain@example.com", `{`, http.StatusBadRequest},
+		{"missing password", http.MethodPut, "/api/admin/users/plain@example.com/password", `{}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := adminRequest(t, h, adminKey, tt.method, tt.path, tt.body)
+			if w.Code != tt.want {
+				t.Fatalf("expected %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+			var body ErrorResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Error == "" {
+				t.Errorf("expected JSON error body, got %s", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestWriteUserError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{fmt.Errorf("get: %w", ErrUserNotFound), http.StatusNotFound},
+		{fmt.Errorf("create: %w", ErrUserAlreadyExists), http.StatusConflict},
+		{fmt.Errorf("email: %w", ErrInvalidInput), http.StatusBadRequest},
+		{errors.New("cognito unavailable"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		writeUserError(w, tt.err)
+		if w.Code != tt.want {
+			t.Errorf("%v: expected %d, got %d", tt.err, tt.want, w.Code)
+		}
+	}
+	w := httptest.NewRecorder()
+	writeUserError(w, errors.New("secret backend detail"))
+	if strings.Contains(w.Body.String(), "secret") {
+		t.Error("500 responses must not leak error details")
+	}
+}
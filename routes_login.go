@@ -0,0 +1,67 @@
+package user
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// SetupLoginRoutes registers the native login routes for clients that cannot
+// open the hosted UI, such as CLI tools and embedded devices. On success they
+// set the same jwt and refresh token cookies as the OAuth callback and return
+// {"claims": {...}}; when Cognito needs more, they return {"challenge": {...}}
+// to be posted back with the answer.
+//
+//	POST /api/auth/native-login           - {"username": "...", "password": "..."}
+//	POST /api/auth/native-login/challenge - {"name": "...", "session": "...", "username": "...", "answer": "..."}
+func (m *Manager) SetupLoginRoutes(r chi.Router) {
+	r.Post("/api/auth/native-login", m.handleNativeLogin)
+	r.Post("/api/auth/native-login/challenge", m.handleNativeLoginChallenge)
+}
+
+func (m *Manager) handleNativeLogin(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	result, err := m.Login(r.Context(), req.Username, req.Password)
+	m.writeLoginResult(w, r, result, err)
+}
+
+func (m *Manager) handleNativeLoginChallenge(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		LoginChallenge
+		Answer string `json:"answer"`
+	}
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	result, err := m.RespondToLoginChallenge(r.Context(), req.LoginChallenge, req.Answer)
+	m.writeLoginResult(w, r, result, err)
+}
+
+// writeLoginResult sets the login cookies once authentication is complete.
+func (m *Manager) writeLoginResult(w http.ResponseWriter, r *http.Request, result *LoginResult, err error) {
+	switch {
+	case errors.Is(err, ErrInvalidCredentials):
+		writeError(w, http.StatusUnauthorized, "Invalid credentials", nil)
+		return
+	case errors.Is(err, ErrPasswordResetRequired):
+		writeError(w, http.StatusForbidden, "Password reset required", nil)
+		return
+	case err != nil:
+		writeUserError(w, err)
+		return
+	}
+
+	if result.Challenge == nil {
+		m.setLoginCookies(w, r, result)
+	}
+	writeJSON(w, http.StatusOK, result)
+}
@@ -0,0 +1,143 @@
+package user
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// SetupMFARoutes registers self-service MFA routes. They act on the caller's
+// own account and refuse requests authenticated with an API key.
+//
+// Cognito's TOTP enrollment works on the user's access token, which the
+// login flow does not hand to the browser. The enrollment routes therefore
+// take it as "accessToken" in the body, or, when it is omitted, obtain one
+// from the refresh token stored at login. A token issued to anyone but the
+// caller is refused.
+//
+//	GET  /api/auth/mfa             - Own MFA methods and preferred method
+//	POST /api/auth/mfa/totp        - Start TOTP enrollment; returns the secret and otpauth:// URI
+//	POST /api/auth/mfa/totp/verify - Finish enrollment with {"code": "123456", "deviceName": "..."}
+func (m *Manager) SetupMFARoutes(r chi.Router) {
+	r.Group(func(r chi.Router) {
+		r.Use(m.RequireAuthMiddleware())
+		r.Get("/api/auth/mfa", m.handleGetOwnMFA)
+		r.Post("/api/auth/mfa/totp", m.handleEnrollOwnTOTP)
+		r.Post("/api/auth/mfa/totp/verify", m.handleVerifyOwnTOTP)
+	})
+}
+
+// callerAccessToken returns the access token given in the request body, or
+// one obtained by refreshing the caller's session.
+func (m *Manager) callerAccessToken(w http.ResponseWriter, r *http.Request, given string) (string, error) {
+	if given != "" {
+		return given, nil
+	}
+
+	store := m.refreshTokenStore()
+	refreshToken, err := store.LoadRefreshToken(r)
+	if err != nil {
+		return "", err
+	}
+	refresher, err := m.tokenRefresher(m.requiredConfig())
+	if err != nil {
+		return "", err
+	}
+	claims, tokens, err := refresher.RefreshTokens(r.Context(), refreshToken)
+	if err != nil {
+		return "", err
+	}
+	if tokens.RefreshToken != refreshToken {
+		if err := store.SaveRefreshToken(w, r, claims, tokens.RefreshToken); err != nil {
+			log.Printf("⚠️ [MFA] Failed to save rotated refresh token: %v", err)
+		}
+	}
+	if tokens.AccessToken == "" {
+		return "", fmt.Errorf("refresh returned no access token")
+	}
+	return tokens.AccessToken, nil
+}
+
+func (m *Manager) handleGetOwnMFA(w http.ResponseWriter, r *http.Request) {
+	email, ok := interactiveCallerEmail(w, r, "MFA changes")
+	if !ok {
+		return
+	}
+
+	user, err := m.GetUser(r.Context(), email)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	methods := user.MFAMethods
+	if methods == nil {
+		methods = []MFAMethod{}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"methods":   methods,
+		"preferred": user.PreferredMFA,
+	})
+}
+
+func (m *Manager) handleEnrollOwnTOTP(w http.ResponseWriter, r *http.Request) {
+	email, ok := interactiveCallerEmail(w, r, "MFA changes")
+	if !ok {
+		return
+	}
+
+	var req struct {
+		AccessToken string `json:"accessToken"`
+	}
+	if r.ContentLength != 0 && !decodeJSONBody(w, r, &req) {
+		return
+	}
+
+	accessToken, err := m.callerAccessToken(w, r, req.AccessToken)
+	if err != nil {
+		log.Printf("❌ [MFA] No access token for %s: %v", email, err)
+		writeError(w, http.StatusUnauthorized, "An access token is required", nil)
+		return
+	}
+
+	enrollment, err := m.EnrollTOTP(r.Context(), email, accessToken)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, enrollment)
+}
+
+func (m *Manager) handleVerifyOwnTOTP(w http.ResponseWriter, r *http.Request) {
+	email, ok := interactiveCallerEmail(w, r, "MFA changes")
+	if !ok {
+		return
+	}
+
+	var req struct {
+		AccessToken string `json:"accessToken"`
+		Code        string `json:"code"`
+		DeviceName  string `json:"deviceName"`
+	}
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+	if req.Code == "" {
+		writeError(w, http.StatusBadRequest, "code is required", nil)
+		return
+	}
+
+	accessToken, err := m.callerAccessToken(w, r, req.AccessToken)
+	if err != nil {
+		log.Printf("❌ [MFA] No access token for %s: %v", email, err)
+		writeError(w, http.StatusUnauthorized, "An access token is required", nil)
+		return
+	}
+
+	if err := m.VerifyTOTP(r.Context(), email, accessToken, req.Code, req.DeviceName); err != nil {
+		writeUserError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,98 @@
+package user
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	defaultPasswordResetRateLimit = 5
+	passwordResetRateWindow       = 15 * time.Minute
+)
+
+// SetupPasswordResetRoutes registers the public self-service password reset
+// routes. Both are rate limited per client IP and per email address
+// (OAuthConfig.PasswordResetRateLimit requests per 15 minutes each), with
+// 429 Too Many Requests beyond that. Behind a proxy, install a middleware
+// such as chi's middleware.RealIP first so limits apply per client.
+//
+//	POST /api/auth/forgot-password         - {"email": "..."}; 202 whether or not the account exists
+//	POST /api/auth/forgot-password/confirm - {"email": "...", "code": "123456", "newPassword": "..."}; 204
+func (m *Manager) SetupPasswordResetRoutes(r chi.Router) {
+	limit := defaultPasswordResetRateLimit
+	if config := m.Config(); config != nil && config.PasswordResetRateLimit > 0 {
+		limit = config.PasswordResetRateLimit
+	}
+
+	r.Post("/api/auth/forgot-password", m.forgotPasswordHandler(newRateLimiter(limit, passwordResetRateWindow)))
+	r.Post("/api/auth/forgot-password/confirm", m.confirmForgotPasswordHandler(newRateLimiter(limit, passwordResetRateWindow)))
+}
+
+func (m *Manager) forgotPasswordHandler(limiter *rateLimiter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if ok, retryAfter := limiter.allow("ip:" + clientIP(r)); !ok {
+			writeRateLimited(w, retryAfter)
+			return
+		}
+
+		var req struct {
+			Email string `json:"email"`
+		}
+		if !decodeJSONBody(w, r, &req) {
+			return
+		}
+		if req.Email == "" {
+			writeError(w, http.StatusBadRequest, "email is required", nil)
+			return
+		}
+		if ok, retryAfter := limiter.allow("email:" + resetKey(req.Email)); !ok {
+			writeRateLimited(w, retryAfter)
+			return
+		}
+
+		// Failures are only logged: a 500 for existing accounts alone would
+		// reveal which addresses have one
+		if err := m.ForgotPassword(r.Context(), req.Email); err != nil {
+			log.Printf("❌ Password reset for %s failed: %v", req.Email, err)
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+func (m *Manager) confirmForgotPasswordHandler(limiter *rateLimiter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if ok, retryAfter := limiter.allow("ip:" + clientIP(r)); !ok {
+			writeRateLimited(w, retryAfter)
+			return
+		}
+
+		var req struct {
+			Email       string `json:"email"`
+			Code        string `json:"code"`
+			NewPassword string `json:"newPassword"`
+		}
+		if !decodeJSONBody(w, r, &req) {
+			return
+		}
+		if req.Email != "" {
+			if ok, retryAfter := limiter.allow("email:" + resetKey(req.Email)); !ok {
+				writeRateLimited(w, retryAfter)
+				return
+			}
+		}
+
+		err := m.ConfirmForgotPassword(r.Context(), req.Email, req.Code, req.NewPassword)
+		switch {
+		case errors.Is(err, ErrInvalidResetCode):
+			writeError(w, http.StatusBadRequest, "Invalid or expired code", nil)
+		case err != nil:
+			writeUserError(w, err)
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}
+}
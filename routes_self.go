@@ -0,0 +1,165 @@
+package user
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// SetupSelfServiceRoutes registers routes that let the caller manage their
+// own account, behind RequireAuthMiddleware. The account is always the one
+// in the caller's Claims; no route accepts a user identifier. Routes that
+// issue API keys or change the password refuse callers authenticated with an
+// API key.
+//
+//	PATCH /api/auth/profile        - Update own profile fields
+//	GET   /api/auth/api-key        - Metadata of own default API key
+//	POST  /api/auth/api-key        - Generate a new default API key
+//	POST  /api/auth/api-key/rotate - Rotate the default API key (old key keeps a grace period)
+//	PUT   /api/auth/password       - Change own password {"currentPassword": "...", "newPassword": "..."}
+func (m *Manager) SetupSelfServiceRoutes(r chi.Router) {
+	r.Group(func(r chi.Router) {
+		r.Use(m.RequireAuthMiddleware())
+		m.setupSelfServiceRoutes(r)
+	})
+}
+
+// setupSelfServiceRoutes registers the self-service routes without
+// authentication, for SetupSelfServiceRoutes and tests.
+func (m *Manager) setupSelfServiceRoutes(r chi.Router) {
+	r.Patch("/api/auth/profile", m.handleUpdateOwnProfile)
+	r.Get("/api/auth/api-key", m.handleGetOwnAPIKey)
+	r.Post("/api/auth/api-key", m.handleGenerateOwnAPIKey)
+	r.Post("/api/auth/api-key/rotate", m.handleRotateOwnAPIKey)
+	r.Put("/api/auth/password", m.handleChangeOwnPassword)
+}
+
+// callerEmail returns the email of the authenticated caller, writing a 401
+// when the request carries no usable identity.
+func callerEmail(w http.ResponseWriter, r *http.Request) (string, bool) {
+	email, ok := GetUserIDFromContext(r)
+	if !ok || email == "" {
+		writeError(w, http.StatusUnauthorized, "Not authenticated", nil)
+		return "", false
+	}
+	return email, true
+}
+
+// interactiveCallerEmail is callerEmail for routes that must not be reachable
+// with an API key, so a key (however narrowly scoped) cannot be used to
+// escalate to full control of the account. action names what is refused.
+func interactiveCallerEmail(w http.ResponseWriter, r *http.Request, action string) (string, bool) {
+	email, ok := callerEmail(w, r)
+	if !ok {
+		return "", false
+	}
+	if claims, _ := GetClaimsFromContext(r); claims.Provider == "token" {
+		writeError(w, http.StatusForbidden, "forbidden", map[string]string{"reason": action + " require an interactive login"})
+		return "", false
+	}
+	return email, true
+}
+
+func (m *Manager) handleUpdateOwnProfile(w http.ResponseWriter, r *http.Request) {
+	email, ok := callerEmail(w, r)
+	if !ok {
+		return
+	}
+
+	var update ProfileUpdate
+	if !decodeJSONBody(w, r, &update) {
+		return
+	}
+
+	user, err := m.UpdateProfile(r.Context(), email, update)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, userResponse(user))
+}
+
+func (m *Manager) handleGetOwnAPIKey(w http.ResponseWriter, r *http.Request) {
+	email, ok := callerEmail(w, r)
+	if !ok {
+		return
+	}
+
+	keys, err := m.ListAPIKeys(r.Context(), email)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	for _, key := range keys {
+		if key.Name == defaultAPIKeyName {
+			writeJSON(w, http.StatusOK, key)
+			return
+		}
+	}
+	writeUserError(w, fmt.Errorf("default API key: %w", ErrAPIKeyNotFound))
+}
+
+func (m *Manager) handleGenerateOwnAPIKey(w http.ResponseWriter, r *http.Request) {
+	email, ok := interactiveCallerEmail(w, r, "API key changes")
+	if !ok {
+		return
+	}
+
+	apiKey, err := m.GenerateAPIKey(r.Context(), email)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]string{"apiKey": apiKey})
+}
+
+func (m *Manager) handleRotateOwnAPIKey(w http.ResponseWriter, r *http.Request) {
+	email, ok := interactiveCallerEmail(w, r, "API key changes")
+	if !ok {
+		return
+	}
+
+	apiKey, err := m.RotateAPIKey(r.Context(), email)
+	if err != nil {
+		writeUserError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"apiKey": apiKey})
+}
+
+// handleChangeOwnPassword sets a new permanent password for the caller once
+// the current password checks out, so a stolen session or token alone
+// cannot take over the account. Requests authenticated with an API key are
+// refused outright.
+func (m *Manager) handleChangeOwnPassword(w http.ResponseWriter, r *http.Request) {
+	email, ok := interactiveCallerEmail(w, r, "password changes")
+	if !ok {
+		return
+	}
+
+	var req struct {
+		CurrentPassword string `json:"currentPassword"`
+		NewPassword     string `json:"newPassword"`
+	}
+	if !decodeJSONBody(w, r, &req) {
+		return
+	}
+	if req.CurrentPassword == "" || req.NewPassword == "" {
+		writeError(w, http.StatusBadRequest, "currentPassword and newPassword are required", nil)
+		return
+	}
+
+	err := m.ChangePassword(r.Context(), email, req.CurrentPassword, req.NewPassword)
+	switch {
+	case errors.Is(err, ErrInvalidCredentials):
+		writeError(w, http.StatusForbidden, "forbidden", map[string]string{"reason": "current password is incorrect"})
+	case errors.Is(err, ErrPasswordResetRequired):
+		writeError(w, http.StatusForbidden, "Password reset required", nil)
+	case err != nil:
+		writeUserError(w, err)
+	default:
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
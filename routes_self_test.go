@@ -0,0 +1,196 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// newSelfServiceTestServer serves the self-service routes over a memory
+// store with two users. Requests authenticate as the email in X-Test-User.
+func newSelfServiceTestServer(t *testing.T) (http.Handler, *Manager, *MemoryUserStore) {
+	t.Helper()
+	store := NewMemoryUserStore()
+	m := newTestManager(t, &OAuthConfig{}, store, []CreateUserRequest{
+		{Email: "me@example.com", GivenName: "Original"},
+		{Email: "other@example.com", GivenName: "Original"},
+	})
+
+	r := chi.NewRouter()
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims := &Claims{Email: r.Header.Get("X-Test-User"), Provider: "cognito"}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), ClaimsKey, claims)))
+		})
+	})
+	m.setupSelfServiceRoutes(r)
+	return r, m, store
+}
+
+func selfServiceRequest(h http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("X-Test-User", "me@example.com")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	return w
+}
+
+func TestSelfService_UpdateProfile(t *testing.T) {
+	h, _, store := newSelfServiceTestServer(t)
+
+	// Identity comes from the claims only; an email in the body is ignored.
+	w := selfServiceRequest(h, http.MethodPatch, "/api/auth/profile", `{"givenName":"Changed","email":"other@example.com"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	ctx := context.Background()
+	me, _ := store.GetUser(ctx, "me@example.com")
+	other, _ := store.GetUser(ctx, "other@example.com")
+	if me.Attributes["given_name"] != "Changed" {
+		t.Errorf("expected own profile to change, got %v", me.Attributes)
+	}
+	if other.Attributes["given_name"] != "Original" {
+		t.Errorf("another user's profile changed: %v", other.Attributes)
+	}
+}
+
+func TestSelfService_APIKey(t *testing.T) {
+	h, m, _ := newSelfServiceTestServer(t)
+	ctx := context.Background()
+
+	if w := selfServiceRequest(h, http.MethodGet, "/api/auth/api-key", ""); w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 before a key exists, got %d", w.Code)
+	}
+
+	w := selfServiceRequest(h, http.MethodPost, "/api/auth/api-key", "")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("generate: expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var generated map[string]string
+	json.Unmarshal(w.Body.Bytes(), &generated)
+	if claims, err := m.FindUserByToken(ctx, generated["apiKey"]); err != nil || claims.Email != "me@example.com" {
+		t.Fatalf("expected generated key to authenticate me, got %+v (%v)", claims, err)
+	}
+
+	w = selfServiceRequest(h, http.MethodGet, "/api/auth/api-key", "")
+	if w.Code != http.StatusOK || strings.Contains(w.Body.String(), generated["apiKey"]) {
+		t.Errorf("expected key metadata without the raw key, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = selfServiceRequest(h, http.MethodPost, "/api/auth/api-key/rotate", "")
+	var rotated map[string]string
+	json.Unmarshal(w.Body.Bytes(), &rotated)
+	if w.Code != http.StatusOK || rotated["apiKey"] == "" || rotated["apiKey"] == generated["apiKey"] {
+		t.Errorf("expected a new key from rotate, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if keys, _ := m.ListAPIKeys(ctx, "other@example.com"); len(keys) != 0 {
+		t.Errorf("another user got API keys: %+v", keys)
+	}
+}
+
+func TestSelfService_ChangePassword(t *testing.T) {
+	h, _, store := newSelfServiceTestServer(t)
+	ctx := context.Background()
+	store.SetPassword(ctx, "me@example.com", "0ld-Passw0rd!", true)
+	store.SetPassword(ctx, "other@example.com", "0ld-Passw0rd!", true)
+
+	if w := selfServiceRequest(h, http.MethodPut, "/api/auth/password", `{"newPassword":"N3w-Passw0rd!"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 without currentPassword, got %d", w.Code)
+	}
+	if w := selfServiceRequest(h, http.MethodPut, "/api/auth/password", `{"currentPassword":"Wr0ng-Passw0rd!","newPassword":"N3w-Passw0rd!"}`); w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for a wrong current password, got %d", w.Code)
+	}
+	if pw, _ := store.Password("me@example.com"); pw != "0ld-Passw0rd!" {
+		t.Fatalf("password changed without the current password: %q", pw)
+	}
+
+	w := selfServiceRequest(h, http.MethodPut, "/api/auth/password", `{"currentPassword":"0ld-Passw0rd!","newPassword":"N3w-Passw0rd!"}`)
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d: %s", w.Code, w.Body.String())
+	}
+	if pw, _ := store.Password("me@example.com"); pw != "N3w-Passw0rd!" {
+		t.Errorf("expected password to change, got %q", pw)
+	}
+	if pw, _ := store.Password("other@example.com"); pw != "0ld-Passw0rd!" {
+		t.Error("another user's password was set")
+	}
+}
+
+func TestSetupAuthRoutes_SelfServiceIsOptIn(t *testing.T) {
+	m := NewManager(&OAuthConfig{
+		ClientID:    "client",
+		UserPoolID:  "us-east-1_test",
+		Region:      "us-east-1",
+		Domain:      "auth.example.com",
+		RedirectURI: "https://app.example.com/oauth2/idpresponse",
+		FrontEndURL: "https://app.example.com",
+		Scopes:      []string{"openid", "email"},
+	})
+
+	r := chi.NewRouter()
+	if err := m.SetupAuthRoutes(r); err != nil {
+		t.Fatalf("SetupAuthRoutes: %v", err)
+	}
+	if r.Match(chi.NewRouteContext(), http.MethodPut, "/api/auth/password") {
+		t.Error("expected SetupAuthRoutes not to register the self-service routes")
+	}
+
+	m.SetupSelfServiceRoutes(r)
+	if !r.Match(chi.NewRouteContext(), http.MethodPut, "/api/auth/password") {
+		t.Error("expected SetupSelfServiceRoutes to register PUT /api/auth/password")
+	}
+}
+
+func TestSelfService_ChangePasswordRejectsAPIKeys(t *testing.T) {
+	store := NewMemoryUserStore()
+	m := newTestManager(t, &OAuthConfig{}, store, []CreateUserRequest{{Email: "me@example.com"}})
+	apiKey, _ := m.GenerateAPIKey(context.Background(), "me@example.com")
+
+	r := chi.NewRouter()
+	m.SetupSelfServiceRoutes(r)
+
+	req := httptest.NewRequest(http.MethodPut, "/api/auth/password", strings.NewReader(`{"currentPassword":"anything","newPassword":"N3w-Passw0rd!"}`))
+	req.Header.Set("Authorization", "Bearer "+apiKey)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for API key auth, got %d", w.Code)
+	}
+	if pw, _ := store.Password("me@example.com"); pw != "" {
+		t.Error("password was changed with an API key")
+	}
+}
+
+func TestSelfService_APIKeyRoutesRejectScopedKeys(t *testing.T) {
+	store := NewMemoryUserStore()
+	m := newTestManager(t, &OAuthConfig{}, store, []CreateUserRequest{{Email: "me@example.com"}})
+	scoped, _, err := m.CreateAPIKey(context.Background(), "me@example.com", CreateAPIKeyRequest{Name: "ci", Scopes: []string{"read"}})
+	if err != nil {
+		t.Fatalf("CreateAPIKey: %v", err)
+	}
+
+	r := chi.NewRouter()
+	m.SetupSelfServiceRoutes(r)
+
+	for _, path := range []string{"/api/auth/api-key", "/api/auth/api-key/rotate"} {
+		req := httptest.NewRequest(http.MethodPost, path, nil)
+		req.Header.Set("Authorization", "Bearer "+scoped)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusForbidden {
+			t.Errorf("POST %s: expected 403 for a scoped API key, got %d", path, w.Code)
+		}
+	}
+
+	keys, _ := m.ListAPIKeys(context.Background(), "me@example.com")
+	if len(keys) != 1 {
+		t.Errorf("expected no keys to be minted with a scoped key, got %d keys", len(keys))
+	}
+}
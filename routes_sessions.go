@@ -0,0 +1,118 @@
+package user
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// sessionResponse is a Session as returned to its owner. The session ID is
+// a bearer credential, so sessions are identified by their PublicID.
+type sessionResponse struct {
+	ID        string    `json:"id"` // Session.PublicID
+	Username  string    `json:"username"`
+	Email     string    `json:"email"`
+	UserAgent string    `json:"userAgent,omitempty"`
+	IPAddress string    `json:"ipAddress,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	Current   bool      `json:"current"` // The session making the request
+}
+
+// setupSessionRoutes registers session management routes on an
+// authenticated /api/auth router.
+func (m *Manager) setupSessionRoutes(r chi.Router) {
+	r.Get("/sessions", m.handleListSessions)
+	r.Delete("/sessions", m.handleRevokeAllSessions)
+	r.Delete("/sessions/{id}", m.handleRevokeSession)
+}
+
+// handleListSessions lists the caller's active sessions.
+func (m *Manager) handleListSessions(w http.ResponseWriter, r *http.Request) {
+	claims, ok := GetClaimsFromContext(r)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "Not authenticated", nil)
+		return
+	}
+
+	sessions, err := m.ListSessions(r.Context(), claims.Username)
+	if err != nil {
+		log.Printf("❌ Failed to list sessions: %v", err)
+		writeError(w, http.StatusInternalServerError, "Failed to list sessions", nil)
+		return
+	}
+
+	response := make([]sessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		response = append(response, sessionResponse{
+			ID:        session.PublicID(),
+			Username:  session.Username,
+			Email:     session.Email,
+			UserAgent: session.UserAgent,
+			IPAddress: session.IPAddress,
+			CreatedAt: session.CreatedAt,
+			ExpiresAt: session.ExpiresAt,
+			Current:   session.ID == claims.SessionID,
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"sessions": response})
+}
+
+// handleRevokeSession revokes one of the caller's sessions, identified by
+// its PublicID.
+func (m *Manager) handleRevokeSession(w http.ResponseWriter, r *http.Request) {
+	claims, ok := GetClaimsFromContext(r)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "Not authenticated", nil)
+		return
+	}
+
+	sessionID, err := m.sessionIDByPublicID(r.Context(), claims.Username, GetIDFromURL(r))
+	if err == nil {
+		err = m.RevokeSession(r.Context(), claims.Username, sessionID)
+	}
+	if err != nil {
+		if errors.Is(err, ErrSessionNotFound) {
+			writeError(w, http.StatusNotFound, "Session not found", nil)
+			return
+		}
+		log.Printf("❌ Failed to revoke session: %v", err)
+		writeError(w, http.StatusInternalServerError, "Failed to revoke session", nil)
+		return
+	}
+
+	if sessionID == claims.SessionID {
+		m.clearSessionCookies(w)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleRevokeAllSessions logs the caller out everywhere, including the
+// current browser.
+func (m *Manager) handleRevokeAllSessions(w http.ResponseWriter, r *http.Request) {
+	claims, ok := GetClaimsFromContext(r)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "Not authenticated", nil)
+		return
+	}
+
+	revoked, err := m.RevokeAllSessions(r.Context(), claims.Username)
+	if err != nil {
+		log.Printf("❌ Failed to revoke sessions: %v", err)
+		writeError(w, http.StatusInternalServerError, "Failed to revoke sessions", nil)
+		return
+	}
+
+	m.clearSessionCookies(w)
+	writeJSON(w, http.StatusOK, map[string]int{"revoked": revoked})
+}
+
+// clearSessionCookies clears the JWT and session cookies of a revoked
+// session, with the cookie domain they were set with at login.
+func (m *Manager) clearSessionCookies(w http.ResponseWriter) {
+	w.Header().Add("Set-Cookie", CreateJWTCookie("", 0, cookieDomainFromConfig(m.requiredConfig())))
+	w.Header().Add("Set-Cookie", createSessionCookie(sessionCookieName, "", 0))
+}
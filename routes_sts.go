@@ -9,15 +9,15 @@ import (
 )
 
 // SetupSTSRoutes registers STS credential exchange routes.
-func SetupSTSRoutes(r chi.Router) {
+func (m *Manager) SetupSTSRoutes(r chi.Router) {
 	r.Group(func(r chi.Router) {
-		r.Use(RequireAuthMiddleware())
-		r.Get("/api/auth/sts-credentials", handleSTSCredentials)
+		r.Use(m.RequireAuthMiddleware())
+		r.Get("/api/auth/sts-credentials", m.handleSTSCredentials)
 	})
 }
 
 // handleSTSCredentials exchanges a Cognito ID token for temporary AWS credentials.
-func handleSTSCredentials(w http.ResponseWriter, r *http.Request) {
+func (m *Manager) handleSTSCredentials(w http.ResponseWriter, r *http.Request) {
 	// Get the ID token from the cookie
 	cookie, err := r.Cookie("id_token")
 	if err != nil || cookie.Value == "" {
@@ -27,7 +27,7 @@ func handleSTSCredentials(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get OAuth config
-	config := GetOAuthConfig()
+	config := m.Config()
 	if config == nil {
 		log.Printf("STS: OAuth config not set")
 		writeSTSError(w, "Server configuration error", http.StatusInternalServerError)
@@ -38,7 +38,7 @@ func handleSTSCredentials(w http.ResponseWriter, r *http.Request) {
 	// This allows dynamic role selection based on Cognito group membership.
 
 	// Exchange ID token for STS credentials
-	creds, err := GetSTSCredentials(r.Context(), cookie.Value, config)
+	creds, err := m.GetSTSCredentials(r.Context(), cookie.Value)
 	if err != nil {
 		log.Printf("STS: Failed to get credentials: %v", err)
 		writeSTSError(w, "Failed to obtain credentials", http.StatusInternalServerError)
@@ -0,0 +1,269 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// AttributeType is the value type enforced by an AttributeRule.
+type AttributeType string
+
+const (
+	AttributeTypeString  AttributeType = "string" // Default
+	AttributeTypeNumber  AttributeType = "number"
+	AttributeTypeBoolean AttributeType = "boolean" // "true" or "false"
+	AttributeTypeUUID    AttributeType = "uuid"
+)
+
+// AttributeRule constrains one user attribute. Rules are keyed in
+// OAuthConfig.AttributeSchema by the stored attribute name: "custom:tenantId",
+// "given_name", or the role attribute ("custom:role" by default).
+type AttributeRule struct {
+	Type             AttributeType `json:"type,omitempty"`
+	Required         bool          `json:"required,omitempty"`
+	RequiredForRoles []string      `json:"requiredForRoles,omitempty"` // Required only for users with one of these roles
+	Enum             []string      `json:"enum,omitempty"`             // Allowed values
+	Pattern          string        `json:"pattern,omitempty"`          // Regular expression the whole value must match
+	MaxLength        int           `json:"maxLength,omitempty"`        // In characters
+	Immutable        bool          `json:"immutable,omitempty"`        // Set at creation only
+}
+
+// FieldError is one attribute that failed schema validation.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError lists every attribute that failed schema validation. It
+// wraps ErrInvalidInput.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	parts := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		parts[i] = f.Field + " " + f.Message
+	}
+	return "validation failed: " + strings.Join(parts, "; ")
+}
+
+func (e *ValidationError) Unwrap() error {
+	return ErrInvalidInput
+}
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// attributeRule is an AttributeRule ready for validation.
+type attributeRule struct {
+	AttributeRule
+	pattern     *regexp.Regexp
+	requiredFor map[string]bool
+}
+
+// compileAttributeSchema checks schema and compiles its patterns.
+func compileAttributeSchema(schema map[string]AttributeRule) (map[string]*attributeRule, error) {
+	rules := make(map[string]*attributeRule, len(schema))
+	for name, rule := range schema {
+		if name == "" {
+			return nil, fmt.Errorf("attribute schema has an empty attribute name: %w", ErrInvalidInput)
+		}
+		switch rule.Type {
+		case "", AttributeTypeString, AttributeTypeNumber, AttributeTypeBoolean, AttributeTypeUUID:
+		default:
+			return nil, fmt.Errorf("attribute %q has unknown type %q: %w", name, rule.Type, ErrInvalidInput)
+		}
+		if rule.MaxLength < 0 {
+			return nil, fmt.Errorf("attribute %q has a negative maxLength: %w", name, ErrInvalidInput)
+		}
+
+		compiled := &attributeRule{AttributeRule: rule, requiredFor: make(map[string]bool)}
+		if rule.Pattern != "" {
+			pattern, err := regexp.Compile(`^(?:` + rule.Pattern + `)$`)
+			if err != nil {
+				return nil, fmt.Errorf("attribute %q has an invalid pattern: %v: %w", name, err, ErrInvalidInput)
+			}
+			compiled.pattern = pattern
+		}
+		for _, role := range rule.RequiredForRoles {
+			compiled.requiredFor[role] = true
+		}
+		rules[name] = compiled
+	}
+	return rules, nil
+}
+
+// attributeRules compiles the configured attribute schema; nil when there is none.
+func (m *Manager) attributeRules() (map[string]*attributeRule, error) {
+	config := m.Config()
+	if config == nil || len(config.AttributeSchema) == 0 {
+		return nil, nil
+	}
+	return compileAttributeSchema(config.AttributeSchema)
+}
+
+// validateAttributes checks a user's attributes against rules. before is the
+// stored attribute set (empty when creating) and changes the attributes being
+// written, where "" clears an attribute. Only changed values are checked
+// against type, enum, pattern and length, so users stored before a rule was
+// added can still be updated; required attributes are checked on creation,
+// when cleared, and when a role change makes them required.
+func validateAttributes(rules map[string]*attributeRule, roleAttr string, before, changes map[string]string, creating bool) error {
+	after := make(map[string]string, len(before)+len(changes))
+	for name, value := range before {
+		after[name] = value
+	}
+	for name, value := range changes {
+		after[name] = value
+	}
+	role := after[roleAttr]
+	if role == "" {
+		role = "user"
+	}
+	roleChanged := !creating && after[roleAttr] != before[roleAttr]
+
+	names := make([]string, 0, len(rules))
+	for name := range rules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var fields []FieldError
+	for _, name := range names {
+		rule := rules[name]
+		value := after[name]
+		changed := creating || value != before[name]
+
+		if !creating && changed && rule.Immutable {
+			fields = append(fields, FieldError{Field: name, Message: "cannot be changed"})
+			continue
+		}
+		if value == "" {
+			required := rule.Required || rule.requiredFor[role]
+			if required && (changed || (roleChanged && rule.requiredFor[role])) {
+				fields = append(fields, FieldError{Field: name, Message: "is required"})
+			}
+			continue
+		}
+		if changed {
+			if msg := rule.check(value); msg != "" {
+				fields = append(fields, FieldError{Field: name, Message: msg})
+			}
+		}
+	}
+
+	if len(fields) > 0 {
+		return &ValidationError{Fields: fields}
+	}
+	return nil
+}
+
+// check returns why value violates the rule, or "".
+func (r *attributeRule) check(value string) string {
+	if r.MaxLength > 0 && utf8.RuneCountInString(value) > r.MaxLength {
+		return fmt.Sprintf("must be at most %d characters", r.MaxLength)
+	}
+
+	switch r.Type {
+	case AttributeTypeNumber:
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return "must be a number"
+		}
+	case AttributeTypeBoolean:
+		if value != "true" && value != "false" {
+			return "must be true or false"
+		}
+	case AttributeTypeUUID:
+		if !uuidPattern.MatchString(value) {
+			return "must be a UUID"
+		}
+	}
+
+	if len(r.Enum) > 0 {
+		allowed := false
+		for _, v := range r.Enum {
+			if v == value {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return "must be one of " + strings.Join(r.Enum, ", ")
+		}
+	}
+
+	if r.pattern != nil && !r.pattern.MatchString(value) {
+		return "does not match the required format"
+	}
+	return ""
+}
+
+// validateNewUserAttributes checks the attributes of a user being created.
+func (m *Manager) validateNewUserAttributes(attributes map[string]string) error {
+	rules, err := m.attributeRules()
+	if err != nil || rules == nil {
+		return err
+	}
+	return validateAttributes(rules, m.roleAttributeName(), nil, attributes, true)
+}
+
+// updateAttributes validates changes against the attribute schema, writes
+// them and returns the updated user. Cached claims for the user are dropped,
+// since role, tenant and custom attributes all feed into them.
+func (m *Manager) updateAttributes(ctx context.Context, email string, changes map[string]string) (*User, error) {
+	store, err := m.userStore()
+	if err != nil {
+		return nil, err
+	}
+	if err := m.validateAttributeChanges(ctx, store, email, changes); err != nil {
+		return nil, err
+	}
+
+	if err := store.UpdateAttributes(ctx, email, changes); err != nil {
+		return nil, err
+	}
+	m.tokenCache.invalidateUser(email)
+
+	return m.GetUser(ctx, email)
+}
+
+// deleteAttributes is updateAttributes for removing the named attributes.
+func (m *Manager) deleteAttributes(ctx context.Context, email string, names []string) (*User, error) {
+	store, err := m.userStore()
+	if err != nil {
+		return nil, err
+	}
+	cleared := make(map[string]string, len(names))
+	for _, name := range names {
+		cleared[name] = ""
+	}
+	if err := m.validateAttributeChanges(ctx, store, email, cleared); err != nil {
+		return nil, err
+	}
+
+	if err := deleteStoreAttributes(ctx, store, email, names); err != nil {
+		return nil, err
+	}
+	m.tokenCache.invalidateUser(email)
+
+	return m.GetUser(ctx, email)
+}
+
+// validateAttributeChanges checks changes to an existing user against the
+// attribute schema, if one is configured.
+func (m *Manager) validateAttributeChanges(ctx context.Context, store UserStore, email string, changes map[string]string) error {
+	rules, err := m.attributeRules()
+	if err != nil || rules == nil {
+		return err
+	}
+	record, err := store.GetUser(ctx, email)
+	if err != nil {
+		return err
+	}
+	return validateAttributes(rules, m.roleAttributeName(), record.Attributes, changes, false)
+}
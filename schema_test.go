@@ -0,0 +1,176 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const testTenantID = "0b6f1c2e-8d4a-4f3e-9c1b-2a7d5e6f8a90"
+
+func testAttributeSchema() map[string]AttributeRule {
+	return map[string]AttributeRule{
+		"custom:tenantId":          {Type: AttributeTypeUUID, Required: true, Immutable: true},
+		"custom:role":              {Enum: []string{"user", "provider", "admin"}},
+		"custom:serviceProviderId": {RequiredForRoles: []string{"provider"}, Pattern: `sp-[0-9]+`},
+		"custom:seats":             {Type: AttributeTypeNumber},
+		"given_name":               {MaxLength: 5},
+	}
+}
+
+func newSchemaTestManager(t *testing.T) (*Manager, *MemoryUserStore) {
+	t.Helper()
+	store := NewMemoryUserStore()
+	m := newTestManager(t, &OAuthConfig{AttributeSchema: testAttributeSchema()}, store, []CreateUserRequest{{
+		Email:            "alice@example.com",
+		CustomAttributes: map[string]string{"tenantId": testTenantID},
+	}})
+	return m, store
+}
+
+// fieldErrors returns the field -> message map of a ValidationError.
+func fieldErrors(t *testing.T, err error) map[string]string {
+	t.Helper()
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected a ValidationError, got %v", err)
+	}
+	if !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("expected the ValidationError to wrap ErrInvalidInput")
+	}
+	fields := make(map[string]string)
+	for _, f := range validationErr.Fields {
+		fields[f.Field] = f.Message
+	}
+	return fields
+}
+
+func TestCompileAttributeSchema_RejectsBadRules(t *testing.T) {
+	for name, schema := range map[string]map[string]AttributeRule{
+		"unknown type": {"custom:a": {Type: "date"}},
+		"bad pattern":  {"custom:a": {Pattern: "("}},
+		"negative max": {"custom:a": {MaxLength: -1}},
+		"empty name":   {"": {}},
+	} {
+		if _, err := compileAttributeSchema(schema); !errors.Is(err, ErrInvalidInput) {
+			t.Errorf("%s: expected ErrInvalidInput, got %v", name, err)
+		}
+	}
+}
+
+func TestAttributeSchema_CreateReportsEveryField(t *testing.T) {
+	m, _ := newSchemaTestManager(t)
+
+	_, err := m.CreateUser(context.Background(), CreateUserRequest{
+		Email:            "bob@example.com",
+		GivenName:        "Roberto",
+		Role:             "provider",
+		CustomAttributes: map[string]string{"tenantId": "acme", "seats": "many"},
+	})
+	want := map[string]string{
+		"custom:tenantId":          "must be a UUID",
+		"custom:serviceProviderId": "is required",
+		"custom:seats":             "must be a number",
+		"given_name":               "must be at most 5 characters",
+	}
+	if got := fieldErrors(t, err); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	_, _, err = m.CreateUserWithInvitation(context.Background(), CreateUserRequest{Email: "carol@example.com", Role: "root"})
+	want = map[string]string{"custom:tenantId": "is required", "custom:role": "must be one of user, provider, admin"}
+	if got := fieldErrors(t, err); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	if _, err := m.CreateUser(context.Background(), CreateUserRequest{
+		Email:            "dave@example.com",
+		Role:             "provider",
+		CustomAttributes: map[string]string{"tenantId": testTenantID, "serviceProviderId": "sp-7"},
+	}); err != nil {
+		t.Errorf("expected a valid user to be created, got %v", err)
+	}
+}
+
+func TestAttributeSchema_Updates(t *testing.T) {
+	m, _ := newSchemaTestManager(t)
+	ctx := context.Background()
+
+	_, err := m.UpdateRole(ctx, "alice@example.com", "provider")
+	if got := fieldErrors(t, err); got["custom:serviceProviderId"] != "is required" {
+		t.Errorf("expected the role change to require a service provider, got %v", got)
+	}
+	_, err = m.UpdateRole(ctx, "alice@example.com", "root")
+	if got := fieldErrors(t, err); got["custom:role"] == "" {
+		t.Errorf("expected an enum error, got %v", got)
+	}
+
+	_, err = m.UpdateTenantID(ctx, "alice@example.com", "1b6f1c2e-8d4a-4f3e-9c1b-2a7d5e6f8a90")
+	if got := fieldErrors(t, err); got["custom:tenantId"] != "cannot be changed" {
+		t.Errorf("expected tenantId to be immutable, got %v", got)
+	}
+	_, err = m.DeleteCustomAttributes(ctx, "alice@example.com", "tenantId")
+	if got := fieldErrors(t, err); got["custom:tenantId"] != "cannot be changed" {
+		t.Errorf("expected tenantId to be immutable, got %v", got)
+	}
+
+	_, err = m.UpdateCustomAttributes(ctx, "alice@example.com", map[string]string{"seats": "x", "serviceProviderId": "nope"})
+	want := map[string]string{"custom:seats": "must be a number", "custom:serviceProviderId": "does not match the required format"}
+	if got := fieldErrors(t, err); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	given := "Alexandra"
+	_, err = m.UpdateProfile(ctx, "alice@example.com", ProfileUpdate{GivenName: &given})
+	if got := fieldErrors(t, err); got["given_name"] == "" {
+		t.Errorf("expected a length error, got %v", got)
+	}
+
+	if _, err := m.UpdateCustomAttributes(ctx, "alice@example.com", map[string]string{"serviceProviderId": "sp-1"}); err != nil {
+		t.Fatalf("UpdateCustomAttributes: %v", err)
+	}
+	if user, err := m.UpdateRole(ctx, "alice@example.com", "provider"); err != nil || user.Role != "provider" {
+		t.Errorf("expected the role change to pass once the service provider is set, got %v", err)
+	}
+}
+
+func TestAttributeSchema_LegacyUsersCanStillBeUpdated(t *testing.T) {
+	m, store := newSchemaTestManager(t)
+	ctx := context.Background()
+	// Stored before the schema existed: no tenant, invalid seats.
+	store.CreateUser(ctx, "legacy@example.com", map[string]string{"email": "legacy@example.com", "custom:seats": "lots"})
+
+	given := "Leo"
+	if _, err := m.UpdateProfile(ctx, "legacy@example.com", ProfileUpdate{GivenName: &given}); err != nil {
+		t.Errorf("expected an unrelated update to pass, got %v", err)
+	}
+}
+
+func TestAttributeSchema_AdminRouteReturnsFieldErrors(t *testing.T) {
+	m, _ := newSchemaTestManager(t)
+	ctx := context.Background()
+	if _, err := m.CreateUser(ctx, CreateUserRequest{Email: "admin@example.com", Role: "admin", CustomAttributes: map[string]string{"tenantId": testTenantID}}); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	key, err := m.GenerateAPIKey(ctx, "admin@example.com")
+	if err != nil {
+		t.Fatalf("GenerateAPIKey: %v", err)
+	}
+	r := chi.NewRouter()
+	m.SetupAdminUserRoutes(r)
+
+	w := adminRequest(t, r, key, http.MethodPost, "/api/admin/users", `{"email":"new@example.com","customAttributes":{"tenantId":"acme"}}`)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp ErrorResponse
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp.Details["custom:tenantId"] != "must be a UUID" {
+		t.Errorf("expected field-level details, got %+v", resp)
+	}
+}
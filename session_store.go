@@ -0,0 +1,248 @@
+package user
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Session is a server-side login session. It holds the refresh token so the
+// browser only carries an opaque session ID.
+type Session struct {
+	ID           string    `json:"id"`
+	Username     string    `json:"username"`
+	Email        string    `json:"email"`
+	RefreshToken string    `json:"refreshToken,omitempty"` // Never returned by ListSessions
+	UserAgent    string    `json:"userAgent,omitempty"`    // User-Agent of the login request
+	IPAddress    string    `json:"ipAddress,omitempty"`    // Remote address of the login request
+	CreatedAt    time.Time `json:"createdAt"`
+	ExpiresAt    time.Time `json:"expiresAt"`
+}
+
+// PublicID identifies the session to its owner without revealing ID, which
+// is a bearer credential. It is a truncated SHA-256 of ID.
+func (s *Session) PublicID() string {
+	sum := sha256.Sum256([]byte(s.ID))
+	return hex.EncodeToString(sum[:16])
+}
+
+func (s *Session) expired(now time.Time) bool {
+	return !s.ExpiresAt.IsZero() && !now.Before(s.ExpiresAt)
+}
+
+// SessionStore persists server-side sessions. GetSession returns an error
+// wrapping ErrSessionNotFound for unknown or expired sessions; deleting a
+// session revokes it.
+type SessionStore interface {
+	SaveSession(ctx context.Context, session *Session) error
+	GetSession(ctx context.Context, id string) (*Session, error)
+	DeleteSession(ctx context.Context, id string) error
+	// ListSessions returns the user's unexpired sessions, oldest first.
+	ListSessions(ctx context.Context, username string) ([]*Session, error)
+}
+
+// MemorySessionStore is an in-process SessionStore. Sessions are lost on
+// restart, so it suits single-instance deployments and tests.
+type MemorySessionStore struct {
+	mu       sync.RWMutex
+	sessions map[string]Session
+}
+
+// NewMemorySessionStore creates an empty in-memory session store.
+func NewMemorySessionStore() *MemorySessionStore {
+	return &MemorySessionStore{sessions: make(map[string]Session)}
+}
+
+func (s *MemorySessionStore) SaveSession(ctx context.Context, session *Session) error {
+	if session == nil || session.ID == "" {
+		return fmt.Errorf("session ID is required: %w", ErrInvalidInput)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sessions[session.ID] = *session
+	return nil
+}
+
+func (s *MemorySessionStore) GetSession(ctx context.Context, id string) (*Session, error) {
+	s.mu.RLock()
+	session, ok := s.sessions[id]
+	s.mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("get session: %w", ErrSessionNotFound)
+	}
+	if session.expired(time.Now()) {
+		s.DeleteSession(ctx, id)
+		return nil, fmt.Errorf("get session: %w", ErrSessionNotFound)
+	}
+	return &session, nil
+}
+
+func (s *MemorySessionStore) DeleteSession(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.sessions, id)
+	return nil
+}
+
+func (s *MemorySessionStore) ListSessions(ctx context.Context, username string) ([]*Session, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return userSessions(s.sessions, username, time.Now()), nil
+}
+
+// userSessions returns copies of username's unexpired sessions, oldest first.
+func userSessions(sessions map[string]Session, username string, now time.Time) []*Session {
+	var result []*Session
+	for _, session := range sessions {
+		if session.Username != username || session.expired(now) {
+			continue
+		}
+		session := session
+		result = append(result, &session)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+	return result
+}
+
+// FileSessionStore is a SessionStore persisted to a single JSON file, so
+// sessions survive restarts of a single-instance deployment. The file holds
+// refresh tokens and is written with 0600 permissions. It is not safe for
+// several processes sharing one file.
+type FileSessionStore struct {
+	mu       sync.Mutex
+	path     string
+	sessions map[string]Session
+}
+
+// NewFileSessionStore opens (or creates) the session file at path.
+func NewFileSessionStore(path string) (*FileSessionStore, error) {
+	s := &FileSessionStore{path: path, sessions: make(map[string]Session)}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read session file: %w", err)
+	}
+	if len(data) > 0 {
+		var sessions []Session
+		if err := json.Unmarshal(data, &sessions); err != nil {
+			return nil, fmt.Errorf("failed to parse session file %s: %w", path, err)
+		}
+		for _, session := range sessions {
+			s.sessions[session.ID] = session
+		}
+	}
+
+	return s, nil
+}
+
+func (s *FileSessionStore) SaveSession(ctx context.Context, session *Session) error {
+	if session == nil || session.ID == "" {
+		return fmt.Errorf("session ID is required: %w", ErrInvalidInput)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, existed := s.sessions[session.ID]
+	s.sessions[session.ID] = *session
+	if err := s.persist(); err != nil {
+		if existed {
+			s.sessions[session.ID] = previous
+		} else {
+			delete(s.sessions, session.ID)
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *FileSessionStore) GetSession(ctx context.Context, id string) (*Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session, ok := s.sessions[id]
+	if !ok || session.expired(time.Now()) {
+		return nil, fmt.Errorf("get session: %w", ErrSessionNotFound)
+	}
+	return &session, nil
+}
+
+func (s *FileSessionStore) DeleteSession(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous, ok := s.sessions[id]
+	if !ok {
+		return nil
+	}
+	delete(s.sessions, id)
+	if err := s.persist(); err != nil {
+		s.sessions[id] = previous
+		return err
+	}
+	return nil
+}
+
+func (s *FileSessionStore) ListSessions(ctx context.Context, username string) ([]*Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return userSessions(s.sessions, username, time.Now()), nil
+}
+
+// persist writes all unexpired sessions to a temp file and renames it over
+// the session file, so a crash never leaves a truncated file behind.
+// Callers hold s.mu.
+func (s *FileSessionStore) persist() error {
+	now := time.Now()
+	sessions := make([]Session, 0, len(s.sessions))
+	for id, session := range s.sessions {
+		if session.expired(now) {
+			delete(s.sessions, id)
+			continue
+		}
+		sessions = append(sessions, session)
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].ID < sessions[j].ID })
+
+	data, err := json.Marshal(sessions)
+	if err != nil {
+		return fmt.Errorf("failed to encode sessions: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to write session file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(0o600); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write session file: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write session file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write session file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to write session file: %w", err)
+	}
+	return nil
+}
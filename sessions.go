@@ -0,0 +1,115 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"log"
+)
+
+// SetSessionStore enables server-side sessions on the default Manager.
+// See WithSessionStore.
+func SetSessionStore(store SessionStore) {
+	defaultManager.sessions = store
+}
+
+func (m *Manager) sessionStore() (SessionStore, error) {
+	if m.sessions == nil {
+		return nil, fmt.Errorf("session store is not configured")
+	}
+	return m.sessions, nil
+}
+
+// ListSessions returns the user's active sessions, oldest first. Refresh
+// tokens are never included.
+func (m *Manager) ListSessions(ctx context.Context, username string) ([]*Session, error) {
+	if username == "" {
+		return nil, fmt.Errorf("username is required: %w", ErrInvalidInput)
+	}
+
+	store, err := m.sessionStore()
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, err := store.ListSessions(ctx, username)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	for _, session := range sessions {
+		session.RefreshToken = ""
+	}
+	return sessions, nil
+}
+
+// RevokeSession revokes one of the user's sessions. Requests carrying it are
+// rejected by RequireAuthMiddleware and it can no longer be refreshed.
+func (m *Manager) RevokeSession(ctx context.Context, username, sessionID string) error {
+	if username == "" || sessionID == "" {
+		return fmt.Errorf("username and sessionID are required: %w", ErrInvalidInput)
+	}
+
+	store, err := m.sessionStore()
+	if err != nil {
+		return err
+	}
+
+	session, err := store.GetSession(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	// Do not reveal whether another user's session exists
+	if session.Username != username {
+		return fmt.Errorf("get session: %w", ErrSessionNotFound)
+	}
+
+	if err := store.DeleteSession(ctx, sessionID); err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+
+	log.Printf("✅ Revoked session for %s", username)
+	return nil
+}
+
+// sessionIDByPublicID returns the ID of the user's session whose PublicID is
+// publicID.
+func (m *Manager) sessionIDByPublicID(ctx context.Context, username, publicID string) (string, error) {
+	sessions, err := m.ListSessions(ctx, username)
+	if err != nil {
+		return "", err
+	}
+	for _, session := range sessions {
+		if publicID != "" && session.PublicID() == publicID {
+			return session.ID, nil
+		}
+	}
+	return "", fmt.Errorf("get session: %w", ErrSessionNotFound)
+}
+
+// RevokeAllSessions revokes every session of the user ("log out
+// everywhere") and returns how many were revoked.
+func (m *Manager) RevokeAllSessions(ctx context.Context, username string) (int, error) {
+	if username == "" {
+		return 0, fmt.Errorf("username is required: %w", ErrInvalidInput)
+	}
+
+	store, err := m.sessionStore()
+	if err != nil {
+		return 0, err
+	}
+
+	sessions, err := store.ListSessions(ctx, username)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	revoked := 0
+	for _, session := range sessions {
+		if err := store.DeleteSession(ctx, session.ID); err != nil {
+			return revoked, fmt.Errorf("failed to revoke session: %w", err)
+		}
+		revoked++
+	}
+
+	log.Printf("✅ Revoked %d sessions for %s", revoked, username)
+	return revoked, nil
+}
@@ -0,0 +1,393 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func newTestSession(id, username string, createdAt time.Time) *Session {
+	return &Session{
+		ID:           id,
+		Username:     username,
+		Email:        username + "@example.com",
+		RefreshToken: "refresh-" + id,
+		CreatedAt:    createdAt,
+		ExpiresAt:    createdAt.Add(time.Hour),
+	}
+}
+
+func TestFileSessionStore_PersistsAcrossReopen(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "sessions.json")
+
+	store, err := NewFileSessionStore(path)
+	if err != nil {
+		t.Fatalf("NewFileSessionStore: %v", err)
+	}
+	now := time.Now()
+	for _, session := range []*Session{
+		newTestSession("s2", "alice", now),
+		newTestSession("s1", "alice", now.Add(-time.Minute)),
+		newTestSession("s3", "bob", now),
+		{ID: "old", Username: "alice", CreatedAt: now.Add(-2 * time.Hour), ExpiresAt: now.Add(-time.Hour)},
+	} {
+		if err := store.SaveSession(ctx, session); err != nil {
+			t.Fatalf("SaveSession: %v", err)
+		}
+	}
+	if err := store.DeleteSession(ctx, "s3"); err != nil {
+		t.Fatalf("DeleteSession: %v", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("expected session file mode 0600, got %o", perm)
+	}
+
+	reopened, err := NewFileSessionStore(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	got, err := reopened.GetSession(ctx, "s1")
+	if err != nil || got.RefreshToken != "refresh-s1" {
+		t.Fatalf("expected s1 after reopen, got %+v (%v)", got, err)
+	}
+	if _, err := reopened.GetSession(ctx, "s3"); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("expected deleted session to stay deleted, got %v", err)
+	}
+
+	sessions, err := reopened.ListSessions(ctx, "alice")
+	if err != nil {
+		t.Fatalf("ListSessions: %v", err)
+	}
+	if len(sessions) != 2 || sessions[0].ID != "s1" || sessions[1].ID != "s2" {
+		t.Errorf("expected alice's unexpired sessions oldest first, got %+v", sessions)
+	}
+}
+
+func TestManager_RevokeSessions(t *testing.T) {
+	ctx := context.Background()
+	sessions := NewMemorySessionStore()
+	m := NewManager(&OAuthConfig{}, WithSessionStore(sessions))
+
+	now := time.Now()
+	for _, session := range []*Session{
+		newTestSession("a1", "alice", now.Add(-time.Minute)),
+		newTestSession("a2", "alice", now),
+		newTestSession("b1", "bob", now),
+	} {
+		sessions.SaveSession(ctx, session)
+	}
+
+	listed, err := m.ListSessions(ctx, "alice")
+	if err != nil {
+		t.Fatalf("ListSessions: %v", err)
+	}
+	if len(listed) != 2 {
+		t.Fatalf("expected 2 sessions, got %d", len(listed))
+	}
+	for _, session := range listed {
+		if session.RefreshToken != "" {
+			t.Errorf("ListSessions leaked the refresh token of %s", session.ID)
+		}
+	}
+
+	if err := m.RevokeSession(ctx, "alice", "b1"); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("expected ErrSessionNotFound revoking another user's session, got %v", err)
+	}
+	if err := m.RevokeSession(ctx, "alice", "a1"); err != nil {
+		t.Fatalf("RevokeSession: %v", err)
+	}
+	if _, err := sessions.GetSession(ctx, "a1"); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("expected a1 to be revoked, got %v", err)
+	}
+
+	revoked, err := m.RevokeAllSessions(ctx, "alice")
+	if err != nil || revoked != 1 {
+		t.Errorf("expected 1 revoked session, got %d (%v)", revoked, err)
+	}
+	if _, err := sessions.GetSession(ctx, "b1"); err != nil {
+		t.Errorf("expected bob's session to survive, got %v", err)
+	}
+
+	if _, err := NewManager(&OAuthConfig{}).ListSessions(ctx, "alice"); err == nil {
+		t.Error("expected error without a session store")
+	}
+}
+
+func TestCallbackHandler_StartsSessionWithoutRefreshToken(t *testing.T) {
+	sessions := NewMemorySessionStore()
+	service := &fakeTokenService{idToken: createTestJWT("alice@example.com", "")}
+	h := newTestRefreshHandlers(service, NewSessionRefreshTokenStore(sessions, 0))
+
+	req := httptest.NewRequest(http.MethodGet, "/oauth2/idpresponse?code=abc&state=xyz", nil)
+	req.Header.Set("User-Agent", "test-browser")
+	w := httptest.NewRecorder()
+	h.CallbackHandler(w, req)
+
+	sessionCookie := responseCookies(w)[sessionCookieName]
+	if sessionCookie == nil {
+		t.Fatal("expected session cookie")
+	}
+	session, err := sessions.GetSession(context.Background(), sessionCookie.Value)
+	if err != nil {
+		t.Fatalf("GetSession: %v", err)
+	}
+	if session.Username != "alice" || session.UserAgent != "test-browser" || session.IPAddress == "" {
+		t.Errorf("unexpected session: %+v", session)
+	}
+}
+
+func TestRequireAuthMiddleware_RejectsRevokedSession(t *testing.T) {
+	ctx := context.Background()
+	sessions := NewMemorySessionStore()
+	sessions.SaveSession(ctx, newTestSession("s1", "alice", time.Now()))
+
+	m := NewManager(&OAuthConfig{}, WithSessionStore(sessions))
+	m.refresher = &fakeTokenService{idToken: createTestJWT("alice@example.com", "")}
+
+	var gotSessionID string
+	handler := m.RequireAuthMiddleware(WithTransparentRefresh())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, _ := GetClaimsFromContext(r)
+		gotSessionID = claims.SessionID
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	serve := func() int {
+		req := httptest.NewRequest(http.MethodGet, "/api/things", nil)
+		req.AddCookie(&http.Cookie{Name: sessionCookieName, Value: "s1"})
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := serve(); code != http.StatusOK {
+		t.Fatalf("expected 200 for a live session, got %d", code)
+	}
+	if gotSessionID != "s1" {
+		t.Errorf("expected Claims.SessionID s1, got %q", gotSessionID)
+	}
+
+	if err := m.RevokeSession(ctx, "alice", "s1"); err != nil {
+		t.Fatalf("RevokeSession: %v", err)
+	}
+	if code := serve(); code != http.StatusUnauthorized {
+		t.Errorf("expected 401 after revocation, got %d", code)
+	}
+}
+
+func TestBindSession(t *testing.T) {
+	ctx := context.Background()
+	sessions := NewMemorySessionStore()
+	sessions.SaveSession(ctx, newTestSession("s1", "alice", time.Now()))
+	m := NewManager(&OAuthConfig{}, WithSessionStore(sessions))
+
+	tests := []struct {
+		name     string
+		cookie   string
+		username string
+		wantErr  bool
+	}{
+		{"live session", "s1", "alice", false},
+		{"no session cookie", "", "alice", true},
+		{"unknown session", "nope", "alice", true},
+		{"another user's session", "s1", "mallory", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: sessionCookieName, Value: tt.cookie})
+			}
+			claims := &Claims{Username: tt.username}
+			err := m.bindSession(req, claims)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("expected error=%v, got %v", tt.wantErr, err)
+			}
+			if err != nil && !errors.Is(err, ErrSessionNotFound) {
+				t.Errorf("expected ErrSessionNotFound, got %v", err)
+			}
+		})
+	}
+
+	if err := NewManager(&OAuthConfig{}).bindSession(httptest.NewRequest(http.MethodGet, "/", nil), &Claims{}); err != nil {
+		t.Errorf("expected no-op without a session store, got %v", err)
+	}
+}
+
+func TestSessionRoutes(t *testing.T) {
+	ctx := context.Background()
+	sessions := NewMemorySessionStore()
+	now := time.Now()
+	sessions.SaveSession(ctx, newTestSession("s1", "alice", now.Add(-time.Minute)))
+	sessions.SaveSession(ctx, newTestSession("s2", "alice", now))
+	m := NewManager(&OAuthConfig{}, WithSessionStore(sessions))
+
+	withClaims := func(req *http.Request) *http.Request {
+		claims := &Claims{Username: "alice", SessionID: "s2"}
+		return req.WithContext(context.WithValue(req.Context(), ClaimsKey, claims))
+	}
+
+	w := httptest.NewRecorder()
+	m.handleListSessions(w, withClaims(httptest.NewRequest(http.MethodGet, "/api/auth/sessions", nil)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	if strings.Contains(w.Body.String(), "refresh-") {
+		t.Error("session list leaked a refresh token")
+	}
+	var listed struct {
+		Sessions []struct {
+			ID      string `json:"id"`
+			Current bool   `json:"current"`
+		} `json:"sessions"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &listed)
+	if len(listed.Sessions) != 2 || listed.Sessions[0].Current || !listed.Sessions[1].Current {
+		t.Errorf("expected s2 marked current, got %+v", listed.Sessions)
+	}
+
+	w = httptest.NewRecorder()
+	m.handleRevokeAllSessions(w, withClaims(httptest.NewRequest(http.MethodDelete, "/api/auth/sessions", nil)))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"revoked":2`) {
+		t.Errorf("expected 2 revoked sessions, got %d %s", w.Code, w.Body.String())
+	}
+	cookies := responseCookies(w)
+	if c := cookies["jwt"]; c == nil || c.MaxAge >= 0 {
+		t.Errorf("expected jwt cookie to be cleared, got %+v", c)
+	}
+	if c := cookies[sessionCookieName]; c == nil || c.MaxAge >= 0 {
+		t.Errorf("expected session cookie to be cleared, got %+v", c)
+	}
+}
+
+func TestSessionRoutes_UsePublicIDs(t *testing.T) {
+	ctx := context.Background()
+	sessions := NewMemorySessionStore()
+	now := time.Now()
+	sessions.SaveSession(ctx, newTestSession("s1", "alice", now.Add(-time.Minute)))
+	sessions.SaveSession(ctx, newTestSession("s2", "alice", now))
+	m := NewManager(&OAuthConfig{}, WithSessionStore(sessions))
+
+	r := chi.NewRouter()
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims := &Claims{Username: "alice", SessionID: "s2"}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), ClaimsKey, claims)))
+		})
+	})
+	r.Route("/api/auth", m.setupSessionRoutes)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/auth/sessions", nil))
+	var listed struct {
+		Sessions []struct {
+			ID string `json:"id"`
+		} `json:"sessions"`
+	}
+	json.Unmarshal(w.Body.Bytes(), &listed)
+	if len(listed.Sessions) != 2 {
+		t.Fatalf("expected 2 sessions, got %s", w.Body.String())
+	}
+	if strings.Contains(w.Body.String(), `"s1"`) || strings.Contains(w.Body.String(), `"s2"`) {
+		t.Errorf("session list leaked a session ID: %s", w.Body.String())
+	}
+
+	// The raw session ID no longer addresses a session.
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/api/auth/sessions/s1", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for a raw session ID, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/api/auth/sessions/"+listed.Sessions[0].ID, nil))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d %s", w.Code, w.Body.String())
+	}
+	if _, err := sessions.GetSession(ctx, "s1"); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("expected s1 to be revoked, got %v", err)
+	}
+	if _, err := sessions.GetSession(ctx, "s2"); err != nil {
+		t.Errorf("expected s2 to remain, got %v", err)
+	}
+}
+
+func TestSessionRoutes_ClearCookiesLikeLogin(t *testing.T) {
+	ctx := context.Background()
+	sessions := NewMemorySessionStore()
+	sessions.SaveSession(ctx, newTestSession("s1", "alice", time.Now()))
+	m := NewManager(&OAuthConfig{FrontEndURL: "https://app.example.com"}, WithSessionStore(sessions))
+
+	login := httptest.NewRecorder()
+	m.setLoginCookies(login, httptest.NewRequest(http.MethodPost, "/api/auth/login", nil), &LoginResult{
+		Claims: &Claims{Username: "alice"},
+		Tokens: &OAuthTokens{IDToken: createTestJWT("alice@example.com", "")},
+	})
+	set := responseCookies(login)["jwt"]
+	if set == nil {
+		t.Fatal("expected login to set the jwt cookie")
+	}
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/auth/sessions", nil)
+	req = req.WithContext(context.WithValue(req.Context(), ClaimsKey, &Claims{Username: "alice", SessionID: "s1"}))
+	w := httptest.NewRecorder()
+	m.handleRevokeAllSessions(w, req)
+
+	cleared := responseCookies(w)["jwt"]
+	if cleared == nil || cleared.MaxAge >= 0 {
+		t.Fatalf("expected jwt cookie to be cleared, got %+v", cleared)
+	}
+	if cleared.Domain != set.Domain || cleared.Path != set.Path || cleared.Secure != set.Secure || cleared.SameSite != set.SameSite {
+		t.Errorf("expected the cleared cookie to match the login cookie %+v, got %+v", set, cleared)
+	}
+}
+
+func TestRequireAuthMiddleware_RejectsRevokedSessionBearerToken(t *testing.T) {
+	ctx := context.Background()
+	sessions := NewMemorySessionStore()
+	sessions.SaveSession(ctx, newTestSession("s1", "alice@example.com", time.Now()))
+	m, sub, sign := newSignOutTestManager(t, false, WithSessionStore(sessions))
+	token := sign(sub, time.Now())
+
+	handler := m.RequireAuthMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	serve := func(withSessionCookie bool) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/things", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		if withSessionCookie {
+			req.AddCookie(&http.Cookie{Name: sessionCookieName, Value: "s1"})
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := serve(true); code != http.StatusOK {
+		t.Fatalf("expected 200 for a Bearer token with a live session, got %d", code)
+	}
+	if code := serve(false); code != http.StatusUnauthorized {
+		t.Errorf("expected 401 for a Bearer token without a session, got %d", code)
+	}
+
+	if err := m.RevokeSession(ctx, "alice@example.com", "s1"); err != nil {
+		t.Fatalf("RevokeSession: %v", err)
+	}
+	if code := serve(true); code != http.StatusUnauthorized {
+		t.Errorf("expected 401 for a Bearer token replayed after revocation, got %d", code)
+	}
+}
@@ -0,0 +1,164 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// RevocationList records, per token subject, a cut-off before which every
+// issued ID token is revoked. ID token validation consults it, so a sign-out
+// takes effect immediately instead of when the user's tokens expire.
+type RevocationList interface {
+	// RevokeTokens revokes the subject's tokens issued at or before before.
+	// A cut-off earlier than the recorded one is ignored.
+	RevokeTokens(ctx context.Context, sub string, before time.Time) error
+	// RevokedBefore returns the subject's cut-off, or the zero time when none is recorded.
+	RevokedBefore(ctx context.Context, sub string) (time.Time, error)
+}
+
+// MemoryRevocationList is an in-process RevocationList. Revocations are lost
+// on restart and are not shared between instances; deployments with several
+// instances should provide a shared implementation.
+type MemoryRevocationList struct {
+	mu      sync.RWMutex
+	cutoffs map[string]time.Time
+}
+
+// NewMemoryRevocationList creates an empty in-memory revocation list.
+func NewMemoryRevocationList() *MemoryRevocationList {
+	return &MemoryRevocationList{cutoffs: make(map[string]time.Time)}
+}
+
+func (l *MemoryRevocationList) RevokeTokens(ctx context.Context, sub string, before time.Time) error {
+	if sub == "" {
+		return fmt.Errorf("sub is required: %w", ErrInvalidInput)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if before.After(l.cutoffs[sub]) {
+		l.cutoffs[sub] = before
+	}
+	return nil
+}
+
+func (l *MemoryRevocationList) RevokedBefore(ctx context.Context, sub string) (time.Time, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.cutoffs[sub], nil
+}
+
+// WithRevocationList sets the list consulted when validating ID tokens and
+// written by SignOutUser. Defaults to a MemoryRevocationList.
+func WithRevocationList(list RevocationList) ManagerOption {
+	return func(m *Manager) {
+		m.oidc.setRevocationList(list)
+	}
+}
+
+// SetRevocationList sets the revocation list of the default Manager, which
+// ValidateOIDCTokenFromOAuthConfig also consults. See WithRevocationList.
+func SetRevocationList(list RevocationList) {
+	defaultManager.oidc.setRevocationList(list)
+}
+
+// GlobalSignOutStore is an optional UserStore extension for backends that
+// issue tokens of their own and can revoke them. The Cognito store
+// implements it with AdminUserGlobalSignOut.
+type GlobalSignOutStore interface {
+	// GlobalSignOut revokes the user's refresh and access tokens.
+	GlobalSignOut(ctx context.Context, username string) error
+}
+
+// SignOutUser signs the user out everywhere. ID tokens issued so far are
+// rejected by RequireAuthMiddleware and ValidateIDToken from now on, the
+// user's Cognito refresh and access tokens are revoked, and their
+// server-side sessions are revoked when a SessionStore is configured.
+// API keys are not affected.
+func (m *Manager) SignOutUser(ctx context.Context, email string) error {
+	if email == "" {
+		return fmt.Errorf("email cannot be empty: %w", ErrInvalidInput)
+	}
+
+	store, err := m.userStore()
+	if err != nil {
+		return err
+	}
+
+	record, err := store.GetUser(ctx, email)
+	if err != nil {
+		return err
+	}
+
+	// Revoke locally first so the sign-out holds even if Cognito fails below
+	if sub := record.Attributes["sub"]; sub != "" {
+		if list := m.oidc.revocationList(); list != nil {
+			if err := list.RevokeTokens(ctx, sub, time.Now()); err != nil {
+				return fmt.Errorf("failed to revoke tokens: %w", err)
+			}
+		}
+	}
+
+	if signOutStore, ok := store.(GlobalSignOutStore); ok {
+		if err := signOutStore.GlobalSignOut(ctx, record.Username); err != nil {
+			return err
+		}
+	}
+
+	if m.sessions != nil {
+		if _, err := m.RevokeAllSessions(ctx, record.Username); err != nil {
+			return err
+		}
+	}
+
+	log.Printf("✅ Signed out %s everywhere", email)
+	return nil
+}
+
+// signOutOnAccountChange calls SignOutUser when OAuthConfig.SignOutOnAccountChange
+// is set. A failure is logged rather than returned so it never fails the
+// account change itself.
+func (m *Manager) signOutOnAccountChange(ctx context.Context, email, operation string) {
+	config := m.Config()
+	if config == nil || !config.SignOutOnAccountChange {
+		return
+	}
+
+	if err := m.SignOutUser(ctx, email); err != nil {
+		log.Printf("⚠️ [%s] Could not sign out %s: %v", operation, email, err)
+	}
+}
+
+func (c *oidcProviderCache) revocationList() RevocationList {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.revocations
+}
+
+func (c *oidcProviderCache) setRevocationList(list RevocationList) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.revocations = list
+}
+
+// checkRevoked rejects a token of sub issued at or before the subject's
+// revocation cut-off. Token iat has second precision, so a token issued in
+// the same second as the sign-out is rejected too.
+func (c *oidcProviderCache) checkRevoked(ctx context.Context, sub string, issuedAt time.Time) error {
+	list := c.revocationList()
+	if list == nil || sub == "" {
+		return nil
+	}
+
+	cutoff, err := list.RevokedBefore(ctx, sub)
+	if err != nil {
+		return fmt.Errorf("failed to check token revocation: %w", err)
+	}
+	if !cutoff.IsZero() && issuedAt.Unix() <= cutoff.Unix() {
+		return fmt.Errorf("token issued before the user was signed out: %w", ErrTokenRevoked)
+	}
+	return nil
+}
@@ -0,0 +1,248 @@
+package user
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
+	"github.com/go-jose/go-jose/v4"
+	"github.com/go-jose/go-jose/v4/jwt"
+)
+
+const testIssuerClientID = "test-client"
+
+// newTestIssuer serves OIDC discovery and a JWKS for a fresh RSA key, and
+// returns the issuer URL plus a function signing ID tokens for sub issued at
+// iat, with any extra claims added.
+func newTestIssuer(t *testing.T) (string, func(sub string, iat time.Time, extra ...map[string]any) string) {
+	t.Helper()
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate RSA key: %v", err)
+	}
+
+	var issuer string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"issuer":                                issuer,
+			"authorization_endpoint":                issuer + "/authorize",
+			"token_endpoint":                        issuer + "/token",
+			"jwks_uri":                              issuer + "/jwks",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{
+			Key: &priv.PublicKey, KeyID: "k1", Algorithm: "RS256", Use: "sig",
+		}}})
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	issuer = srv.URL
+
+	signer, err := jose.NewSigner(
+		jose.SigningKey{Algorithm: jose.RS256, Key: priv},
+		(&jose.SignerOptions{}).WithType("JWT").WithHeader(jose.HeaderKey("kid"), "k1"),
+	)
+	if err != nil {
+		t.Fatalf("new signer: %v", err)
+	}
+
+	sign := func(sub string, iat time.Time, extra ...map[string]any) string {
+		claims := map[string]any{
+			"iss":              issuer,
+			"aud":              testIssuerClientID,
+			"sub":              sub,
+			"iat":              iat.Unix(),
+			"exp":              iat.Add(time.Hour).Unix(),
+			"email":            "alice@example.com",
+			"cognito:username": "alice@example.com",
+		}
+		for _, e := range extra {
+			for k, v := range e {
+				claims[k] = v
+			}
+		}
+		raw, err := jwt.Signed(signer).Claims(claims).Serialize()
+		if err != nil {
+			t.Fatalf("sign jwt: %v", err)
+		}
+		return raw
+	}
+	return issuer, sign
+}
+
+func newSignOutTestManager(t *testing.T, signOutOnChange bool, opts ...ManagerOption) (*Manager, string, func(sub string, iat time.Time, extra ...map[string]any) string) {
+	t.Helper()
+	issuer, sign := newTestIssuer(t)
+	store := NewMemoryUserStore()
+	config := &OAuthConfig{ClientID: testIssuerClientID, IssuerURL: issuer, SignOutOnAccountChange: signOutOnChange}
+	m := newTestManager(t, config, store, []CreateUserRequest{{Email: "alice@example.com"}}, opts...)
+	record, _ := store.GetUser(context.Background(), "alice@example.com")
+	return m, record.Attributes["sub"], sign
+}
+
+func TestSignOutUser_RevokesEarlierTokens(t *testing.T) {
+	m, sub, sign := newSignOutTestManager(t, false)
+	ctx := context.Background()
+	oldToken := sign(sub, time.Now().Add(-time.Minute))
+
+	handler := m.RequireAuthMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	serve := func(token string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/things", nil)
+		req.AddCookie(&http.Cookie{Name: "jwt", Value: token})
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := serve(oldToken); code != http.StatusOK {
+		t.Fatalf("expected 200 before sign-out, got %d", code)
+	}
+	if err := m.SignOutUser(ctx, "alice@example.com"); err != nil {
+		t.Fatalf("SignOutUser: %v", err)
+	}
+	if code := serve(oldToken); code != http.StatusUnauthorized {
+		t.Errorf("expected 401 for a token issued before sign-out, got %d", code)
+	}
+	if _, err := m.ValidateIDToken(ctx, oldToken); !errors.Is(err, ErrTokenRevoked) {
+		t.Errorf("expected ErrTokenRevoked, got %v", err)
+	}
+
+	if code := serve(sign(sub, time.Now().Add(2*time.Second))); code != http.StatusOK {
+		t.Errorf("expected 200 for a token issued after sign-out, got %d", code)
+	}
+	if _, err := m.ValidateIDToken(ctx, sign("someone-else", time.Now().Add(-time.Minute))); err != nil {
+		t.Errorf("expected other users' tokens to stay valid, got %v", err)
+	}
+
+	if err := m.SignOutUser(ctx, "nobody@example.com"); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("expected ErrUserNotFound, got %v", err)
+	}
+}
+
+func TestSignOutUser_RevokesSessions(t *testing.T) {
+	sessions := NewMemorySessionStore()
+	m, _, _ := newSignOutTestManager(t, false, WithSessionStore(sessions))
+	ctx := context.Background()
+	sessions.SaveSession(ctx, newTestSession("s1", "alice@example.com", time.Now()))
+	sessions.SaveSession(ctx, newTestSession("s2", "bob@example.com", time.Now()))
+
+	if err := m.SignOutUser(ctx, "alice@example.com"); err != nil {
+		t.Fatalf("SignOutUser: %v", err)
+	}
+	if _, err := sessions.GetSession(ctx, "s1"); !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("expected alice's session to be revoked, got %v", err)
+	}
+	if _, err := sessions.GetSession(ctx, "s2"); err != nil {
+		t.Errorf("expected bob's session to survive, got %v", err)
+	}
+}
+
+func TestSignOutOnAccountChange(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name   string
+		change func(m *Manager) error
+	}{
+		{"DisableUser", func(m *Manager) error { return m.DisableUser(ctx, "alice@example.com") }},
+		{"DeleteUser", func(m *Manager) error { return m.DeleteUser(ctx, "alice@example.com") }},
+		{"SetUserPassword", func(m *Manager) error {
+			return m.SetUserPassword(ctx, "alice@example.com", "N3w-Passw0rd!", true)
+		}},
+		{"UpdateRole", func(m *Manager) error {
+			_, err := m.UpdateRole(ctx, "alice@example.com", "manager")
+			return err
+		}},
+	}
+
+	for _, tt := range tests {
+		for _, enabled := range []bool{true, false} {
+			m, sub, sign := newSignOutTestManager(t, enabled)
+			token := sign(sub, time.Now().Add(-time.Minute))
+
+			if err := tt.change(m); err != nil {
+				t.Fatalf("%s: %v", tt.name, err)
+			}
+			_, err := m.ValidateIDToken(ctx, token)
+			if enabled && !errors.Is(err, ErrTokenRevoked) {
+				t.Errorf("%s with SignOutOnAccountChange: expected ErrTokenRevoked, got %v", tt.name, err)
+			}
+			if !enabled && err != nil {
+				t.Errorf("%s without SignOutOnAccountChange: expected the token to stay valid, got %v", tt.name, err)
+			}
+		}
+	}
+}
+
+func TestValidateOIDCTokenFromOAuthConfig_ConsultsDefaultRevocationList(t *testing.T) {
+	resetOIDCProviderForTesting()
+	t.Cleanup(resetOIDCProviderForTesting)
+	list := NewMemoryRevocationList()
+	SetRevocationList(list)
+	t.Cleanup(func() { SetRevocationList(NewMemoryRevocationList()) })
+
+	issuer, sign := newTestIssuer(t)
+	config := &OAuthConfig{ClientID: testIssuerClientID, IssuerURL: issuer}
+	token := sign("user-1", time.Now().Add(-time.Minute))
+
+	if _, err := ValidateOIDCTokenFromOAuthConfig(context.Background(), token, config); err != nil {
+		t.Fatalf("expected the token to be valid, got %v", err)
+	}
+	list.RevokeTokens(context.Background(), "user-1", time.Now())
+	if _, err := ValidateOIDCTokenFromOAuthConfig(context.Background(), token, config); !errors.Is(err, ErrTokenRevoked) {
+		t.Errorf("expected ErrTokenRevoked, got %v", err)
+	}
+}
+
+func TestMemoryRevocationList_KeepsLatestCutoff(t *testing.T) {
+	ctx := context.Background()
+	list := NewMemoryRevocationList()
+	later := time.Now()
+	list.RevokeTokens(ctx, "sub", later)
+	list.RevokeTokens(ctx, "sub", later.Add(-time.Hour))
+
+	if got, _ := list.RevokedBefore(ctx, "sub"); !got.Equal(later) {
+		t.Errorf("expected the later cut-off to win, got %v", got)
+	}
+	if got, _ := list.RevokedBefore(ctx, "other"); !got.IsZero() {
+		t.Errorf("expected no cut-off for an unknown subject, got %v", got)
+	}
+	if err := list.RevokeTokens(ctx, "", later); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("expected ErrInvalidInput for an empty sub, got %v", err)
+	}
+}
+
+// mockSignOutCognitoClient records AdminUserGlobalSignOut calls.
+type mockSignOutCognitoClient struct {
+	mockUserMgmtCognitoClient
+	signedOut []string
+}
+
+func (m *mockSignOutCognitoClient) AdminUserGlobalSignOut(_ context.Context, params *cognitoidentityprovider.AdminUserGlobalSignOutInput, _ ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.AdminUserGlobalSignOutOutput, error) {
+	m.signedOut = append(m.signedOut, aws.ToString(params.UserPoolId)+"/"+aws.ToString(params.Username))
+	return &cognitoidentityprovider.AdminUserGlobalSignOutOutput{}, nil
+}
+
+func TestSignOutUser_CallsCognitoGlobalSignOut(t *testing.T) {
+	client := &mockSignOutCognitoClient{}
+	m := NewManager(&OAuthConfig{UserPoolID: "pool", Region: "us-east-1"}, WithCognitoClient(client))
+
+	if err := m.SignOutUser(context.Background(), "test@example.com"); err != nil {
+		t.Fatalf("SignOutUser: %v", err)
+	}
+	if len(client.signedOut) != 1 || client.signedOut[0] != "pool/test@example.com" {
+		t.Errorf("expected one global sign-out for pool/test@example.com, got %v", client.signedOut)
+	}
+}
@@ -18,9 +18,10 @@ type EncryptedStateRepository struct {
 }
 
 type statePayload struct {
-	Timestamp   int64  `json:"timestamp"`
-	RedirectURL string `json:"redirect_url"`
-	Nonce       string `json:"nonce"`
+	Timestamp    int64  `json:"timestamp"`
+	RedirectURL  string `json:"redirect_url"`
+	Nonce        string `json:"nonce"`
+	CodeVerifier string `json:"code_verifier,omitempty"` // PKCE verifier sent on code exchange
 }
 
 func NewEncryptedStateRepository() StateRepository {
@@ -50,12 +51,14 @@ func (r *EncryptedStateRepository) StoreState(state string, redirectURL string,
 }
 
 func (r *EncryptedStateRepository) GenerateEncryptedState(nonce string, redirectURL string) (string, error) {
-	payload := statePayload{
+	return r.encryptPayload(statePayload{
 		Timestamp:   time.Now().Unix(),
 		RedirectURL: redirectURL,
 		Nonce:       nonce,
-	}
+	})
+}
 
+func (r *EncryptedStateRepository) encryptPayload(payload statePayload) (string, error) {
 	payloadJSON, err := json.Marshal(payload)
 	if err != nil {
 		return "", fmt.Errorf("failed to marshal state payload: %w", err)
@@ -65,16 +68,25 @@ func (r *EncryptedStateRepository) GenerateEncryptedState(nonce string, redirect
 }
 
 func (r *EncryptedStateRepository) ValidateAndRemoveState(state string) (string, bool) {
+	payload, ok := r.validatePayload(state)
+	if !ok {
+		return "", false
+	}
+	return payload.RedirectURL, true
+}
+
+// validatePayload decrypts state and checks its age.
+func (r *EncryptedStateRepository) validatePayload(state string) (*statePayload, bool) {
 	decrypted, err := r.decrypt(state)
 	if err != nil {
 		log.Printf("❌ [EncryptedStateRepo] Failed to decrypt state: %v", err)
-		return "", false
+		return nil, false
 	}
 
 	var payload statePayload
 	if err := json.Unmarshal(decrypted, &payload); err != nil {
 		log.Printf("❌ [EncryptedStateRepo] Failed to unmarshal state payload: %v", err)
-		return "", false
+		return nil, false
 	}
 
 	now := time.Now().Unix()
@@ -82,16 +94,16 @@ func (r *EncryptedStateRepository) ValidateAndRemoveState(state string) (string,
 
 	if now-payload.Timestamp > maxAge {
 		log.Printf("❌ [EncryptedStateRepo] State expired (age: %d seconds)", now-payload.Timestamp)
-		return "", false
+		return nil, false
 	}
 
 	if payload.Timestamp > now+60 {
 		log.Printf("❌ [EncryptedStateRepo] State timestamp is in the future (clock skew?)")
-		return "", false
+		return nil, false
 	}
 
 	log.Printf("✅ [EncryptedStateRepo] State validated successfully (age: %d seconds)", now-payload.Timestamp)
-	return payload.RedirectURL, true
+	return &payload, true
 }
 
 func (r *EncryptedStateRepository) CleanupExpiredStates() error {
@@ -103,7 +115,18 @@ func (r *EncryptedStateRepository) Encrypt(plaintext []byte) (string, error) {
 }
 
 func (r *EncryptedStateRepository) encrypt(plaintext []byte) (string, error) {
-	block, err := aes.NewCipher(r.key)
+	return sealAESGCM(r.key, plaintext, nil)
+}
+
+func (r *EncryptedStateRepository) decrypt(ciphertext string) ([]byte, error) {
+	return openAESGCM(r.key, ciphertext, nil)
+}
+
+// sealAESGCM encrypts plaintext with key and returns it base64url encoded.
+// additionalData binds the ciphertext to its purpose so values encrypted for
+// one use cannot be replayed as another.
+func sealAESGCM(key, plaintext, additionalData []byte) (string, error) {
+	block, err := aes.NewCipher(key)
 	if err != nil {
 		return "", err
 	}
@@ -118,17 +141,18 @@ func (r *EncryptedStateRepository) encrypt(plaintext []byte) (string, error) {
 		return "", err
 	}
 
-	ciphertext := gcm.Seal(nonce, nonce, plaintext, nil)
+	ciphertext := gcm.Seal(nonce, nonce, plaintext, additionalData)
 	return base64.URLEncoding.EncodeToString(ciphertext), nil
 }
 
-func (r *EncryptedStateRepository) decrypt(ciphertext string) ([]byte, error) {
+// openAESGCM reverses sealAESGCM.
+func openAESGCM(key []byte, ciphertext string, additionalData []byte) ([]byte, error) {
 	data, err := base64.URLEncoding.DecodeString(ciphertext)
 	if err != nil {
 		return nil, err
 	}
 
-	block, err := aes.NewCipher(r.key)
+	block, err := aes.NewCipher(key)
 	if err != nil {
 		return nil, err
 	}
@@ -144,11 +168,10 @@ func (r *EncryptedStateRepository) decrypt(ciphertext string) ([]byte, error) {
 	}
 
 	nonce, ciphertextBytes := data[:nonceSize], data[nonceSize:]
-	plaintext, err := gcm.Open(nil, nonce, ciphertextBytes, nil)
+	plaintext, err := gcm.Open(nil, nonce, ciphertextBytes, additionalData)
 	if err != nil {
 		return nil, err
 	}
 
 	return plaintext, nil
 }
-
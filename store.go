@@ -0,0 +1,127 @@
+package user
+
+import (
+	"context"
+	"fmt"
+)
+
+// UserStore is the persistence backend behind the user management API.
+// Implementations store users as flat attribute maps keyed by the same
+// attribute names Cognito uses (email, given_name, custom:role, ...), so the
+// business rules in api.go (role defaults, custom attribute normalization,
+// API key attributes) apply unchanged to every backend.
+//
+// The Cognito-backed store is used by default. MemoryUserStore provides a
+// complete in-process implementation for local development and tests.
+type UserStore interface {
+	// GetUser returns the user with the given username, or an error wrapping ErrUserNotFound.
+	GetUser(ctx context.Context, username string) (*UserRecord, error)
+	// CreateUser creates a user with the given attributes, or returns an error wrapping ErrUserAlreadyExists.
+	CreateUser(ctx context.Context, username string, attributes map[string]string) (*UserRecord, error)
+	// UpdateAttributes sets the given attributes, leaving all others untouched.
+	UpdateAttributes(ctx context.Context, username string, attributes map[string]string) error
+	// SetPassword sets a user's password. Non-permanent passwords put the user in FORCE_CHANGE_PASSWORD.
+	SetPassword(ctx context.Context, username, password string, permanent bool) error
+	DisableUser(ctx context.Context, username string) error
+	EnableUser(ctx context.Context, username string) error
+	DeleteUser(ctx context.Context, username string) error
+	// ListUsers returns up to limit users starting at pageToken, plus the token
+	// for the next page ("" when there are no more users).
+	ListUsers(ctx context.Context, limit int, pageToken string) ([]*UserRecord, string, error)
+	// FindUsersByAttribute returns up to limit users whose attribute name equals value.
+	FindUsersByAttribute(ctx context.Context, name, value string, limit int) ([]*UserRecord, error)
+}
+
+// UserRecord is the backend-level view of a stored user.
+type UserRecord struct {
+	Username   string
+	Attributes map[string]string
+	Status     string // CONFIRMED, FORCE_CHANGE_PASSWORD, ...
+	Enabled    bool
+
+	// MFA settings, when the backend reports them (Cognito does on GetUser only)
+	MFAMethods   []MFAMethod
+	PreferredMFA MFAMethod
+}
+
+// SetUserStore overrides the backend used by the package-level user functions.
+// Passing nil restores the default Cognito-backed store.
+func SetUserStore(store UserStore) {
+	defaultManager.store = store
+	defaultManager.tokenCache.clear()
+}
+
+// ResetUserStore restores the default Cognito-backed store.
+func ResetUserStore() {
+	defaultManager.store = nil
+	defaultManager.tokenCache.clear()
+}
+
+// recordToUser maps a stored user onto the public User type.
+func recordToUser(record *UserRecord, roleAttr string) (*User, error) {
+	if record == nil {
+		return nil, fmt.Errorf("user record is nil")
+	}
+
+	attrs := record.Attributes
+	user := &User{
+		Username:          record.Username,
+		Email:             attrs["email"],
+		GivenName:         attrs["given_name"],
+		FamilyName:        attrs["family_name"],
+		Name:              attrs["name"],
+		Picture:           attrs["picture"],
+		Role:              firstNonEmpty(attrs[roleAttr], attrs["custom:role"], attrs["custom:userRole"]),
+		APIKey:            firstNonEmpty(attrs["custom:apiKey"], attrs["custom:api_key"]),
+		TenantID:          attrs["custom:tenantId"],
+		ServiceProviderID: attrs["custom:serviceProviderId"],
+		UserStatus:        record.Status,
+		Enabled:           record.Enabled,
+		MFAMethods:        record.MFAMethods,
+		PreferredMFA:      record.PreferredMFA,
+		CustomAttributes:  customAttributesOf(attrs),
+	}
+
+	if user.Email == "" {
+		if record.Username == "" {
+			return nil, fmt.Errorf("user has no email attribute")
+		}
+		user.Email = record.Username
+	}
+
+	if user.Role == "" {
+		user.Role = "user"
+	}
+
+	return user, nil
+}
+
+// recordsToUsers converts records, skipping any that cannot be mapped.
+func recordsToUsers(records []*UserRecord, roleAttr string) []*User {
+	users := make([]*User, 0, len(records))
+	for _, record := range records {
+		user, err := recordToUser(record, roleAttr)
+		if err != nil {
+			continue
+		}
+		users = append(users, user)
+	}
+	return users
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func copyAttributes(attrs map[string]string) map[string]string {
+	out := make(map[string]string, len(attrs))
+	for k, v := range attrs {
+		out[k] = v
+	}
+	return out
+}
@@ -0,0 +1,174 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider/types"
+)
+
+// cognitoUserStore is the UserStore backed by a Cognito user pool.
+// Clients are resolved per call through awsClients so STS credentials
+// carried on the context are honored.
+type cognitoUserStore struct {
+	clients *awsClients
+}
+
+// NewCognitoUserStore returns a UserStore backed by the Cognito user pool in config.
+func NewCognitoUserStore(config *OAuthConfig) UserStore {
+	return &cognitoUserStore{clients: defaultAWSClients(config)}
+}
+
+func (s *cognitoUserStore) GetUser(ctx context.Context, username string) (*UserRecord, error) {
+	result, err := cognitoGetUser(ctx, username, s.clients)
+	if err != nil {
+		return nil, err
+	}
+
+	record := cognitoUserToRecord(types.UserType{
+		Username:   result.Username,
+		Attributes: result.UserAttributes,
+		UserStatus: result.UserStatus,
+		Enabled:    result.Enabled,
+	})
+	record.MFAMethods = mfaMethodsOf(result.UserMFASettingList)
+	record.PreferredMFA = MFAMethod(aws.ToString(result.PreferredMfaSetting))
+	return record, nil
+}
+
+func (s *cognitoUserStore) CreateUser(ctx context.Context, username string, attributes map[string]string) (*UserRecord, error) {
+	cognitoUser, err := cognitoCreateUserWithAttributes(ctx, username, attributes, s.clients)
+	if err != nil {
+		return nil, err
+	}
+	if cognitoUser == nil {
+		return &UserRecord{Username: username, Attributes: copyAttributes(attributes), Enabled: true}, nil
+	}
+	return cognitoUserToRecord(*cognitoUser), nil
+}
+
+func (s *cognitoUserStore) UpdateAttributes(ctx context.Context, username string, attributes map[string]string) error {
+	return cognitoUpdateUserAttributes(ctx, username, toCognitoAttributes(attributes), s.clients)
+}
+
+func (s *cognitoUserStore) DeleteAttributes(ctx context.Context, username string, names []string) error {
+	return cognitoDeleteUserAttributes(ctx, username, names, s.clients)
+}
+
+func (s *cognitoUserStore) SetPassword(ctx context.Context, username, password string, permanent bool) error {
+	return cognitoSetUserPassword(ctx, username, password, permanent, s.clients)
+}
+
+func (s *cognitoUserStore) DisableUser(ctx context.Context, username string) error {
+	return cognitoDisableUser(ctx, username, s.clients)
+}
+
+func (s *cognitoUserStore) EnableUser(ctx context.Context, username string) error {
+	return cognitoEnableUser(ctx, username, s.clients)
+}
+
+func (s *cognitoUserStore) DeleteUser(ctx context.Context, username string) error {
+	return cognitoDeleteUser(ctx, username, s.clients)
+}
+
+func (s *cognitoUserStore) PasswordPolicy(ctx context.Context) (*PasswordPolicy, error) {
+	return cognitoPasswordPolicy(ctx, s.clients)
+}
+
+func (s *cognitoUserStore) GlobalSignOut(ctx context.Context, username string) error {
+	return cognitoGlobalSignOut(ctx, username, s.clients)
+}
+
+func (s *cognitoUserStore) ListUsers(ctx context.Context, limit int, pageToken string) ([]*UserRecord, string, error) {
+	return s.listUsers(ctx, limit, pageToken, "")
+}
+
+// ListUsersFiltered applies the part of filter Cognito supports server-side
+// (see cognitoUserFilter).
+func (s *cognitoUserStore) ListUsersFiltered(ctx context.Context, limit int, pageToken string, filter UserFilter) ([]*UserRecord, string, error) {
+	return s.listUsers(ctx, limit, pageToken, cognitoUserFilter(filter))
+}
+
+func (s *cognitoUserStore) listUsers(ctx context.Context, limit int, pageToken, filter string) ([]*UserRecord, string, error) {
+	var token *string
+	if pageToken != "" {
+		token = aws.String(pageToken)
+	}
+
+	users, nextToken, err := cognitoListUsers(ctx, int32(limit), token, filter, s.clients)
+	if err != nil {
+		return nil, "", err
+	}
+
+	records := make([]*UserRecord, 0, len(users))
+	for _, u := range users {
+		records = append(records, cognitoUserToRecord(u))
+	}
+	return records, aws.ToString(nextToken), nil
+}
+
+func (s *cognitoUserStore) FindUsersByAttribute(ctx context.Context, name, value string, limit int) ([]*UserRecord, error) {
+	client, err := s.clients.cognitoClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 || limit > 60 {
+		limit = 60
+	}
+
+	input := &cognitoidentityprovider.ListUsersInput{
+		UserPoolId: aws.String(s.clients.config.UserPoolID),
+		Filter:     aws.String(fmt.Sprintf("%s = \"%s\"", name, value)),
+		Limit:      aws.Int32(int32(limit)),
+	}
+
+	result, err := client.ListUsers(ctx, input)
+	if err != nil {
+		return nil, wrapCognitoError(err, "ListUsers")
+	}
+
+	records := make([]*UserRecord, 0, len(result.Users))
+	for _, u := range result.Users {
+		records = append(records, cognitoUserToRecord(u))
+	}
+	return records, nil
+}
+
+// cognitoUserToRecord flattens a Cognito user into a UserRecord.
+func cognitoUserToRecord(cognitoUser types.UserType) *UserRecord {
+	record := &UserRecord{
+		Username:   aws.ToString(cognitoUser.Username),
+		Attributes: make(map[string]string, len(cognitoUser.Attributes)),
+		Status:     string(cognitoUser.UserStatus),
+		Enabled:    cognitoUser.Enabled,
+	}
+	for _, attr := range cognitoUser.Attributes {
+		if attr.Name == nil || attr.Value == nil {
+			continue
+		}
+		record.Attributes[*attr.Name] = *attr.Value
+	}
+	return record
+}
+
+// toCognitoAttributes converts an attribute map to Cognito attributes in a stable order.
+func toCognitoAttributes(attrs map[string]string) []types.AttributeType {
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	attributes := make([]types.AttributeType, 0, len(keys))
+	for _, k := range keys {
+		attributes = append(attributes, types.AttributeType{
+			Name:  aws.String(k),
+			Value: aws.String(attrs[k]),
+		})
+	}
+	return attributes
+}
@@ -0,0 +1,432 @@
+package user
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// MemoryUserStore is an in-process UserStore for local development and
+// integration tests. It mirrors the Cognito behaviors the rest of the package
+// relies on: generated sub attributes, FORCE_CHANGE_PASSWORD after a
+// temporary password, password policy checks, enable/disable state and
+// groups.
+type MemoryUserStore struct {
+	mu     sync.RWMutex
+	users  map[string]*memoryUser
+	groups map[string]*memoryGroup
+	policy PasswordPolicy
+}
+
+type memoryUser struct {
+	record   UserRecord
+	password string
+}
+
+type memoryGroup struct {
+	group   Group
+	members map[string]bool
+}
+
+// NewMemoryUserStore creates an empty in-memory user store.
+func NewMemoryUserStore() *MemoryUserStore {
+	return &MemoryUserStore{
+		users:  make(map[string]*memoryUser),
+		groups: make(map[string]*memoryGroup),
+		policy: DefaultPasswordPolicy(),
+	}
+}
+
+// SetPasswordPolicy replaces the policy SetPassword enforces, like changing
+// a user pool's policy. Defaults to DefaultPasswordPolicy.
+func (s *MemoryUserStore) SetPasswordPolicy(policy PasswordPolicy) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.policy = policy
+}
+
+func (s *MemoryUserStore) PasswordPolicy(ctx context.Context) (*PasswordPolicy, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	policy := s.policy
+	return &policy, nil
+}
+
+func (s *MemoryUserStore) GetUser(ctx context.Context, username string) (*UserRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	u, ok := s.users[username]
+	if !ok {
+		return nil, fmt.Errorf("get user %s: %w", username, ErrUserNotFound)
+	}
+	return u.snapshot(), nil
+}
+
+func (s *MemoryUserStore) CreateUser(ctx context.Context, username string, attributes map[string]string) (*UserRecord, error) {
+	if username == "" {
+		return nil, fmt.Errorf("username cannot be empty: %w", ErrInvalidInput)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.users[username]; exists {
+		return nil, fmt.Errorf("create user %s: %w", username, ErrUserAlreadyExists)
+	}
+
+	attrs := copyAttributes(attributes)
+	if attrs["sub"] == "" {
+		sub, err := newMemorySub()
+		if err != nil {
+			return nil, err
+		}
+		attrs["sub"] = sub
+	}
+
+	u := &memoryUser{
+		record: UserRecord{
+			Username:   username,
+			Attributes: attrs,
+			Status:     "FORCE_CHANGE_PASSWORD",
+			Enabled:    true,
+		},
+	}
+	s.users[username] = u
+	return u.snapshot(), nil
+}
+
+func (s *MemoryUserStore) UpdateAttributes(ctx context.Context, username string, attributes map[string]string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[username]
+	if !ok {
+		return fmt.Errorf("update attributes %s: %w", username, ErrUserNotFound)
+	}
+	for k, v := range attributes {
+		if k == "sub" {
+			return fmt.Errorf("attribute sub is immutable: %w", ErrInvalidInput)
+		}
+		u.record.Attributes[k] = v
+	}
+	return nil
+}
+
+// DeleteAttributes removes the named attributes; names the user does not
+// have are ignored.
+func (s *MemoryUserStore) DeleteAttributes(ctx context.Context, username string, names []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[username]
+	if !ok {
+		return fmt.Errorf("delete attributes %s: %w", username, ErrUserNotFound)
+	}
+	for _, name := range names {
+		if name == "sub" {
+			return fmt.Errorf("attribute sub is immutable: %w", ErrInvalidInput)
+		}
+	}
+	for _, name := range names {
+		delete(u.record.Attributes, name)
+	}
+	return nil
+}
+
+func (s *MemoryUserStore) SetPassword(ctx context.Context, username, password string, permanent bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.policy.Validate(password); err != nil {
+		return fmt.Errorf("set password %s: %v: %w", username, err, ErrInvalidInput)
+	}
+
+	u, ok := s.users[username]
+	if !ok {
+		return fmt.Errorf("set password %s: %w", username, ErrUserNotFound)
+	}
+	u.password = password
+	if permanent {
+		u.record.Status = "CONFIRMED"
+	} else {
+		u.record.Status = "FORCE_CHANGE_PASSWORD"
+	}
+	return nil
+}
+
+func (s *MemoryUserStore) VerifyPassword(ctx context.Context, username, password string) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	u, ok := s.users[username]
+	if !ok || u.password == "" || subtle.ConstantTimeCompare([]byte(u.password), []byte(password)) != 1 {
+		return fmt.Errorf("verify password %s: %w", username, ErrInvalidCredentials)
+	}
+	return nil
+}
+
+func (s *MemoryUserStore) DisableUser(ctx context.Context, username string) error {
+	return s.setEnabled(username, false)
+}
+
+func (s *MemoryUserStore) EnableUser(ctx context.Context, username string) error {
+	return s.setEnabled(username, true)
+}
+
+func (s *MemoryUserStore) setEnabled(username string, enabled bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.users[username]
+	if !ok {
+		return fmt.Errorf("set enabled %s: %w", username, ErrUserNotFound)
+	}
+	u.record.Enabled = enabled
+	return nil
+}
+
+func (s *MemoryUserStore) DeleteUser(ctx context.Context, username string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[username]; !ok {
+		return fmt.Errorf("delete user %s: %w", username, ErrUserNotFound)
+	}
+	delete(s.users, username)
+	for _, g := range s.groups {
+		delete(g.members, username)
+	}
+	return nil
+}
+
+// ListUsers pages through users in username order. The page token is the
+// last username of the previous page, so pages stay stable under inserts.
+func (s *MemoryUserStore) ListUsers(ctx context.Context, limit int, pageToken string) ([]*UserRecord, string, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > 60 {
+		limit = 60
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	usernames := s.sortedUsernames()
+	start := sort.SearchStrings(usernames, pageToken)
+	if pageToken != "" && start < len(usernames) && usernames[start] == pageToken {
+		start++
+	}
+
+	end := start + limit
+	if end > len(usernames) {
+		end = len(usernames)
+	}
+
+	records := make([]*UserRecord, 0, end-start)
+	for _, username := range usernames[start:end] {
+		records = append(records, s.users[username].snapshot())
+	}
+
+	nextToken := ""
+	if end < len(usernames) {
+		nextToken = usernames[end-1]
+	}
+	return records, nextToken, nil
+}
+
+func (s *MemoryUserStore) FindUsersByAttribute(ctx context.Context, name, value string, limit int) ([]*UserRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var records []*UserRecord
+	for _, username := range s.sortedUsernames() {
+		u := s.users[username]
+		if u.record.Attributes[name] != value {
+			continue
+		}
+		records = append(records, u.snapshot())
+		if limit > 0 && len(records) >= limit {
+			break
+		}
+	}
+	return records, nil
+}
+
+// Password returns the last password set for username. It exists so tests can
+// assert on passwords the package generated (e.g. invitation temp passwords).
+func (s *MemoryUserStore) Password(username string) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	u, ok := s.users[username]
+	if !ok {
+		return "", false
+	}
+	return u.password, true
+}
+
+func (s *MemoryUserStore) CreateGroup(ctx context.Context, group Group) (*Group, error) {
+	if group.Name == "" {
+		return nil, fmt.Errorf("group name cannot be empty: %w", ErrInvalidInput)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.groups[group.Name]; exists {
+		return nil, fmt.Errorf("create group %s: %w", group.Name, ErrGroupAlreadyExists)
+	}
+	s.groups[group.Name] = &memoryGroup{group: group, members: make(map[string]bool)}
+	return &group, nil
+}
+
+func (s *MemoryUserStore) DeleteGroup(ctx context.Context, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.groups[name]; !ok {
+		return fmt.Errorf("delete group %s: %w", name, ErrGroupNotFound)
+	}
+	delete(s.groups, name)
+	return nil
+}
+
+// ListGroups pages through groups in name order, with the same page tokens
+// as ListUsers.
+func (s *MemoryUserStore) ListGroups(ctx context.Context, limit int, pageToken string) ([]*Group, string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	names := make([]string, 0, len(s.groups))
+	for name := range s.groups {
+		names = append(names, name)
+	}
+	page, nextToken := memoryPage(names, limit, pageToken)
+
+	groups := make([]*Group, 0, len(page))
+	for _, name := range page {
+		g := s.groups[name].group
+		groups = append(groups, &g)
+	}
+	return groups, nextToken, nil
+}
+
+func (s *MemoryUserStore) AddUserToGroup(ctx context.Context, username, group string) error {
+	return s.setMembership(username, group, true)
+}
+
+func (s *MemoryUserStore) RemoveUserFromGroup(ctx context.Context, username, group string) error {
+	return s.setMembership(username, group, false)
+}
+
+func (s *MemoryUserStore) setMembership(username, group string, member bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[username]; !ok {
+		return fmt.Errorf("group membership %s: %w", username, ErrUserNotFound)
+	}
+	g, ok := s.groups[group]
+	if !ok {
+		return fmt.Errorf("group membership %s: %w", group, ErrGroupNotFound)
+	}
+	if member {
+		g.members[username] = true
+	} else {
+		delete(g.members, username)
+	}
+	return nil
+}
+
+func (s *MemoryUserStore) ListUsersInGroup(ctx context.Context, group string, limit int, pageToken string) ([]*UserRecord, string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	g, ok := s.groups[group]
+	if !ok {
+		return nil, "", fmt.Errorf("list users in group %s: %w", group, ErrGroupNotFound)
+	}
+	usernames := make([]string, 0, len(g.members))
+	for username := range g.members {
+		usernames = append(usernames, username)
+	}
+	page, nextToken := memoryPage(usernames, limit, pageToken)
+
+	records := make([]*UserRecord, 0, len(page))
+	for _, username := range page {
+		records = append(records, s.users[username].snapshot())
+	}
+	return records, nextToken, nil
+}
+
+func (s *MemoryUserStore) ListGroupsForUser(ctx context.Context, username string) ([]*Group, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, ok := s.users[username]; !ok {
+		return nil, fmt.Errorf("list groups for user %s: %w", username, ErrUserNotFound)
+	}
+	var groups []*Group
+	for _, g := range s.groups {
+		if g.members[username] {
+			group := g.group
+			groups = append(groups, &group)
+		}
+	}
+	return groups, nil
+}
+
+// memoryPage sorts keys and returns the page after pageToken (the last key
+// of the previous page) plus the next page token.
+func memoryPage(keys []string, limit int, pageToken string) ([]string, string) {
+	if limit <= 0 || limit > 60 {
+		limit = 60
+	}
+	sort.Strings(keys)
+
+	start := sort.SearchStrings(keys, pageToken)
+	if pageToken != "" && start < len(keys) && keys[start] == pageToken {
+		start++
+	}
+	end := start + limit
+	if end > len(keys) {
+		end = len(keys)
+	}
+
+	nextToken := ""
+	if end < len(keys) {
+		nextToken = keys[end-1]
+	}
+	return keys[start:end], nextToken
+}
+
+func (s *MemoryUserStore) sortedUsernames() []string {
+	usernames := make([]string, 0, len(s.users))
+	for username := range s.users {
+		usernames = append(usernames, username)
+	}
+	sort.Strings(usernames)
+	return usernames
+}
+
+func (u *memoryUser) snapshot() *UserRecord {
+	record := u.record
+	record.Attributes = copyAttributes(u.record.Attributes)
+	return &record
+}
+
+// newMemorySub generates a random UUIDv4-formatted subject identifier.
+func newMemorySub() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate sub: %w", err)
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
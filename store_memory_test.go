@@ -0,0 +1,153 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func setupMemoryStore(t *testing.T) *MemoryUserStore {
+	t.Helper()
+	originalConfig := oauthConfig
+	t.Cleanup(func() {
+		ResetUserStore()
+		oauthConfig = originalConfig
+	})
+
+	SetOAuthConfig(nil)
+	store := NewMemoryUserStore()
+	SetUserStore(store)
+	return store
+}
+
+func TestMemoryUserStore_UserLifecycle(t *testing.T) {
+	store := setupMemoryStore(t)
+	ctx := context.Background()
+
+	created, tempPassword, err := CreateUserWithInvitation(ctx, CreateUserRequest{
+		Email:      "mem@example.com",
+		GivenName:  "Mem",
+		FamilyName: "Store",
+		Role:       "admin",
+		CustomAttributes: map[string]string{
+			"tenantId": "acme",
+		},
+	})
+	if err != nil {
+		t.Fatalf("CreateUserWithInvitation: %v", err)
+	}
+	if created.Role != "admin" || created.TenantID != "acme" {
+		t.Errorf("unexpected user: %+v", created)
+	}
+	if created.UserStatus != "FORCE_CHANGE_PASSWORD" || !created.Enabled {
+		t.Errorf("expected enabled FORCE_CHANGE_PASSWORD user, got status=%q enabled=%v", created.UserStatus, created.Enabled)
+	}
+	if pw, _ := store.Password("mem@example.com"); pw != tempPassword {
+		t.Errorf("expected stored temp password %q, got %q", tempPassword, pw)
+	}
+
+	if _, err := CreateUser(ctx, CreateUserRequest{Email: "mem@example.com"}); !errors.Is(err, ErrUserAlreadyExists) {
+		t.Errorf("expected ErrUserAlreadyExists, got %v", err)
+	}
+
+	name := "Mem Updated"
+	updated, err := UpdateProfile(ctx, "mem@example.com", ProfileUpdate{Name: &name})
+	if err != nil {
+		t.Fatalf("UpdateProfile: %v", err)
+	}
+	if updated.Name != name || updated.GivenName != "Mem" {
+		t.Errorf("unexpected profile after update: %+v", updated)
+	}
+
+	if _, err := UpdateRole(ctx, "mem@example.com", "user"); err != nil {
+		t.Fatalf("UpdateRole: %v", err)
+	}
+	if err := SetUserPassword(ctx, "mem@example.com", "Permanent123!", true); err != nil {
+		t.Fatalf("SetUserPassword: %v", err)
+	}
+	if err := DisableUser(ctx, "mem@example.com"); err != nil {
+		t.Fatalf("DisableUser: %v", err)
+	}
+
+	got, err := GetUser(ctx, "mem@example.com")
+	if err != nil {
+		t.Fatalf("GetUser: %v", err)
+	}
+	if got.Role != "user" || got.UserStatus != "CONFIRMED" || got.Enabled {
+		t.Errorf("unexpected user state: %+v", got)
+	}
+
+	if err := DeleteUser(ctx, "mem@example.com"); err != nil {
+		t.Fatalf("DeleteUser: %v", err)
+	}
+	if _, err := GetUser(ctx, "mem@example.com"); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("expected ErrUserNotFound after delete, got %v", err)
+	}
+}
+
+func TestMemoryUserStore_SetPasswordEnforcesPolicy(t *testing.T) {
+	setupMemoryStore(t)
+	ctx := context.Background()
+
+	if _, err := CreateUser(ctx, CreateUserRequest{Email: "weak@example.com"}); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+
+	err := SetUserPassword(ctx, "weak@example.com", "weak", true)
+	if !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("expected ErrInvalidInput for weak password, got %v", err)
+	}
+}
+
+func TestMemoryUserStore_ListUsersPaginates(t *testing.T) {
+	setupMemoryStore(t)
+	ctx := context.Background()
+
+	for _, email := range []string{"c@example.com", "a@example.com", "b@example.com"} {
+		if _, err := CreateUser(ctx, CreateUserRequest{Email: email}); err != nil {
+			t.Fatalf("CreateUser(%s): %v", email, err)
+		}
+	}
+
+	users, err := ListUsers(ctx, 2, 1)
+	if err != nil {
+		t.Fatalf("ListUsers: %v", err)
+	}
+	if len(users) != 2 || users[0].Email != "b@example.com" || users[1].Email != "c@example.com" {
+		t.Errorf("unexpected page: %+v", users)
+	}
+}
+
+func TestMemoryUserStore_APIKeyLookup(t *testing.T) {
+	setupMemoryStore(t)
+	ctx := context.Background()
+
+	if _, err := CreateUser(ctx, CreateUserRequest{Email: "key@example.com", Role: "admin"}); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+
+	apiKey, err := GenerateAPIKey(ctx, "key@example.com")
+	if err != nil {
+		t.Fatalf("GenerateAPIKey: %v", err)
+	}
+
+	user, err := ValidateAPIKey(ctx, apiKey)
+	if err != nil {
+		t.Fatalf("ValidateAPIKey: %v", err)
+	}
+	if user.Email != "key@example.com" {
+		t.Errorf("expected key@example.com, got %q", user.Email)
+	}
+
+	claims, err := FindUserByToken(ctx, apiKey)
+	if err != nil {
+		t.Fatalf("FindUserByToken: %v", err)
+	}
+	if claims.Sub == "" || claims.Role != "admin" || claims.Provider != "token" {
+		t.Errorf("unexpected claims: %+v", claims)
+	}
+
+	if _, err := ValidateAPIKey(ctx, "usr_unknown"); !errors.Is(err, ErrInvalidAPIKey) {
+		t.Errorf("expected ErrInvalidAPIKey, got %v", err)
+	}
+}
@@ -7,10 +7,14 @@ import (
 	"time"
 )
 
-var (
-	stsCache      = make(map[string]*cachedCredentials)
-	stsCacheMutex sync.RWMutex
-)
+// stsCredentialCache caches STS credentials by ID token hash. Each Manager owns
+// its own cache; the package-level functions share defaultSTSCache.
+type stsCredentialCache struct {
+	mu      sync.RWMutex
+	entries map[string]*cachedCredentials
+}
+
+var defaultSTSCache = newSTSCredentialCache()
 
 type cachedCredentials struct {
 	creds     *STSCredentials
@@ -19,11 +23,15 @@ type cachedCredentials struct {
 
 const cacheExpirationBuffer = 5 * time.Minute
 
-func getCachedCredentials(tokenHash string) *STSCredentials {
-	stsCacheMutex.RLock()
-	defer stsCacheMutex.RUnlock()
+func newSTSCredentialCache() *stsCredentialCache {
+	return &stsCredentialCache{entries: make(map[string]*cachedCredentials)}
+}
+
+func (c *stsCredentialCache) get(tokenHash string) *STSCredentials {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
 
-	cached, exists := stsCache[tokenHash]
+	cached, exists := c.entries[tokenHash]
 	if !exists {
 		return nil
 	}
@@ -35,23 +43,35 @@ func getCachedCredentials(tokenHash string) *STSCredentials {
 	return cached.creds
 }
 
-func setCachedCredentials(tokenHash string, creds *STSCredentials) {
-	stsCacheMutex.Lock()
-	defer stsCacheMutex.Unlock()
+func (c *stsCredentialCache) set(tokenHash string, creds *STSCredentials) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	stsCache[tokenHash] = &cachedCredentials{
+	c.entries[tokenHash] = &cachedCredentials{
 		creds:     creds,
 		expiresAt: creds.Expiration,
 	}
 }
 
+func (c *stsCredentialCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]*cachedCredentials)
+}
+
+func getCachedCredentials(tokenHash string) *STSCredentials {
+	return defaultSTSCache.get(tokenHash)
+}
+
+func setCachedCredentials(tokenHash string, creds *STSCredentials) {
+	defaultSTSCache.set(tokenHash, creds)
+}
+
 func hashToken(token string) string {
 	h := sha256.Sum256([]byte(token))
 	return hex.EncodeToString(h[:16])
 }
 
 func clearSTSCache() {
-	stsCacheMutex.Lock()
-	defer stsCacheMutex.Unlock()
-	stsCache = make(map[string]*cachedCredentials)
+	defaultSTSCache.clear()
 }
@@ -42,12 +42,29 @@ func GetSTSCredentials(ctx context.Context, idToken string, oauthConfig *OAuthCo
 		return nil, fmt.Errorf("oauth config is not set")
 	}
 
+	return defaultAWSClients(oauthConfig).stsCredentials(ctx, idToken)
+}
+
+// GetSTSCredentials exchanges a Cognito ID token for temporary AWS credentials
+// using the Manager's STS client and credential cache.
+func (m *Manager) GetSTSCredentials(ctx context.Context, idToken string) (*STSCredentials, error) {
+	if m.Config() == nil {
+		return nil, fmt.Errorf("oauth config is not set")
+	}
+
+	return m.clients().stsCredentials(ctx, idToken)
+}
+
+// stsCredentials implements GetSTSCredentials against the client's own STS
+// client and credential cache.
+func (c *awsClients) stsCredentials(ctx context.Context, idToken string) (*STSCredentials, error) {
+	oauthConfig := c.config
 	if idToken == "" {
 		return nil, fmt.Errorf("ID token is required")
 	}
 
 	tokenHash := hashToken(idToken)
-	if cached := getCachedCredentials(tokenHash); cached != nil {
+	if cached := c.stsCache.get(tokenHash); cached != nil {
 		return cached, nil
 	}
 
@@ -68,18 +85,11 @@ func GetSTSCredentials(ctx context.Context, idToken string, oauthConfig *OAuthCo
 		return nil, fmt.Errorf("role %s is not in the allowed roles from token", roleARN)
 	}
 
-	// Create STS client with anonymous credentials
-	// AssumeRoleWithWebIdentity authenticates via the web identity token (JWT), not AWS signature
-	cfg, err := config.LoadDefaultConfig(ctx,
-		config.WithRegion(oauthConfig.Region),
-		config.WithCredentialsProvider(aws.AnonymousCredentials{}),
-	)
+	stsClient, err := c.stsClient(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+		return nil, err
 	}
 
-	stsClient := stsClientFactory(ctx, cfg)
-
 	// Build session name
 	sessionName := buildSessionName(claims, oauthConfig)
 
@@ -113,11 +123,30 @@ func GetSTSCredentials(ctx context.Context, idToken string, oauthConfig *OAuthCo
 		Expiration:      aws.ToTime(result.Credentials.Expiration),
 	}
 
-	setCachedCredentials(tokenHash, creds)
+	c.stsCache.set(tokenHash, creds)
 
 	return creds, nil
 }
 
+// stsClient returns the injected STS client or builds one with anonymous
+// credentials: AssumeRoleWithWebIdentity authenticates via the web identity
+// token (JWT), not an AWS signature.
+func (c *awsClients) stsClient(ctx context.Context) (STSClient, error) {
+	if c.sts != nil {
+		return c.sts, nil
+	}
+
+	cfg, err := config.LoadDefaultConfig(ctx,
+		config.WithRegion(c.config.Region),
+		config.WithCredentialsProvider(aws.AnonymousCredentials{}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	return stsClientFactory(ctx, cfg), nil
+}
+
 // selectRoleARN determines which role ARN to use for STS.
 // Priority: 1) cognito:preferred_role from token, 2) first role in cognito:roles, 3) STSRoleARN from config
 func selectRoleARN(claims *OIDCClaims, oauthConfig *OAuthConfig) string {
@@ -0,0 +1,198 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// TenantKey is the key for storing the resolved tenant ID in the request context
+const TenantKey = contextKey("tenant")
+
+// TenantResolver extracts the requested tenant ID from a request, returning
+// "" when the request does not name one.
+type TenantResolver func(r *http.Request) string
+
+// TenantFromURLParam resolves the tenant from a chi route parameter.
+func TenantFromURLParam(name string) TenantResolver {
+	return func(r *http.Request) string {
+		return chi.URLParam(r, name)
+	}
+}
+
+// TenantFromHeader resolves the tenant from a request header.
+func TenantFromHeader(name string) TenantResolver {
+	return func(r *http.Request) string {
+		return strings.TrimSpace(r.Header.Get(name))
+	}
+}
+
+// TenantFromSubdomain resolves the tenant from the first label of the host
+// under baseDomain, e.g. "acme" for acme.example.com with baseDomain
+// "example.com". Requests to baseDomain itself name no tenant.
+func TenantFromSubdomain(baseDomain string) TenantResolver {
+	suffix := "." + strings.ToLower(strings.TrimPrefix(baseDomain, "."))
+	return func(r *http.Request) string {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		host = strings.ToLower(host)
+		if !strings.HasSuffix(host, suffix) {
+			return ""
+		}
+		sub := strings.TrimSuffix(host, suffix)
+		if i := strings.LastIndex(sub, "."); i >= 0 {
+			sub = sub[i+1:]
+		}
+		return sub
+	}
+}
+
+// TenantFromContext returns the tenant resolved by RequireTenant.
+func TenantFromContext(ctx context.Context) (string, bool) {
+	tenantID, ok := ctx.Value(TenantKey).(string)
+	return tenantID, ok && tenantID != ""
+}
+
+// isSuperAdmin reports whether claims hold one of the configured
+// SuperAdminRoles, which may cross tenant boundaries.
+func (m *Manager) isSuperAdmin(claims *Claims) bool {
+	config := m.Config()
+	if config == nil {
+		return false
+	}
+	for _, role := range config.SuperAdminRoles {
+		if m.HasRole(claims, role) {
+			return true
+		}
+	}
+	return false
+}
+
+// RequireTenant creates middleware that confines requests to the caller's
+// tenant (Claims.TenantID, from custom:tenantId). The requested tenant comes
+// from the first resolver that returns one; by default the "tenantId" route
+// parameter, then the X-Tenant-ID header. Requests for another tenant get a
+// 403 unless the caller holds one of OAuthConfig.SuperAdminRoles. Requests
+// naming no tenant are scoped to the caller's own.
+//
+// The resolved tenant is available to handlers through TenantFromContext.
+// It must run after RequireAuthMiddleware.
+func (m *Manager) RequireTenant(resolvers ...TenantResolver) func(http.Handler) http.Handler {
+	if len(resolvers) == 0 {
+		resolvers = []TenantResolver{TenantFromURLParam("tenantId"), TenantFromHeader("X-Tenant-ID")}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := GetClaimsFromContext(r)
+			if !ok || claims == nil {
+				log.Printf("❌ [RequireTenant] No claims in context - is RequireAuthMiddleware applied?")
+				writeError(w, http.StatusUnauthorized, "unauthorized", nil)
+				return
+			}
+
+			var requested string
+			for _, resolve := range resolvers {
+				if requested = resolve(r); requested != "" {
+					break
+				}
+			}
+
+			tenantID := claims.TenantID
+			switch {
+			case requested == "" && tenantID == "" && !m.isSuperAdmin(claims):
+				log.Printf("❌ [RequireTenant] %s has no tenant", claims.Email)
+				writeError(w, http.StatusForbidden, "forbidden", map[string]string{"tenant": "user has no tenant"})
+				return
+			case requested != "" && requested != tenantID:
+				if !m.isSuperAdmin(claims) {
+					log.Printf("❌ [RequireTenant] %s (tenant %q) denied access to tenant %q", claims.Email, tenantID, requested)
+					writeError(w, http.StatusForbidden, "forbidden", map[string]string{"tenant": requested})
+					return
+				}
+				log.Printf("🔓 [RequireTenant] Super admin %s accessing tenant %q", claims.Email, requested)
+				tenantID = requested
+			}
+
+			ctx := context.WithValue(r.Context(), TenantKey, tenantID)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// GetTenantUser returns the user only if they belong to tenantID. Users of
+// other tenants are reported as ErrUserNotFound so their existence does not
+// leak across tenants.
+func (m *Manager) GetTenantUser(ctx context.Context, tenantID, email string) (*User, error) {
+	if tenantID == "" {
+		return nil, fmt.Errorf("tenant ID cannot be empty: %w", ErrInvalidInput)
+	}
+
+	user, err := m.GetUser(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	if user.TenantID != tenantID {
+		return nil, fmt.Errorf("user %s: %w", email, ErrUserNotFound)
+	}
+	return user, nil
+}
+
+// ListTenantUsers lists the users of tenantID with the same limit and offset
+// semantics as ListUsers. Cognito cannot filter on custom attributes, so the
+// pool is paged through and filtered here.
+func (m *Manager) ListTenantUsers(ctx context.Context, tenantID string, limit, offset int) ([]*User, error) {
+	if tenantID == "" {
+		return nil, fmt.Errorf("tenant ID cannot be empty: %w", ErrInvalidInput)
+	}
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > 60 {
+		limit = 60
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	store, err := m.userStore()
+	if err != nil {
+		return nil, err
+	}
+
+	var matched []*UserRecord
+	pageToken := ""
+	for {
+		records, nextToken, err := store.ListUsers(ctx, 60, pageToken)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, record := range records {
+			if record.Attributes["custom:tenantId"] == tenantID {
+				matched = append(matched, record)
+			}
+		}
+
+		if nextToken == "" || len(matched) >= limit+offset {
+			break
+		}
+		pageToken = nextToken
+	}
+
+	if offset >= len(matched) {
+		return []*User{}, nil
+	}
+	matched = matched[offset:]
+	if len(matched) > limit {
+		matched = matched[:limit]
+	}
+	return recordsToUsers(matched, m.roleAttributeName()), nil
+}
@@ -0,0 +1,131 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestRequireTenant(t *testing.T) {
+	m := NewManager(&OAuthConfig{SuperAdminRoles: []string{"admin"}})
+
+	r := chi.NewRouter()
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims := &Claims{Email: "u@example.com", Role: r.Header.Get("X-Test-Role"), TenantID: r.Header.Get("X-Test-Tenant")}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), ClaimsKey, claims)))
+		})
+	})
+	var gotTenant string
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		gotTenant, _ = TenantFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	}
+	r.With(m.RequireTenant()).Get("/tenants/{tenantId}/things", handler)
+	r.With(m.RequireTenant()).Get("/things", handler)
+
+	tests := []struct {
+		name       string
+		path       string
+		role       string
+		tenant     string
+		header     string
+		wantCode   int
+		wantTenant string
+	}{
+		{"own tenant from route", "/tenants/acme/things", "user", "acme", "", http.StatusOK, "acme"},
+		{"other tenant from route", "/tenants/globex/things", "user", "acme", "", http.StatusForbidden, ""},
+		{"other tenant from header", "/things", "user", "acme", "globex", http.StatusForbidden, ""},
+		{"no tenant named uses own", "/things", "user", "acme", "", http.StatusOK, "acme"},
+		{"user without tenant", "/things", "user", "", "", http.StatusForbidden, ""},
+		{"user without tenant naming one", "/things", "user", "", "acme", http.StatusForbidden, ""},
+		{"super admin crosses tenants", "/tenants/globex/things", "admin", "acme", "", http.StatusOK, "globex"},
+		{"manager is not super admin", "/tenants/globex/things", "manager", "acme", "", http.StatusForbidden, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotTenant = ""
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set("X-Test-Role", tt.role)
+			req.Header.Set("X-Test-Tenant", tt.tenant)
+			if tt.header != "" {
+				req.Header.Set("X-Tenant-ID", tt.header)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.wantCode {
+				t.Fatalf("expected %d, got %d: %s", tt.wantCode, w.Code, w.Body.String())
+			}
+			if gotTenant != tt.wantTenant {
+				t.Errorf("expected tenant %q in context, got %q", tt.wantTenant, gotTenant)
+			}
+		})
+	}
+}
+
+func TestTenantFromSubdomain(t *testing.T) {
+	resolve := TenantFromSubdomain("example.com")
+
+	tests := map[string]string{
+		"acme.example.com":      "acme",
+		"acme.example.com:8443": "acme",
+		"api.acme.example.com":  "acme",
+		"example.com":           "",
+		"acme.evil.com":         "",
+		"acmeexample.com":       "",
+	}
+	for host, want := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Host = host
+		if got := resolve(req); got != want {
+			t.Errorf("%s: expected %q, got %q", host, want, got)
+		}
+	}
+}
+
+func TestTenantScopedUsers(t *testing.T) {
+	ctx := context.Background()
+	m := NewManager(&OAuthConfig{}, WithUserStore(NewMemoryUserStore()))
+
+	for _, u := range []struct{ email, tenant string }{
+		{"a1@example.com", "acme"},
+		{"g1@example.com", "globex"},
+		{"a2@example.com", "acme"},
+		{"a3@example.com", "acme"},
+	} {
+		if _, err := m.CreateUser(ctx, CreateUserRequest{Email: u.email, CustomAttributes: map[string]string{"tenantId": u.tenant}}); err != nil {
+			t.Fatalf("CreateUser: %v", err)
+		}
+	}
+
+	if _, err := m.GetTenantUser(ctx, "acme", "a1@example.com"); err != nil {
+		t.Errorf("expected a1 in acme, got %v", err)
+	}
+	if _, err := m.GetTenantUser(ctx, "acme", "g1@example.com"); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("expected ErrUserNotFound for another tenant's user, got %v", err)
+	}
+	if _, err := m.GetTenantUser(ctx, "", "a1@example.com"); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("expected ErrInvalidInput for empty tenant, got %v", err)
+	}
+
+	users, err := m.ListTenantUsers(ctx, "acme", 2, 1)
+	if err != nil {
+		t.Fatalf("ListTenantUsers: %v", err)
+	}
+	if len(users) != 2 || users[0].Email != "a2@example.com" || users[1].Email != "a3@example.com" {
+		t.Errorf("unexpected page: %+v", users)
+	}
+	for _, u := range users {
+		if u.TenantID != "acme" {
+			t.Errorf("ListTenantUsers returned %s from tenant %q", u.Email, u.TenantID)
+		}
+	}
+
+	if users, _ := m.ListTenantUsers(ctx, "acme", 10, 5); len(users) != 0 {
+		t.Errorf("expected empty page past the end, got %d users", len(users))
+	}
+}
@@ -0,0 +1,256 @@
+package user
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	defaultTokenCacheTTL         = 5 * time.Minute
+	defaultTokenCacheNegativeTTL = 30 * time.Second
+	defaultTokenCacheMaxEntries  = 10000
+)
+
+// TokenCacheStats reports opaque-token cache activity since the last clear.
+type TokenCacheStats struct {
+	Hits         uint64 `json:"hits"`         // Lookups answered from the cache (including negative hits)
+	NegativeHits uint64 `json:"negativeHits"` // Hits on tokens cached as unknown
+	Misses       uint64 `json:"misses"`       // Lookups that went to the user store
+	Evictions    uint64 `json:"evictions"`    // Entries dropped to stay within the size bound
+	Entries      int    `json:"entries"`      // Entries currently cached
+}
+
+type tokenCacheEntry struct {
+	tokenHash string
+	claims    *Claims // nil for a negative entry
+	expiresAt time.Time
+}
+
+// tokenClaimsCache is a bounded LRU cache of opaque token -> Claims with
+// per-entry TTLs. Tokens are keyed by their SHA-256 so raw API keys are not
+// held in memory longer than the request that carried them.
+type tokenClaimsCache struct {
+	mu         sync.Mutex
+	entries    map[string]*list.Element
+	lru        *list.List // front = most recently used
+	stats      TokenCacheStats
+	generation uint64 // bumped by every invalidation
+}
+
+func newTokenClaimsCache() *tokenClaimsCache {
+	return &tokenClaimsCache{
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// get returns a copy of the cached claims and whether the token was found.
+// A found token with nil claims is a negative entry.
+func (c *tokenClaimsCache) get(token string) (*Claims, bool) {
+	tokenHash := hashToken(token)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[tokenHash]
+	if !ok {
+		c.stats.Misses++
+		return nil, false
+	}
+
+	entry := elem.Value.(*tokenCacheEntry)
+	if time.Now().After(entry.expiresAt) {
+		c.removeElement(elem)
+		c.stats.Misses++
+		return nil, false
+	}
+
+	c.lru.MoveToFront(elem)
+	c.stats.Hits++
+	if entry.claims == nil {
+		c.stats.NegativeHits++
+		return nil, true
+	}
+	return copyClaims(entry.claims), true
+}
+
+// currentGeneration returns the invalidation counter, to be read before a
+// user store lookup and passed to setIfGeneration with its result.
+func (c *tokenClaimsCache) currentGeneration() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.generation
+}
+
+// set caches claims (or a negative entry when claims is nil) for ttl,
+// evicting the least recently used entries beyond maxEntries.
+func (c *tokenClaimsCache) set(token string, claims *Claims, ttl time.Duration, maxEntries int) {
+	c.setIfGeneration(c.currentGeneration(), token, claims, ttl, maxEntries)
+}
+
+// setIfGeneration is set, skipped when the cache was invalidated since
+// generation was read: the lookup may have seen the user before the change.
+func (c *tokenClaimsCache) setIfGeneration(generation uint64, token string, claims *Claims, ttl time.Duration, maxEntries int) {
+	if ttl <= 0 || maxEntries <= 0 {
+		return
+	}
+	tokenHash := hashToken(token)
+	entry := &tokenCacheEntry{
+		tokenHash: tokenHash,
+		expiresAt: time.Now().Add(ttl),
+	}
+	if claims != nil {
+		entry.claims = copyClaims(claims)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.generation != generation {
+		return
+	}
+	if elem, ok := c.entries[tokenHash]; ok {
+		elem.Value = entry
+		c.lru.MoveToFront(elem)
+	} else {
+		c.entries[tokenHash] = c.lru.PushFront(entry)
+	}
+
+	for c.lru.Len() > maxEntries {
+		c.removeElement(c.lru.Back())
+		c.stats.Evictions++
+	}
+}
+
+// invalidateToken drops the entry for token, positive or negative.
+func (c *tokenClaimsCache) invalidateToken(token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	if elem, ok := c.entries[hashToken(token)]; ok {
+		c.removeElement(elem)
+	}
+}
+
+// invalidateUser drops every cached token that resolved to the user with the
+// given email or username, and every negative entry: those do not record
+// which user a token belonged to, and may hold the keys of a user who was
+// disabled until now.
+func (c *tokenClaimsCache) invalidateUser(email string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	for elem := c.lru.Front(); elem != nil; {
+		next := elem.Next()
+		if claims := elem.Value.(*tokenCacheEntry).claims; claims == nil || claims.Email == email || claims.Username == email {
+			c.removeElement(elem)
+		}
+		elem = next
+	}
+}
+
+func (c *tokenClaimsCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = make(map[string]*list.Element)
+	c.lru.Init()
+	c.stats = TokenCacheStats{}
+	c.generation++
+}
+
+func (c *tokenClaimsCache) snapshot() TokenCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats
+	stats.Entries = c.lru.Len()
+	return stats
+}
+
+func (c *tokenClaimsCache) removeElement(elem *list.Element) {
+	c.lru.Remove(elem)
+	delete(c.entries, elem.Value.(*tokenCacheEntry).tokenHash)
+}
+
+func copyClaims(claims *Claims) *Claims {
+	cp := *claims
+	if claims.Scopes != nil {
+		cp.Scopes = append([]string(nil), claims.Scopes...)
+	}
+	return &cp
+}
+
+// tokenCacheSettings returns the cache TTLs and size bound from the config.
+// A negative TTL disables that kind of caching.
+func tokenCacheSettings(config *OAuthConfig) (ttl, negativeTTL time.Duration, maxEntries int) {
+	ttl, negativeTTL, maxEntries = defaultTokenCacheTTL, defaultTokenCacheNegativeTTL, defaultTokenCacheMaxEntries
+	if config == nil {
+		return
+	}
+	if config.TokenCacheTTLSeconds != 0 {
+		ttl = time.Duration(config.TokenCacheTTLSeconds) * time.Second
+	}
+	if config.TokenCacheNegativeTTLSeconds != 0 {
+		negativeTTL = time.Duration(config.TokenCacheNegativeTTLSeconds) * time.Second
+	}
+	if config.TokenCacheMaxEntries != 0 {
+		maxEntries = config.TokenCacheMaxEntries
+	}
+	return
+}
+
+// cachedClaimsForToken resolves an opaque token through the token cache,
+// falling back to the user store. Unknown tokens, and keys of disabled
+// users, are cached briefly so repeated bad tokens do not each cost a user
+// pool query; backend errors are never cached, nor are results of lookups
+// that overlapped an invalidation.
+func (m *Manager) cachedClaimsForToken(ctx context.Context, token string) (*Claims, error) {
+	if claims, ok := m.tokenCache.get(token); ok {
+		if claims == nil {
+			return nil, errTokenNotFound
+		}
+		return claims, nil
+	}
+
+	store, err := m.userStore()
+	if err != nil {
+		return nil, err
+	}
+
+	config := m.Config()
+	ttl, negativeTTL, maxEntries := tokenCacheSettings(config)
+
+	generation := m.tokenCache.currentGeneration()
+	claims, key, err := findTokenClaims(ctx, store, token, config)
+	if err == errTokenNotFound {
+		m.tokenCache.setIfGeneration(generation, token, nil, negativeTTL, maxEntries)
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if key != nil && key.ExpiresAt != 0 {
+		if untilExpiry := time.Until(time.Unix(key.ExpiresAt, 0)); untilExpiry < ttl {
+			ttl = untilExpiry
+		}
+	}
+	m.tokenCache.setIfGeneration(generation, token, claims, ttl, maxEntries)
+	return claims, nil
+}
+
+// TokenCacheStats returns hit/miss statistics for the opaque-token cache used
+// by RequireAuthMiddleware.
+func (m *Manager) TokenCacheStats() TokenCacheStats {
+	return m.tokenCache.snapshot()
+}
+
+// ClearTokenCache drops every cached token lookup and resets the statistics.
+func (m *Manager) ClearTokenCache() {
+	m.tokenCache.clear()
+}
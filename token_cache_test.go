@@ -0,0 +1,246 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+// countingUserStore counts token lookups that reach the backend.
+type countingUserStore struct {
+	*MemoryUserStore
+	lookups int32
+}
+
+func (s *countingUserStore) FindUsersByAttribute(ctx context.Context, name, value string, limit int) ([]*UserRecord, error) {
+	atomic.AddInt32(&s.lookups, 1)
+	return s.MemoryUserStore.FindUsersByAttribute(ctx, name, value, limit)
+}
+
+func newTokenCacheTestManager(t *testing.T, config *OAuthConfig) (*Manager, *countingUserStore, string) {
+	t.Helper()
+	store := &countingUserStore{MemoryUserStore: NewMemoryUserStore()}
+	m := newTestManager(t, config, store, []CreateUserRequest{{Email: "cache@example.com", Role: "admin"}})
+
+	apiKey, err := m.GenerateAPIKey(context.Background(), "cache@example.com")
+	if err != nil {
+		t.Fatalf("GenerateAPIKey: %v", err)
+	}
+	atomic.StoreInt32(&store.lookups, 0)
+	return m, store, apiKey
+}
+
+func TestTokenCache_HitsSkipStore(t *testing.T) {
+	m, store, apiKey := newTokenCacheTestManager(t, &OAuthConfig{})
+
+	handler := m.RequireAuthMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, _ := GetClaimsFromContext(r)
+		if claims.Email != "cache@example.com" {
+			t.Errorf("expected cached claims for cache@example.com, got %+v", claims)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	for i := 0; i < 3; i++ {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("request %d: expected 200, got %d: %s", i, w.Code, w.Body.String())
+		}
+	}
+
+	if got := atomic.LoadInt32(&store.lookups); got != 1 {
+		t.Errorf("expected 1 store lookup, got %d", got)
+	}
+	stats := m.TokenCacheStats()
+	if stats.Hits != 2 || stats.Misses != 1 || stats.Entries != 1 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestTokenCache_NegativeEntries(t *testing.T) {
+	m, store, _ := newTokenCacheTestManager(t, &OAuthConfig{})
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, err := m.cachedClaimsForToken(ctx, "unknown-token"); !errors.Is(err, errTokenNotFound) {
+			t.Fatalf("expected errTokenNotFound, got %v", err)
+		}
+	}
+	if got := atomic.LoadInt32(&store.lookups); got != 1 {
+		t.Errorf("expected 1 store lookup, got %d", got)
+	}
+	if stats := m.TokenCacheStats(); stats.NegativeHits != 1 {
+		t.Errorf("expected 1 negative hit, got %+v", stats)
+	}
+
+	// A key assigned after the negative lookup must work immediately.
+	key := newAPIKey("")
+	if err := m.UpdateAPIKey(ctx, "cache@example.com", key); err != nil {
+		t.Fatalf("UpdateAPIKey: %v", err)
+	}
+	if _, err := m.cachedClaimsForToken(ctx, "unknown-token"); err == nil {
+		t.Fatal("expected unknown token to stay unknown")
+	}
+	if _, err := m.cachedClaimsForToken(ctx, key); err != nil {
+		t.Errorf("expected updated key to resolve, got %v", err)
+	}
+}
+
+func TestTokenCache_InvalidatedOnKeyAndUserChanges(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		change func(m *Manager) error
+	}{
+		{"RotateAPIKey", func(m *Manager) error {
+			_, err := m.RotateAPIKey(ctx, "cache@example.com")
+			return err
+		}},
+		{"GenerateAPIKey", func(m *Manager) error {
+			_, err := m.GenerateAPIKey(ctx, "cache@example.com")
+			return err
+		}},
+		{"RevokeAPIKey", func(m *Manager) error {
+			keys, err := m.ListAPIKeys(ctx, "cache@example.com")
+			if err != nil {
+				return err
+			}
+			return m.RevokeAPIKey(ctx, "cache@example.com", keys[0].ID)
+		}},
+		{"UpdateRole", func(m *Manager) error {
+			_, err := m.UpdateRole(ctx, "cache@example.com", "user")
+			return err
+		}},
+		{"DisableUser", func(m *Manager) error {
+			return m.DisableUser(ctx, "cache@example.com")
+		}},
+		{"DeleteUser", func(m *Manager) error {
+			return m.DeleteUser(ctx, "cache@example.com")
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, _, apiKey := newTokenCacheTestManager(t, &OAuthConfig{})
+			if _, err := m.cachedClaimsForToken(ctx, apiKey); err != nil {
+				t.Fatalf("cachedClaimsForToken: %v", err)
+			}
+			if err := tt.change(m); err != nil {
+				t.Fatalf("%s: %v", tt.name, err)
+			}
+			if stats := m.TokenCacheStats(); stats.Entries != 0 {
+				t.Errorf("expected cache entry to be invalidated, got %+v", stats)
+			}
+		})
+	}
+}
+
+func TestTokenCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	cache := newTokenClaimsCache()
+	for i := 0; i < 3; i++ {
+		cache.set(fmt.Sprintf("token-%d", i), &Claims{Email: fmt.Sprintf("u%d@example.com", i)}, defaultTokenCacheTTL, 2)
+	}
+
+	if _, ok := cache.get("token-0"); ok {
+		t.Error("expected oldest entry to be evicted")
+	}
+	if claims, ok := cache.get("token-2"); !ok || claims.Email != "u2@example.com" {
+		t.Errorf("expected newest entry to be cached, got %+v", claims)
+	}
+	if stats := cache.snapshot(); stats.Evictions != 1 || stats.Entries != 2 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestTokenCache_DisabledByNegativeTTL(t *testing.T) {
+	m, store, apiKey := newTokenCacheTestManager(t, &OAuthConfig{TokenCacheTTLSeconds: -1})
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, err := m.cachedClaimsForToken(ctx, apiKey); err != nil {
+			t.Fatalf("cachedClaimsForToken: %v", err)
+		}
+	}
+	if got := atomic.LoadInt32(&store.lookups); got != 2 {
+		t.Errorf("expected every lookup to reach the store, got %d", got)
+	}
+}
+
+func TestTokenCache_DisabledUserKeyRejected(t *testing.T) {
+	m, store, apiKey := newTokenCacheTestManager(t, &OAuthConfig{})
+	ctx := context.Background()
+
+	if err := m.DisableUser(ctx, "cache@example.com"); err != nil {
+		t.Fatalf("DisableUser: %v", err)
+	}
+	if _, err := m.cachedClaimsForToken(ctx, apiKey); !errors.Is(err, errTokenNotFound) {
+		t.Fatalf("expected a disabled user's key to be rejected, got %v", err)
+	}
+	lookups := atomic.LoadInt32(&store.lookups)
+	if _, err := m.cachedClaimsForToken(ctx, apiKey); !errors.Is(err, errTokenNotFound) {
+		t.Fatalf("expected a disabled user's key to stay rejected, got %v", err)
+	}
+	if got := atomic.LoadInt32(&store.lookups); got != lookups {
+		t.Errorf("expected the rejection to be cached, got %d more store lookups", got-lookups)
+	}
+	if stats := m.TokenCacheStats(); stats.NegativeHits != 1 {
+		t.Errorf("expected a negative hit, got %+v", stats)
+	}
+}
+
+func TestTokenCache_EnableUserDropsRejection(t *testing.T) {
+	m, _, apiKey := newTokenCacheTestManager(t, &OAuthConfig{})
+	ctx := context.Background()
+
+	if err := m.DisableUser(ctx, "cache@example.com"); err != nil {
+		t.Fatalf("DisableUser: %v", err)
+	}
+	if _, err := m.cachedClaimsForToken(ctx, apiKey); !errors.Is(err, errTokenNotFound) {
+		t.Fatalf("expected a disabled user's key to be rejected, got %v", err)
+	}
+
+	if err := m.EnableUser(ctx, "cache@example.com"); err != nil {
+		t.Fatalf("EnableUser: %v", err)
+	}
+	claims, err := m.cachedClaimsForToken(ctx, apiKey)
+	if err != nil {
+		t.Fatalf("expected the key to work once the user is re-enabled, got %v", err)
+	}
+	if claims.Email != "cache@example.com" {
+		t.Errorf("expected claims for cache@example.com, got %+v", claims)
+	}
+}
+
+// invalidatingUserStore invalidates the cache while a lookup is in flight,
+// like a DisableUser racing with authentication.
+type invalidatingUserStore struct {
+	*countingUserStore
+	m *Manager
+}
+
+func (s *invalidatingUserStore) FindUsersByAttribute(ctx context.Context, name, value string, limit int) ([]*UserRecord, error) {
+	records, err := s.countingUserStore.FindUsersByAttribute(ctx, name, value, limit)
+	s.m.tokenCache.invalidateUser("cache@example.com")
+	return records, err
+}
+
+func TestTokenCache_SkipsWritesOverlappingInvalidation(t *testing.T) {
+	m, counting, apiKey := newTokenCacheTestManager(t, &OAuthConfig{})
+	store := &invalidatingUserStore{countingUserStore: counting, m: m}
+	m.store = store
+
+	if _, err := m.cachedClaimsForToken(context.Background(), apiKey); err != nil {
+		t.Fatalf("cachedClaimsForToken: %v", err)
+	}
+	if stats := m.TokenCacheStats(); stats.Entries != 0 {
+		t.Errorf("expected a result read before the invalidation not to be cached, got %+v", stats)
+	}
+}
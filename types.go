@@ -21,6 +21,39 @@ type User struct {
 	ServiceProviderID string `json:"serviceProviderId,omitempty"` // Service provider from custom:serviceProviderId
 	UserStatus        string `json:"userStatus,omitempty"`        // Cognito user status (CONFIRMED, FORCE_CHANGE_PASSWORD, etc.)
 	Enabled           bool   `json:"enabled"`                     // Whether user account is enabled
+	// MFA status, set by GetUser; users from list calls leave it empty
+	MFAMethods   []MFAMethod `json:"mfaMethods,omitempty"`   // Enabled MFA methods
+	PreferredMFA MFAMethod   `json:"preferredMfa,omitempty"` // Method used for sign-in challenges
+	// Group membership, set by GetUser when OAuthConfig.LoadUserGroups is
+	// set and the store supports groups. These
+	// mirror the cognito:groups, cognito:roles and cognito:preferred_role
+	// claims of the user's next tokens.
+	Groups           []string `json:"groups,omitempty"`           // Group names by precedence
+	GroupRoleARNs    []string `json:"groupRoleArns,omitempty"`    // IAM roles of those groups
+	PreferredRoleARN string   `json:"preferredRoleArn,omitempty"` // Role of the highest-precedence group
+	// CustomAttributes holds every custom:* attribute keyed without the
+	// prefix, including the ones mapped to fields above. API key attributes
+	// are left out.
+	CustomAttributes map[string]string `json:"customAttributes,omitempty"`
+}
+
+// APIKey describes one of a user's API keys. The raw key is only returned
+// when the key is created or rotated.
+type APIKey struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"` // Public lookup prefix shared by the user's keys
+	Scopes     []string   `json:"scopes,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	ExpiresAt  *time.Time `json:"expiresAt,omitempty"` // Nil for keys that never expire
+}
+
+// CreateAPIKeyRequest represents a request to create a named API key
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes,omitempty"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
 }
 
 // CreateUserRequest represents a request to create a new user
@@ -47,7 +80,7 @@ type ProfileUpdate struct {
 type OAuthConfig struct {
 	// Required Cognito/OAuth2 fields
 	ClientID     string   `json:"clientId"`
-	ClientSecret string   `json:"clientSecret"`
+	ClientSecret string   `json:"clientSecret"` // Empty for public clients (PKCE only)
 	UserPoolID   string   `json:"userPoolId"`
 	RedirectURI  string   `json:"redirectUri"` // Full URL, path extracted internally
 	Region       string   `json:"region"`
@@ -74,9 +107,61 @@ type OAuthConfig struct {
 	FromEmail string `json:"fromEmail,omitempty"` // From address for invitation emails
 	AppName   string `json:"appName,omitempty"`   // Application name for email branding
 
-	// Cognito custom attribute name for user role (defaults to "custom:role")
+	// Cognito custom attribute name for user role (defaults to "custom:role"),
+	// read into Claims.Role from API key lookups, and from ID tokens unless
+	// CalculateDefaultRole is set.
 	// Set to "custom:userRole" for pools that use that attribute name instead.
 	RoleAttributeName string `json:"roleAttributeName,omitempty"`
+
+	// How long the previous key keeps working after RotateAPIKey (defaults to 24 hours)
+	APIKeyRotationGraceSeconds int `json:"apiKeyRotationGraceSeconds,omitempty"`
+
+	// Opaque-token cache used by RequireAuthMiddleware. Zero values use the
+	// defaults (5 minutes, 30 seconds, 10000 entries); a negative TTL disables
+	// that kind of caching.
+	TokenCacheTTLSeconds         int `json:"tokenCacheTtlSeconds,omitempty"`
+	TokenCacheNegativeTTLSeconds int `json:"tokenCacheNegativeTtlSeconds,omitempty"`
+	TokenCacheMaxEntries         int `json:"tokenCacheMaxEntries,omitempty"`
+
+	// Lifetime of the refresh token cookie or session (defaults to 30 days,
+	// Cognito's default refresh token validity)
+	RefreshTokenMaxAgeSeconds int `json:"refreshTokenMaxAgeSeconds,omitempty"`
+
+	// Role-to-permission map used by RequireRole and RequirePermission
+	// (defaults to DefaultRoles: admin inherits manager, which inherits user)
+	Roles map[string]RoleDefinition `json:"roles,omitempty"`
+
+	// Roles allowed to cross tenant boundaries in RequireTenant (none by default)
+	SuperAdminRoles []string `json:"superAdminRoles,omitempty"`
+
+	// Validation rules by attribute name, enforced when users are created
+	// and when their attributes are updated
+	AttributeSchema map[string]AttributeRule `json:"attributeSchema,omitempty"`
+
+	// Fill User.Groups, GroupRoleARNs and PreferredRoleARN in GetUser. Off by
+	// default, since it costs a group listing per lookup; ListGroupsForUser
+	// works either way.
+	LoadUserGroups bool `json:"loadUserGroups,omitempty"`
+
+	// Sign the user out everywhere (see SignOutUser) as part of DisableUser,
+	// DeleteUser, SetUserPassword and UpdateRole
+	SignOutOnAccountChange bool `json:"signOutOnAccountChange,omitempty"`
+
+	// Cognito flow used by Login (defaults to USER_PASSWORD_AUTH). The app
+	// client must have the flow enabled.
+	LoginAuthFlow AuthFlow `json:"loginAuthFlow,omitempty"`
+
+	// Self-service password reset (ForgotPassword). The code lifetime
+	// defaults to 15 minutes; the rate limit, per client IP and per email
+	// address, defaults to 5 requests per 15 minutes. PasswordResetURL is an
+	// optional link to the app's reset page, included in the email.
+	PasswordResetCodeTTLSeconds int    `json:"passwordResetCodeTtlSeconds,omitempty"`
+	PasswordResetRateLimit      int    `json:"passwordResetRateLimit,omitempty"`
+	PasswordResetURL            string `json:"passwordResetUrl,omitempty"`
+
+	// Rules for new passwords. When nil, the user pool's policy is read
+	// with DescribeUserPool (see GetPasswordPolicy).
+	PasswordPolicy *PasswordPolicy `json:"passwordPolicy,omitempty"`
 }
 
 // STSCredentials represents temporary AWS credentials obtained via STS
@@ -0,0 +1,115 @@
+package user
+
+import (
+	"context"
+	"iter"
+	"time"
+)
+
+// AllUsersOptions configures AllUsers.
+type AllUsersOptions struct {
+	PageSize     int           // Users requested per page (defaults to 60, Cognito's maximum)
+	PageInterval time.Duration // Minimum time between page requests, to stay under Cognito's ListUsers quota
+	Filter       UserFilter    // Same semantics as ListUsersPage
+}
+
+// AllUsers streams every user in the pool, one store page at a time, so jobs
+// over large pools never hold more than a page in memory:
+//
+//	for u, err := range m.AllUsers(ctx, user.AllUsersOptions{PageInterval: 100 * time.Millisecond}) {
+//		if err != nil {
+//			return err
+//		}
+//		...
+//	}
+//
+// On a store error or context cancellation the error is yielded once and the
+// iteration ends. Breaking out of the loop stops further page requests.
+func (m *Manager) AllUsers(ctx context.Context, opts AllUsersOptions) iter.Seq2[*User, error] {
+	return func(yield func(*User, error) bool) {
+		err := m.walkUsers(ctx, opts, func(_ *UserRecord, user *User) bool {
+			return yield(user, nil)
+		})
+		if err != nil {
+			yield(nil, err)
+		}
+	}
+}
+
+// walkUsers calls fn for every user matching opts.Filter, together with the
+// store record it was mapped from, until fn returns false. It returns the
+// first store or context error.
+func (m *Manager) walkUsers(ctx context.Context, opts AllUsersOptions, fn func(*UserRecord, *User) bool) error {
+	pageSize := opts.PageSize
+	if pageSize <= 0 || pageSize > 60 {
+		pageSize = 60
+	}
+
+	store, err := m.userStore()
+	if err != nil {
+		return err
+	}
+	list := store.ListUsers
+	if filtered, ok := store.(FilteredUserLister); ok {
+		list = func(ctx context.Context, limit int, pageToken string) ([]*UserRecord, string, error) {
+			return filtered.ListUsersFiltered(ctx, limit, pageToken, opts.Filter)
+		}
+	}
+
+	roleAttr := m.roleAttributeName()
+	pageToken := ""
+	var lastRequest time.Time
+	for {
+		if err := waitForPage(ctx, lastRequest, opts.PageInterval); err != nil {
+			return err
+		}
+		lastRequest = time.Now()
+
+		records, nextToken, err := list(ctx, pageSize, pageToken)
+		if err != nil {
+			return err
+		}
+
+		for _, record := range records {
+			user, err := recordToUser(record, roleAttr)
+			if err != nil || !opts.Filter.matches(user) {
+				continue
+			}
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if !fn(record, user) {
+				return nil
+			}
+		}
+
+		if nextToken == "" {
+			return nil
+		}
+		pageToken = nextToken
+	}
+}
+
+// waitForPage blocks until interval has passed since last, or ctx is done.
+func waitForPage(ctx context.Context, last time.Time, interval time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if last.IsZero() || interval <= 0 {
+		return nil
+	}
+
+	wait := time.Until(last.Add(interval))
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,149 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// pagingUserStore counts ListUsers calls and can fail on a given call.
+type pagingUserStore struct {
+	*MemoryUserStore
+	calls  int
+	failOn int
+}
+
+func (s *pagingUserStore) ListUsers(ctx context.Context, limit int, pageToken string) ([]*UserRecord, string, error) {
+	s.calls++
+	if s.calls == s.failOn {
+		return nil, "", errors.New("throttled")
+	}
+	return s.MemoryUserStore.ListUsers(ctx, limit, pageToken)
+}
+
+func newAllUsersTestManager(t *testing.T, n int) (*Manager, *pagingUserStore) {
+	t.Helper()
+	store := &pagingUserStore{MemoryUserStore: NewMemoryUserStore()}
+	users := make([]CreateUserRequest, n)
+	for i := range users {
+		users[i].Email = fmt.Sprintf("user%03d@example.com", i)
+	}
+	return newTestManager(t, &OAuthConfig{}, store, users), store
+}
+
+func TestAllUsers_StreamsEveryPage(t *testing.T) {
+	m, store := newAllUsersTestManager(t, 150)
+
+	seen := make(map[string]bool)
+	for u, err := range m.AllUsers(context.Background(), AllUsersOptions{PageSize: 40}) {
+		if err != nil {
+			t.Fatalf("AllUsers: %v", err)
+		}
+		if seen[u.Email] {
+			t.Fatalf("duplicate user %s", u.Email)
+		}
+		seen[u.Email] = true
+	}
+
+	if len(seen) != 150 {
+		t.Errorf("expected 150 users, got %d", len(seen))
+	}
+	if store.calls != 4 {
+		t.Errorf("expected 4 pages of 40, got %d calls", store.calls)
+	}
+}
+
+func TestAllUsers_StopsOnBreak(t *testing.T) {
+	m, store := newAllUsersTestManager(t, 150)
+
+	count := 0
+	for range m.AllUsers(context.Background(), AllUsersOptions{PageSize: 20}) {
+		count++
+		if count == 5 {
+			break
+		}
+	}
+	if store.calls != 1 {
+		t.Errorf("expected no page requests after break, got %d calls", store.calls)
+	}
+}
+
+func TestAllUsers_YieldsErrorAndStops(t *testing.T) {
+	m, store := newAllUsersTestManager(t, 100)
+	store.failOn = 2
+
+	var users, errs int
+	for _, err := range m.AllUsers(context.Background(), AllUsersOptions{PageSize: 30}) {
+		if err != nil {
+			errs++
+			continue
+		}
+		users++
+	}
+	if users != 30 || errs != 1 {
+		t.Errorf("expected 30 users then 1 error, got %d users and %d errors", users, errs)
+	}
+	if store.calls != 2 {
+		t.Errorf("expected iteration to stop after the failing page, got %d calls", store.calls)
+	}
+}
+
+func TestAllUsers_ContextCancellation(t *testing.T) {
+	m, _ := newAllUsersTestManager(t, 100)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var users int
+	var lastErr error
+	for _, err := range m.AllUsers(ctx, AllUsersOptions{PageSize: 30}) {
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		users++
+		if users == 10 {
+			cancel()
+		}
+	}
+	if !errors.Is(lastErr, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", lastErr)
+	}
+	if users != 10 {
+		t.Errorf("expected iteration to stop at cancellation, got %d users", users)
+	}
+}
+
+func TestAllUsers_PageInterval(t *testing.T) {
+	m, store := newAllUsersTestManager(t, 90)
+
+	start := time.Now()
+	for _, err := range m.AllUsers(context.Background(), AllUsersOptions{PageSize: 30, PageInterval: 25 * time.Millisecond}) {
+		if err != nil {
+			t.Fatalf("AllUsers: %v", err)
+		}
+	}
+	// Three pages: two waits between them.
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("expected page requests to be spaced out, finished in %v", elapsed)
+	}
+	if store.calls != 3 {
+		t.Errorf("expected 3 calls, got %d", store.calls)
+	}
+}
+
+func TestAllUsers_Filter(t *testing.T) {
+	m, _ := newAllUsersTestManager(t, 30)
+
+	var got []string
+	for u, err := range m.AllUsers(context.Background(), AllUsersOptions{Filter: UserFilter{EmailPrefix: "user01"}}) {
+		if err != nil {
+			t.Fatalf("AllUsers: %v", err)
+		}
+		got = append(got, u.Email)
+	}
+	if len(got) != 10 {
+		t.Errorf("expected user010..user019, got %v", got)
+	}
+}
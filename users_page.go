@@ -0,0 +1,208 @@
+package user
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// UserFilter narrows a ListUsersPage listing. Zero fields match everything;
+// set fields must all match.
+type UserFilter struct {
+	EmailPrefix       string `json:"emailPrefix,omitempty"`       // Case-insensitive email prefix
+	Status            string `json:"status,omitempty"`            // Cognito user status (CONFIRMED, FORCE_CHANGE_PASSWORD, ...)
+	Enabled           *bool  `json:"enabled,omitempty"`           // Only enabled (true) or disabled (false) users
+	Role              string `json:"role,omitempty"`              // Matches User.Role
+	TenantID          string `json:"tenantId,omitempty"`          // Matches custom:tenantId
+	ServiceProviderID string `json:"serviceProviderId,omitempty"` // Matches custom:serviceProviderId
+}
+
+// matches reports whether user passes every set field of f.
+func (f UserFilter) matches(user *User) bool {
+	if f.EmailPrefix != "" && !strings.HasPrefix(strings.ToLower(user.Email), strings.ToLower(f.EmailPrefix)) {
+		return false
+	}
+	if f.Status != "" && !strings.EqualFold(user.UserStatus, f.Status) {
+		return false
+	}
+	if f.Enabled != nil && user.Enabled != *f.Enabled {
+		return false
+	}
+	if f.Role != "" && user.Role != f.Role {
+		return false
+	}
+	if f.TenantID != "" && user.TenantID != f.TenantID {
+		return false
+	}
+	if f.ServiceProviderID != "" && user.ServiceProviderID != f.ServiceProviderID {
+		return false
+	}
+	return true
+}
+
+// fingerprint identifies the filter a cursor was issued for.
+func (f UserFilter) fingerprint() string {
+	data, _ := json.Marshal(f)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// ListUsersOptions configures ListUsersPage.
+type ListUsersOptions struct {
+	Limit  int        // Users per page (defaults to 20, at most 60)
+	Cursor string     // NextCursor of the previous page; empty for the first page
+	Filter UserFilter // Must stay the same across the pages of one listing
+}
+
+// UserPage is one page of a ListUsersPage listing.
+type UserPage struct {
+	Users      []*User `json:"users"`
+	NextCursor string  `json:"nextCursor,omitempty"` // Empty on the last page
+}
+
+// FilteredUserLister is an optional UserStore extension for backends that can
+// filter listings server-side. Implementations may apply only part of the
+// filter; ListUsersPage re-checks every result.
+type FilteredUserLister interface {
+	ListUsersFiltered(ctx context.Context, limit int, pageToken string, filter UserFilter) ([]*UserRecord, string, error)
+}
+
+// userCursor is the decoded form of UserPage.NextCursor: the store page token
+// to resume from and how many records of that page were already consumed.
+type userCursor struct {
+	PageToken string `json:"t,omitempty"`
+	Skip      int    `json:"s,omitempty"`
+	Filter    string `json:"f"`
+}
+
+func encodeUserCursor(c userCursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeUserCursor(cursor string) (userCursor, error) {
+	var c userCursor
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err == nil {
+		err = json.Unmarshal(data, &c)
+	}
+	if err != nil || c.Skip < 0 {
+		return userCursor{}, fmt.Errorf("malformed cursor: %w", ErrInvalidInput)
+	}
+	return c, nil
+}
+
+// listUsersFetchSize is how many records are requested from the store per
+// call; Cognito's maximum, so client-side filters need few round trips.
+const listUsersFetchSize = 60
+
+// listUsersMaxFetches bounds the store pages one ListUsersPage call reads, so
+// a selective client-side filter cannot walk the whole pool in one call.
+const listUsersMaxFetches = 5
+
+// ListUsersPage returns one page of users matching opts.Filter, plus an opaque
+// cursor for the next page. Unlike ListUsers, each page resumes from the
+// backend's pagination token instead of re-walking the pool.
+//
+// Email prefix, status and enabled filters are sent to Cognito (one per
+// request, as Cognito allows); role, tenant and service provider filters use
+// custom attributes Cognito cannot filter on and are applied here. A call
+// reads at most listUsersMaxFetches store pages, so a page can come back
+// short, or even empty, with a NextCursor: callers must page until
+// NextCursor is empty rather than stop at a short page.
+func (m *Manager) ListUsersPage(ctx context.Context, opts ListUsersOptions) (*UserPage, error) {
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = 20
+	}
+	if limit > 60 {
+		limit = 60
+	}
+
+	fingerprint := opts.Filter.fingerprint()
+	cursor := userCursor{Filter: fingerprint}
+	if opts.Cursor != "" {
+		var err error
+		if cursor, err = decodeUserCursor(opts.Cursor); err != nil {
+			return nil, err
+		}
+		if cursor.Filter != fingerprint {
+			return nil, fmt.Errorf("cursor was issued for a different filter: %w", ErrInvalidInput)
+		}
+	}
+
+	store, err := m.userStore()
+	if err != nil {
+		return nil, err
+	}
+	list := store.ListUsers
+	if filtered, ok := store.(FilteredUserLister); ok {
+		list = func(ctx context.Context, limit int, pageToken string) ([]*UserRecord, string, error) {
+			return filtered.ListUsersFiltered(ctx, limit, pageToken, opts.Filter)
+		}
+	}
+
+	roleAttr := m.roleAttributeName()
+	page := &UserPage{Users: []*User{}}
+	for fetches := 1; ; fetches++ {
+		records, nextToken, err := list(ctx, listUsersFetchSize, cursor.PageToken)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := cursor.Skip; i < len(records); i++ {
+			user, err := recordToUser(records[i], roleAttr)
+			if err != nil || !opts.Filter.matches(user) {
+				continue
+			}
+			page.Users = append(page.Users, user)
+
+			if len(page.Users) == limit {
+				// Resume after this record, within the same store page if it
+				// has more records left.
+				if i+1 < len(records) {
+					page.NextCursor = encodeUserCursor(userCursor{PageToken: cursor.PageToken, Skip: i + 1, Filter: fingerprint})
+				} else if nextToken != "" {
+					page.NextCursor = encodeUserCursor(userCursor{PageToken: nextToken, Filter: fingerprint})
+				}
+				return page, nil
+			}
+		}
+
+		if nextToken == "" {
+			return page, nil
+		}
+		cursor = userCursor{PageToken: nextToken, Filter: fingerprint}
+		if fetches == listUsersMaxFetches {
+			page.NextCursor = encodeUserCursor(cursor)
+			return page, nil
+		}
+	}
+}
+
+// cognitoUserFilter translates the part of f that Cognito can evaluate into
+// ListUsers filter syntax. Cognito accepts a single expression, so the most
+// selective supported field wins; "" means no server-side filter.
+func cognitoUserFilter(f UserFilter) string {
+	switch {
+	case f.EmailPrefix != "":
+		return fmt.Sprintf(`email ^= "%s"`, escapeCognitoFilterValue(strings.ToLower(f.EmailPrefix)))
+	case f.Status != "":
+		return fmt.Sprintf(`cognito:user_status = "%s"`, escapeCognitoFilterValue(strings.ToUpper(f.Status)))
+	case f.Enabled != nil && *f.Enabled:
+		return `status = "Enabled"`
+	case f.Enabled != nil:
+		return `status = "Disabled"`
+	}
+	return ""
+}
+
+var cognitoFilterEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+func escapeCognitoFilterValue(value string) string {
+	return cognitoFilterEscaper.Replace(value)
+}
@@ -0,0 +1,269 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider"
+	"github.com/aws/aws-sdk-go-v2/service/cognitoidentityprovider/types"
+)
+
+// mockPagingCognitoClient serves ListUsers from fixed pages keyed by
+// pagination token and records every request.
+type mockPagingCognitoClient struct {
+	mockUserMgmtCognitoClient
+	pages    map[string]*cognitoidentityprovider.ListUsersOutput
+	requests []*cognitoidentityprovider.ListUsersInput
+}
+
+func (m *mockPagingCognitoClient) ListUsers(ctx context.Context, params *cognitoidentityprovider.ListUsersInput, optFns ...func(*cognitoidentityprovider.Options)) (*cognitoidentityprovider.ListUsersOutput, error) {
+	m.requests = append(m.requests, params)
+	return m.pages[aws.ToString(params.PaginationToken)], nil
+}
+
+func cognitoTestUser(email, role string) types.UserType {
+	return types.UserType{
+		Username: aws.String(email),
+		Attributes: []types.AttributeType{
+			{Name: aws.String("email"), Value: aws.String(email)},
+			{Name: aws.String("custom:role"), Value: aws.String(role)},
+		},
+		UserStatus: types.UserStatusTypeConfirmed,
+		Enabled:    true,
+	}
+}
+
+func TestListUsersPage_WalksAllPagesOnce(t *testing.T) {
+	ctx := context.Background()
+	m := NewManager(&OAuthConfig{}, WithUserStore(NewMemoryUserStore()))
+
+	var want []string
+	for i := 0; i < 130; i++ {
+		email := fmt.Sprintf("user%03d@example.com", i)
+		tenant := "globex"
+		if i%3 == 0 {
+			tenant = "acme"
+			want = append(want, email)
+		}
+		if _, err := m.CreateUser(ctx, CreateUserRequest{Email: email, CustomAttributes: map[string]string{"tenantId": tenant}}); err != nil {
+			t.Fatalf("CreateUser: %v", err)
+		}
+	}
+
+	var got []string
+	opts := ListUsersOptions{Limit: 7, Filter: UserFilter{TenantID: "acme"}}
+	for pages := 0; ; pages++ {
+		if pages > 20 {
+			t.Fatal("pagination did not terminate")
+		}
+		page, err := m.ListUsersPage(ctx, opts)
+		if err != nil {
+			t.Fatalf("ListUsersPage: %v", err)
+		}
+		if len(page.Users) > 7 {
+			t.Fatalf("page exceeds limit: %d users", len(page.Users))
+		}
+		for _, u := range page.Users {
+			got = append(got, u.Email)
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		opts.Cursor = page.NextCursor
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("expected %d users, got %d", len(want), len(got))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("user %d: expected %s, got %s", i, want[i], got[i])
+		}
+	}
+}
+
+func TestListUsersPage_Filters(t *testing.T) {
+	ctx := context.Background()
+	m := NewManager(&OAuthConfig{}, WithUserStore(NewMemoryUserStore()))
+
+	for _, u := range []CreateUserRequest{
+		{Email: "alice@example.com", Role: "admin", CustomAttributes: map[string]string{"tenantId": "acme", "serviceProviderId": "sp1"}},
+		{Email: "alex@example.com", Role: "user", CustomAttributes: map[string]string{"tenantId": "acme"}},
+		{Email: "bob@example.com", Role: "admin"},
+	} {
+		if _, err := m.CreateUser(ctx, u); err != nil {
+			t.Fatalf("CreateUser: %v", err)
+		}
+	}
+	if err := m.DisableUser(ctx, "alex@example.com"); err != nil {
+		t.Fatalf("DisableUser: %v", err)
+	}
+
+	enabled, disabled := true, false
+	tests := []struct {
+		name   string
+		filter UserFilter
+		want   []string
+	}{
+		{"email prefix", UserFilter{EmailPrefix: "AL"}, []string{"alex@example.com", "alice@example.com"}},
+		{"role", UserFilter{Role: "admin"}, []string{"alice@example.com", "bob@example.com"}},
+		{"enabled", UserFilter{Enabled: &enabled}, []string{"alice@example.com", "bob@example.com"}},
+		{"disabled", UserFilter{Enabled: &disabled}, []string{"alex@example.com"}},
+		{"tenant and role", UserFilter{TenantID: "acme", Role: "admin"}, []string{"alice@example.com"}},
+		{"service provider", UserFilter{ServiceProviderID: "sp1"}, []string{"alice@example.com"}},
+		{"status", UserFilter{Status: "force_change_password"}, []string{"alex@example.com", "alice@example.com", "bob@example.com"}},
+		{"no match", UserFilter{Status: "CONFIRMED"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := m.ListUsersPage(ctx, ListUsersOptions{Filter: tt.filter})
+			if err != nil {
+				t.Fatalf("ListUsersPage: %v", err)
+			}
+			var got []string
+			for _, u := range page.Users {
+				got = append(got, u.Email)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestListUsersPage_RejectsBadCursors(t *testing.T) {
+	ctx := context.Background()
+	m := NewManager(&OAuthConfig{}, WithUserStore(NewMemoryUserStore()))
+	for i := 0; i < 3; i++ {
+		m.CreateUser(ctx, CreateUserRequest{Email: fmt.Sprintf("u%d@example.com", i)})
+	}
+
+	page, err := m.ListUsersPage(ctx, ListUsersOptions{Limit: 1})
+	if err != nil || page.NextCursor == "" {
+		t.Fatalf("expected a next cursor, got %+v (%v)", page, err)
+	}
+
+	if _, err := m.ListUsersPage(ctx, ListUsersOptions{Cursor: page.NextCursor, Filter: UserFilter{Role: "admin"}}); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("expected ErrInvalidInput for a cursor from another filter, got %v", err)
+	}
+	if _, err := m.ListUsersPage(ctx, ListUsersOptions{Cursor: "not a cursor!"}); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("expected ErrInvalidInput for a malformed cursor, got %v", err)
+	}
+}
+
+func TestListUsersPage_CognitoFilterAndToken(t *testing.T) {
+	client := &mockPagingCognitoClient{pages: map[string]*cognitoidentityprovider.ListUsersOutput{
+		"": {
+			Users:           []types.UserType{cognitoTestUser("alice@example.com", "admin"), cognitoTestUser("alan@example.com", "user")},
+			PaginationToken: aws.String("page-2"),
+		},
+		"page-2": {
+			Users: []types.UserType{cognitoTestUser("albert@example.com", "admin")},
+		},
+	}}
+	m := NewManager(&OAuthConfig{UserPoolID: "pool", Region: "us-east-1"}, WithCognitoClient(client))
+	ctx := context.Background()
+
+	opts := ListUsersOptions{Limit: 1, Filter: UserFilter{EmailPrefix: "Al", Role: "admin"}}
+	page, err := m.ListUsersPage(ctx, opts)
+	if err != nil {
+		t.Fatalf("ListUsersPage: %v", err)
+	}
+	if len(page.Users) != 1 || page.Users[0].Email != "alice@example.com" {
+		t.Fatalf("unexpected first page: %+v", page.Users)
+	}
+	if got := aws.ToString(client.requests[0].Filter); got != `email ^= "al"` {
+		t.Errorf("expected Cognito email prefix filter, got %q", got)
+	}
+
+	opts.Cursor = page.NextCursor
+	page, err = m.ListUsersPage(ctx, opts)
+	if err != nil {
+		t.Fatalf("ListUsersPage: %v", err)
+	}
+	if len(page.Users) != 1 || page.Users[0].Email != "albert@example.com" || page.NextCursor != "" {
+		t.Errorf("expected albert on the last page, got %+v (cursor %q)", page.Users, page.NextCursor)
+	}
+	if last := client.requests[len(client.requests)-1]; aws.ToString(last.PaginationToken) != "page-2" {
+		t.Errorf("expected the cursor to resume from Cognito's pagination token, got %q", aws.ToString(last.PaginationToken))
+	}
+	if len(client.requests) != 3 {
+		t.Errorf("expected 3 Cognito calls, got %d", len(client.requests))
+	}
+}
+
+func TestListUsersPage_BoundsStoreFetchesPerCall(t *testing.T) {
+	const storePages = 2*listUsersMaxFetches + 1
+	pages := make(map[string]*cognitoidentityprovider.ListUsersOutput)
+	for i := 0; i < storePages; i++ {
+		token := ""
+		if i > 0 {
+			token = fmt.Sprintf("page-%d", i)
+		}
+		out := &cognitoidentityprovider.ListUsersOutput{
+			Users: []types.UserType{cognitoTestUser(fmt.Sprintf("user%02d@example.com", i), "user")},
+		}
+		if i+1 < storePages {
+			out.PaginationToken = aws.String(fmt.Sprintf("page-%d", i+1))
+		}
+		pages[token] = out
+	}
+	pages["page-8"].Users = append(pages["page-8"].Users, cognitoTestUser("admin@example.com", "admin"))
+	client := &mockPagingCognitoClient{pages: pages}
+	m := NewManager(&OAuthConfig{UserPoolID: "pool", Region: "us-east-1"}, WithCognitoClient(client))
+	ctx := context.Background()
+
+	var got []string
+	opts := ListUsersOptions{Filter: UserFilter{Role: "admin"}}
+	for calls := 1; ; calls++ {
+		before := len(client.requests)
+		page, err := m.ListUsersPage(ctx, opts)
+		if err != nil {
+			t.Fatalf("ListUsersPage: %v", err)
+		}
+		if n := len(client.requests) - before; n > listUsersMaxFetches {
+			t.Fatalf("call %d read %d store pages, expected at most %d", calls, n, listUsersMaxFetches)
+		}
+		for _, u := range page.Users {
+			got = append(got, u.Email)
+		}
+		if page.NextCursor == "" {
+			if calls != 3 {
+				t.Errorf("expected the listing to take 3 calls, got %d", calls)
+			}
+			break
+		}
+		opts.Cursor = page.NextCursor
+	}
+
+	if fmt.Sprint(got) != "[admin@example.com]" {
+		t.Errorf("expected only admin@example.com, got %v", got)
+	}
+	if len(client.requests) != storePages {
+		t.Errorf("expected each store page to be read once, got %d reads", len(client.requests))
+	}
+}
+
+func TestCognitoUserFilter(t *testing.T) {
+	enabled, disabled := true, false
+	tests := []struct {
+		filter UserFilter
+		want   string
+	}{
+		{UserFilter{}, ""},
+		{UserFilter{EmailPrefix: `Al"ice`}, `email ^= "al\"ice"`},
+		{UserFilter{Status: "force_change_password"}, `cognito:user_status = "FORCE_CHANGE_PASSWORD"`},
+		{UserFilter{Enabled: &enabled}, `status = "Enabled"`},
+		{UserFilter{Enabled: &disabled}, `status = "Disabled"`},
+		{UserFilter{Role: "admin", TenantID: "acme"}, ""},
+		{UserFilter{EmailPrefix: "a", Status: "CONFIRMED"}, `email ^= "a"`},
+	}
+	for _, tt := range tests {
+		if got := cognitoUserFilter(tt.filter); got != tt.want {
+			t.Errorf("%+v: expected %q, got %q", tt.filter, tt.want, got)
+		}
+	}
+}